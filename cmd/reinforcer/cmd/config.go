@@ -0,0 +1,343 @@
+// MIT License
+//
+// Copyright (c) 2021 Christian Sueiras
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/clear-street/reinforcer/internal/generator"
+	"github.com/clear-street/reinforcer/internal/generator/executor"
+	"github.com/clear-street/reinforcer/internal/generator/method"
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// configFileNames are the names of the config files searched for in the working directory, its parents and the home
+// directory
+var configFileNames = []string{".reinforcer.yaml", ".reinforcer.yml"}
+
+// envPrefix is the prefix of the environment variables that can be used in lieu of the flags (e.g. REINFORCER_OUTPKG)
+const envPrefix = "reinforcer"
+
+// settings are the effective settings for an invocation. They're sourced from (in order of precedence) the command line
+// flags, the environment variables, the config file and the flag defaults.
+type settings struct {
+	Sources           []string `yaml:"src"`
+	SourcePackages    []string `yaml:"srcpkg"`
+	Targets           []string `yaml:"target"`
+	TargetsAll        bool     `yaml:"targetall"`
+	OutPkg            string   `yaml:"outpkg"`
+	OutputDir         string   `yaml:"outputdir"`
+	generator.Options `yaml:",inline"`
+	Debug             bool   `yaml:"debug"`
+	Silent            bool   `yaml:"silent"`
+	Jobs              []*job `yaml:"jobs,omitempty"`
+}
+
+// job is a single code generation job in the config file, every job generates its own output package. The outpkg and
+// the generation options default to the top level settings when not given.
+type job struct {
+	Sources           []string `yaml:"src,omitempty" mapstructure:"src"`
+	SourcePackages    []string `yaml:"srcpkg,omitempty" mapstructure:"srcpkg"`
+	Targets           []string `yaml:"target,omitempty" mapstructure:"target"`
+	TargetsAll        bool     `yaml:"targetall,omitempty" mapstructure:"targetall"`
+	OutPkg            string   `yaml:"outpkg,omitempty" mapstructure:"outpkg"`
+	OutputDir         string   `yaml:"outputdir" mapstructure:"outputdir"`
+	generator.Options `yaml:",inline" mapstructure:",squash"`
+}
+
+// loadConfig binds the command's flags and the environment variables to the given viper instance and reads the config
+// file, the path to the config file that was read is returned (empty if none was found).
+func loadConfig(v *viper.Viper, cmd *cobra.Command) (string, error) {
+	if err := v.BindPFlags(cmd.Flags()); err != nil {
+		return "", err
+	}
+	v.SetEnvPrefix(envPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	v.AutomaticEnv()
+
+	cfgFile := v.GetString("config")
+	if cfgFile == "" {
+		var err error
+		if cfgFile, err = findConfigFile(); err != nil {
+			return "", err
+		}
+		if cfgFile == "" {
+			return "", nil
+		}
+	}
+
+	// The config file is read apart so that its relative paths can be resolved before they're merged with the flags and
+	// the environment variables
+	cfg := viper.New()
+	cfg.SetConfigFile(cfgFile)
+	if err := cfg.ReadInConfig(); err != nil {
+		return "", fmt.Errorf("failed to read config file %s; error=%w", cfgFile, err)
+	}
+	cfgDir := filepath.Dir(cfgFile)
+	values := cfg.AllSettings()
+	resolvePaths(values, cfgDir)
+	if jobs, ok := values["jobs"].([]interface{}); ok {
+		for _, j := range jobs {
+			if job, ok := j.(map[string]interface{}); ok {
+				resolvePaths(job, cfgDir)
+			}
+		}
+	}
+	if err := v.MergeConfigMap(values); err != nil {
+		return "", fmt.Errorf("failed to read config file %s; error=%w", cfgFile, err)
+	}
+	return cfgFile, nil
+}
+
+// resolvePaths resolves the relative src, srcpkg and outputdir paths of the given config values against the directory
+// of the config file. Only the source packages given as a relative path (e.g. ./service) are resolved, the others are
+// import paths.
+func resolvePaths(values map[string]interface{}, dir string) {
+	resolve := func(key string, isPath func(string) bool) {
+		switch value := values[key].(type) {
+		case string:
+			if isPath(value) {
+				values[key] = filepath.Join(dir, value)
+			}
+		case []interface{}:
+			for idx, elem := range value {
+				if s, ok := elem.(string); ok && isPath(s) {
+					value[idx] = filepath.Join(dir, s)
+				}
+			}
+		}
+	}
+	isRelative := func(path string) bool {
+		return path != "" && !filepath.IsAbs(path)
+	}
+	resolve("src", isRelative)
+	resolve("outputdir", isRelative)
+	resolve("srcpkg", func(pkg string) bool {
+		return pkg == "." || pkg == ".." || strings.HasPrefix(pkg, "./") || strings.HasPrefix(pkg, "../")
+	})
+}
+
+// readSettings reads the effective settings from the given viper instance
+func readSettings(v *viper.Viper) (*settings, error) {
+	s := &settings{
+		Sources:        getStringSlice(v, "src"),
+		SourcePackages: getStringSlice(v, "srcpkg"),
+		Targets:        getStringSlice(v, "target"),
+		TargetsAll:     v.GetBool("targetall"),
+		OutPkg:         v.GetString("outpkg"),
+		OutputDir:      v.GetString("outputdir"),
+		Debug:          v.GetBool("debug"),
+		Silent:         v.GetBool("silent"),
+	}
+	if err := v.Unmarshal(&s.Options); err != nil {
+		return nil, fmt.Errorf("failed to read settings; error=%w", err)
+	}
+	s.IdempotentMethods = getStringSlice(v, "idempotent")
+
+	jobs, err := readJobs(v, s.Options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jobs; error=%w", err)
+	}
+	s.Jobs = jobs
+	return s, nil
+}
+
+// readJobs reads the jobs of the config file, the generation options that aren't given for a job default to the given
+// options
+func readJobs(v *viper.Viper, defaults generator.Options) ([]*job, error) {
+	values, ok := v.Get("jobs").([]interface{})
+	if !ok {
+		// There's nothing to default, a malformed jobs setting fails to be decoded
+		var jobs []*job
+		err := v.UnmarshalKey("jobs", &jobs)
+		return jobs, err
+	}
+
+	defaultValues := optionValues(defaults)
+	jobValues := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		if options, ok := value.(map[string]interface{}); ok {
+			merged := make(map[string]interface{}, len(options)+len(defaultValues))
+			for key, value := range defaultValues {
+				merged[key] = value
+			}
+			for key, value := range options {
+				merged[key] = value
+			}
+			value = merged
+		}
+		jobValues = append(jobValues, value)
+	}
+
+	// The merged jobs are decoded apart so that the config isn't altered
+	jv := viper.New()
+	jv.Set("jobs", jobValues)
+	var jobs []*job
+	if err := jv.UnmarshalKey("jobs", &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// optionValues maps the keys of the given generation options in the config file to their values
+func optionValues(opts generator.Options) map[string]interface{} {
+	v := reflect.ValueOf(opts)
+	values := make(map[string]interface{}, v.NumField())
+	for idx := 0; idx < v.NumField(); idx++ {
+		values[v.Type().Field(idx).Tag.Get("mapstructure")] = v.Field(idx).Interface()
+	}
+	return values
+}
+
+// targetsOverridden checks whether the sources or the targets were given with the flags or the environment variables
+func targetsOverridden(cmd *cobra.Command) bool {
+	for _, key := range []string{"src", "srcpkg", "target", "targetall"} {
+		if cmd.Flags().Changed(key) {
+			return true
+		}
+		if _, ok := os.LookupEnv(strings.ToUpper(envPrefix + "_" + key)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// jobParameters creates the executor parameters for the jobs in the settings, the output directories of the jobs are
+// returned in the same order
+func jobParameters(s *settings) ([]*executor.Parameters, []string, error) {
+	if len(s.Sources)+len(s.SourcePackages)+len(s.Targets) > 0 || s.TargetsAll {
+		return nil, nil, fmt.Errorf("src, srcpkg, target and targetall can't be combined with jobs")
+	}
+
+	var err error
+	params := make([]*executor.Parameters, 0, len(s.Jobs))
+	outDirs := make([]string, 0, len(s.Jobs))
+	for idx, j := range s.Jobs {
+		if len(j.Sources)+len(j.SourcePackages) == 0 {
+			return nil, nil, fmt.Errorf("no source provided for job=%d", idx)
+		}
+		if len(j.Targets) == 0 && !j.TargetsAll {
+			return nil, nil, fmt.Errorf("no targets provided for job=%d", idx)
+		}
+		if j.OutputDir == "" {
+			return nil, nil, fmt.Errorf("no output directory provided for job=%d", idx)
+		}
+		p := &executor.Parameters{
+			Sources:        nonNil(j.Sources),
+			SourcePackages: nonNil(j.SourcePackages),
+			Targets:        nonNil(j.Targets),
+			TargetsAll:     j.TargetsAll,
+			OutPkg:         j.OutPkg,
+			Options:        j.Options,
+		}
+		if p.OutPkg == "" {
+			p.OutPkg = s.OutPkg
+		}
+		p.IdempotentMethods = nonNil(p.IdempotentMethods)
+		if p.ErrorResult, err = method.ParseErrorResultRule(string(j.ErrorResult)); err != nil {
+			return nil, nil, fmt.Errorf("invalid errorresult for job=%d; error=%w", idx, err)
+		}
+		params = append(params, p)
+		outDirs = append(outDirs, j.OutputDir)
+	}
+	return params, outDirs, nil
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// getStringSlice reads a list setting, lists given through environment variables are comma separated
+func getStringSlice(v *viper.Viper, key string) []string {
+	var values []string
+	if s, ok := v.Get(key).(string); ok {
+		for _, value := range strings.Split(s, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	} else {
+		values = v.GetStringSlice(key)
+	}
+	return nonNil(values)
+}
+
+// findConfigFile searches for the config file in the working directory and its parents, then in the home directory
+func findConfigFile() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		if cfgFile := configFileIn(dir); cfgFile != "" {
+			return cfgFile, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return configFileIn(home), nil
+}
+
+func configFileIn(dir string) string {
+	for _, name := range configFileNames {
+		cfgFile := filepath.Join(dir, name)
+		if info, err := os.Stat(cfgFile); err == nil && !info.IsDir() {
+			return cfgFile
+		}
+	}
+	return ""
+}
+
+// printSettings writes the effective settings as YAML, the output can be used as a config file
+func printSettings(w io.Writer, cfgFile string, s *settings) error {
+	if cfgFile != "" {
+		if _, err := fmt.Fprintf(w, "# config file: %s\n", cfgFile); err != nil {
+			return err
+		}
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(s); err != nil {
+		return fmt.Errorf("failed to print settings; error=%w", err)
+	}
+	return enc.Close()
+}
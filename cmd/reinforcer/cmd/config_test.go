@@ -0,0 +1,366 @@
+package cmd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/clear-street/reinforcer/cmd/reinforcer/cmd"
+	"github.com/clear-street/reinforcer/cmd/reinforcer/cmd/mocks"
+	"github.com/clear-street/reinforcer/internal/generator"
+	"github.com/clear-street/reinforcer/internal/generator/executor"
+	"github.com/clear-street/reinforcer/internal/generator/method"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `
+src:
+  - /path/to/target.go
+target:
+  - Client
+outpkg: resilient
+outputdir: ./resilient
+ignorenoret: true
+`
+
+func TestRootCommand_Config(t *testing.T) {
+	gen := &generator.Generated{}
+
+	writeConfig := func(t *testing.T, dir string) string {
+		filename := filepath.Join(dir, ".reinforcer.yaml")
+		require.NoError(t, os.WriteFile(filename, []byte(testConfig), 0600))
+		return filename
+	}
+	chdir := func(t *testing.T, dir string) {
+		wd, err := os.Getwd()
+		require.NoError(t, err)
+		require.NoError(t, os.Chdir(dir))
+		t.Cleanup(func() {
+			require.NoError(t, os.Chdir(wd))
+		})
+	}
+
+	t.Run("Config flag", func(t *testing.T) {
+		exec := &mocks.Executor{}
+		exec.On("Execute", &executor.Parameters{
+			Sources:        []string{"/path/to/target.go"},
+			SourcePackages: []string{},
+			Targets:        []string{"Client"},
+			TargetsAll:     false,
+			OutPkg:         "resilient",
+			Options: generator.Options{
+				IgnoreNoReturnMethods: true,
+				ErrorResult:           method.LastErrorResult,
+				IdempotentMethods:     []string{},
+				ContextVariants:       false,
+				AbandonOnContextDone:  false,
+				ErrorVariants:         false,
+				Hedging:               false,
+				Tracing:               false,
+				Observer:              false,
+				Logging:               false,
+				RecoverPanics:         false,
+			},
+		}).Return(gen, nil)
+		dir := t.TempDir()
+		writ := &mocks.Writer{}
+		writ.On("Write", filepath.Join(dir, "resilient"), gen).Return(nil)
+
+		c := cmd.NewRootCmd(exec, writ)
+		c.SetArgs([]string{"--config=" + writeConfig(t, dir), "--silent"})
+		require.NoError(t, c.Execute())
+	})
+
+	t.Run("Config file in a parent directory", func(t *testing.T) {
+		root, err := filepath.EvalSymlinks(t.TempDir())
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(root, ".reinforcer.yaml"), []byte(`
+src:
+  - ./service/target.go
+srcpkg:
+  - ./service
+  - github.com/clear-street/somelib
+target:
+  - Client
+outpkg: resilient
+outputdir: ./resilient
+ignorenoret: true
+`), 0600))
+		sub := filepath.Join(root, "internal", "service")
+		require.NoError(t, os.MkdirAll(sub, 0700))
+		chdir(t, sub)
+
+		exec := &mocks.Executor{}
+		exec.On("Execute", &executor.Parameters{
+			Sources:        []string{filepath.Join(root, "service", "target.go")},
+			SourcePackages: []string{filepath.Join(root, "service"), "github.com/clear-street/somelib"},
+			Targets:        []string{"Client"},
+			TargetsAll:     false,
+			OutPkg:         "resilient",
+			Options: generator.Options{
+				IgnoreNoReturnMethods: true,
+				ErrorResult:           method.LastErrorResult,
+				IdempotentMethods:     []string{},
+				ContextVariants:       false,
+				AbandonOnContextDone:  false,
+				ErrorVariants:         false,
+				Hedging:               false,
+				Tracing:               false,
+				Observer:              false,
+				Logging:               false,
+				RecoverPanics:         false,
+			},
+		}).Return(gen, nil)
+		writ := &mocks.Writer{}
+		writ.On("Write", filepath.Join(root, "resilient"), gen).Return(nil)
+
+		c := cmd.NewRootCmd(exec, writ)
+		c.SetArgs([]string{"--silent"})
+		require.NoError(t, c.Execute())
+	})
+
+	t.Run("Flags take precedence over environment variables and the config file", func(t *testing.T) {
+		t.Setenv("REINFORCER_TARGET", "Service,Store")
+		t.Setenv("REINFORCER_OUTPKG", "fromenv")
+
+		exec := &mocks.Executor{}
+		exec.On("Execute", &executor.Parameters{
+			Sources:        []string{"/path/to/target.go"},
+			SourcePackages: []string{},
+			Targets:        []string{"Service", "Store"},
+			TargetsAll:     false,
+			OutPkg:         "fromflag",
+			Options: generator.Options{
+				IgnoreNoReturnMethods: true,
+				ErrorResult:           method.LastErrorResult,
+				IdempotentMethods:     []string{},
+				ContextVariants:       false,
+				AbandonOnContextDone:  false,
+				ErrorVariants:         false,
+				Hedging:               false,
+				Tracing:               false,
+				Observer:              false,
+				Logging:               false,
+				RecoverPanics:         false,
+			},
+		}).Return(gen, nil)
+		dir := t.TempDir()
+		writ := &mocks.Writer{}
+		writ.On("Write", filepath.Join(dir, "resilient"), gen).Return(nil)
+
+		c := cmd.NewRootCmd(exec, writ)
+		c.SetArgs([]string{"--config=" + writeConfig(t, dir), "--outpkg=fromflag", "--silent"})
+		require.NoError(t, c.Execute())
+	})
+
+	t.Run("Print config", func(t *testing.T) {
+		t.Setenv("REINFORCER_SRCPKG", "github.com/clear-street/somelib")
+		dir := t.TempDir()
+		filename := writeConfig(t, dir)
+
+		b := bytes.NewBufferString("")
+		c := cmd.NewRootCmd(&mocks.Executor{}, &mocks.Writer{})
+		c.SetOut(b)
+		c.SetArgs([]string{"--config=" + filename, "--targetall", "--print-config"})
+		require.NoError(t, c.Execute())
+		require.Equal(t, "# config file: "+filename+`
+src:
+  - /path/to/target.go
+srcpkg:
+  - github.com/clear-street/somelib
+target:
+  - Client
+targetall: true
+outpkg: resilient
+outputdir: `+filepath.Join(dir, "resilient")+`
+ignorenoret: true
+ignorepromoted: false
+errorresult: last
+idempotent: []
+ctxvariants: false
+abandon: false
+errvariants: false
+hedging: false
+tracing: false
+observer: false
+logging: false
+recover: false
+debug: false
+silent: false
+`, b.String())
+	})
+
+	t.Run("Invalid config file", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), ".reinforcer.yaml")
+		require.NoError(t, os.WriteFile(filename, []byte("src: [\n"), 0600))
+
+		c := cmd.NewRootCmd(&mocks.Executor{}, &mocks.Writer{})
+		c.SetArgs([]string{"--config=" + filename})
+		require.Error(t, c.Execute())
+	})
+
+	t.Run("Jobs", func(t *testing.T) {
+		dir := t.TempDir()
+		filename := filepath.Join(dir, ".reinforcer.yaml")
+		require.NoError(t, os.WriteFile(filename, []byte(`
+outpkg: resilient
+ignorenoret: true
+idempotent: [GetUser]
+abandon: true
+hedging: true
+observer: true
+recover: true
+jobs:
+  - src: [./service/client.go]
+    target: [Client]
+    outputdir: ./service/resilient
+  - srcpkg: [github.com/clear-street/somelib]
+    targetall: true
+    outpkg: somelib
+    outputdir: ./somelib/reinforced
+    ignorenoret: false
+    errvariants: true
+    errorresult: first
+    idempotent: [Client.ListUsers]
+    ctxvariants: true
+    hedging: false
+    tracing: true
+    observer: false
+    logging: true
+    recover: false
+`), 0600))
+
+		gens := []*generator.Generated{{}, {}}
+		exec := &mocks.Executor{}
+		exec.On("ExecuteAll", []*executor.Parameters{
+			{
+				Sources:        []string{filepath.Join(dir, "service", "client.go")},
+				SourcePackages: []string{},
+				Targets:        []string{"Client"},
+				TargetsAll:     false,
+				OutPkg:         "resilient",
+				Options: generator.Options{
+					IgnoreNoReturnMethods: true,
+					ErrorResult:           method.LastErrorResult,
+					IdempotentMethods:     []string{"GetUser"},
+					ContextVariants:       false,
+					AbandonOnContextDone:  true,
+					ErrorVariants:         false,
+					Hedging:               true,
+					Tracing:               false,
+					Observer:              true,
+					Logging:               false,
+					RecoverPanics:         true,
+				},
+			},
+			{
+				Sources:        []string{},
+				SourcePackages: []string{"github.com/clear-street/somelib"},
+				Targets:        []string{},
+				TargetsAll:     true,
+				OutPkg:         "somelib",
+				Options: generator.Options{
+					IgnoreNoReturnMethods: false,
+					ErrorResult:           method.FirstErrorResult,
+					IdempotentMethods:     []string{"Client.ListUsers"},
+					ContextVariants:       true,
+					AbandonOnContextDone:  true,
+					ErrorVariants:         true,
+					Hedging:               false,
+					Tracing:               true,
+					Observer:              false,
+					Logging:               true,
+					RecoverPanics:         false,
+				},
+			},
+		}).Return(gens, nil)
+		writ := &mocks.Writer{}
+		writ.On("Write", filepath.Join(dir, "service", "resilient"), gens[0]).Return(nil).Once()
+		writ.On("Write", filepath.Join(dir, "somelib", "reinforced"), gens[1]).Return(nil).Once()
+
+		c := cmd.NewRootCmd(exec, writ)
+		c.SetArgs([]string{"--config=" + filename, "--silent"})
+		require.NoError(t, c.Execute())
+		exec.AssertExpectations(t)
+		writ.AssertExpectations(t)
+	})
+
+	t.Run("Flags take precedence over jobs", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), ".reinforcer.yaml")
+		require.NoError(t, os.WriteFile(filename, []byte(`
+src: [./client.go]
+jobs:
+  - src: [./service/client.go]
+    target: [Client]
+    outputdir: ./service/resilient
+`), 0600))
+
+		exec := &mocks.Executor{}
+		exec.On("Execute", &executor.Parameters{
+			Sources:        []string{"/path/to/target.go"},
+			SourcePackages: []string{},
+			Targets:        []string{"Service"},
+			TargetsAll:     false,
+			OutPkg:         "reinforced",
+			Options: generator.Options{
+				IgnoreNoReturnMethods: false,
+				ErrorResult:           method.LastErrorResult,
+				IdempotentMethods:     []string{},
+				ContextVariants:       false,
+				AbandonOnContextDone:  false,
+				ErrorVariants:         false,
+				Hedging:               false,
+				Tracing:               false,
+				Observer:              false,
+				Logging:               false,
+				RecoverPanics:         false,
+			},
+		}).Return(gen, nil)
+		writ := &mocks.Writer{}
+		writ.On("Write", "./reinforced", gen).Return(nil)
+
+		c := cmd.NewRootCmd(exec, writ)
+		c.SetArgs([]string{"--config=" + filename, "--src=/path/to/target.go", "--target=Service", "--silent"})
+		require.NoError(t, c.Execute())
+		exec.AssertExpectations(t)
+	})
+
+	t.Run("Invalid jobs", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			config string
+			args   []string
+			err    string
+		}{
+			"Combined with targets": {
+				config: "target: [Client]\njobs:\n  - src: [./client.go]\n    targetall: true\n    outputdir: ./reinforced\n",
+				err:    "src, srcpkg, target and targetall can't be combined with jobs",
+			},
+			"No sources": {
+				config: "jobs:\n  - targetall: true\n    outputdir: ./reinforced\n",
+				err:    "no source provided for job=0",
+			},
+			"No targets": {
+				config: "jobs:\n  - src: [./client.go]\n    outputdir: ./reinforced\n",
+				err:    "no targets provided for job=0",
+			},
+			"No output directory": {
+				config: "jobs:\n  - src: [./client.go]\n    targetall: true\n",
+				err:    "no output directory provided for job=0",
+			},
+			"Invalid error result": {
+				config: "jobs:\n  - src: [./client.go]\n    targetall: true\n    outputdir: ./reinforced\n    errorresult: middle\n",
+				err:    "invalid errorresult for job=0; error=unknown error result rule=middle, must be one of last or first",
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				filename := filepath.Join(t.TempDir(), ".reinforcer.yaml")
+				require.NoError(t, os.WriteFile(filename, []byte(tc.config), 0600))
+
+				c := cmd.NewRootCmd(&mocks.Executor{}, &mocks.Writer{})
+				c.SetArgs(append([]string{"--config=" + filename, "--silent"}, tc.args...))
+				require.EqualError(t, c.Execute(), tc.err)
+			})
+		}
+	})
+}
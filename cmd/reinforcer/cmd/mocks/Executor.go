@@ -36,3 +36,26 @@ func (_m *Executor) Execute(settings *executor.Parameters) (*generator.Generated
 
 	return r0, r1
 }
+
+// ExecuteAll provides a mock function with given fields: jobs
+func (_m *Executor) ExecuteAll(jobs []*executor.Parameters) ([]*generator.Generated, error) {
+	ret := _m.Called(jobs)
+
+	var r0 []*generator.Generated
+	if rf, ok := ret.Get(0).(func([]*executor.Parameters) []*generator.Generated); ok {
+		r0 = rf(jobs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*generator.Generated)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]*executor.Parameters) error); ok {
+		r1 = rf(jobs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -31,9 +31,9 @@ import (
 
 	"github.com/clear-street/reinforcer/internal/generator"
 	"github.com/clear-street/reinforcer/internal/generator/executor"
+	"github.com/clear-street/reinforcer/internal/generator/method"
 	"github.com/clear-street/reinforcer/internal/loader"
 	"github.com/clear-street/reinforcer/internal/writer"
-	"github.com/mitchellh/go-homedir"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
@@ -42,7 +42,6 @@ import (
 
 // Version will be set in CI to the current released version
 var Version = "0.0.0"
-var cfgFile string
 
 // Writer describes the code generator writer
 type Writer interface {
@@ -52,6 +51,7 @@ type Writer interface {
 // Executor describes the code generator executor
 type Executor interface {
 	Execute(settings *executor.Parameters) (*generator.Generated, error)
+	ExecuteAll(jobs []*executor.Parameters) ([]*generator.Generated, error)
 }
 
 // DefaultRootCmd creates the default root command with its dependencies wired in
@@ -69,36 +69,48 @@ will automatically inject middleware. Middlewares provide resiliency constructs
 such as circuit breaker, retries, timeouts, etc.
 `,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			flags := cmd.Flags()
-			if showVersion, _ := flags.GetBool("version"); showVersion {
+			if showVersion, _ := cmd.Flags().GetBool("version"); showVersion {
 				fmt.Println(Version)
 				return nil
 			}
 
+			v := viper.New()
+			cfgFile, err := loadConfig(v, cmd)
+			if err != nil {
+				return err
+			}
+			s, err := readSettings(v)
+			if err != nil {
+				return err
+			}
+
+			if printConfig, _ := cmd.Flags().GetBool("print-config"); printConfig {
+				return printSettings(cmd.OutOrStdout(), cfgFile, s)
+			}
+
 			zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 			log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 
-			debug, _ := flags.GetBool("debug")
-			silent, _ := flags.GetBool("silent")
-
 			// Default level for this example is info, unless debug flag is present (or logging is disabled)
 			zerolog.SetGlobalLevel(zerolog.InfoLevel)
-			if debug {
+			if s.Debug {
 				zerolog.SetGlobalLevel(zerolog.DebugLevel)
 			}
-			if silent {
+			if s.Silent {
 				zerolog.SetGlobalLevel(zerolog.Disabled)
 			}
-
-			sources, err := flags.GetStringSlice("src")
-			if err != nil {
-				return err
+			if cfgFile != "" {
+				log.Info().Msgf("Using config file: %s", cfgFile)
 			}
-			sourcePackages, err := flags.GetStringSlice("srcpkg")
-			if err != nil {
-				return err
+
+			// The sources and the targets given explicitly with the flags or the environment variables take precedence over
+			// the jobs of the config file
+			if len(s.Jobs) > 0 && !targetsOverridden(cmd) {
+				return runJobs(exec, writ, s)
 			}
-			if len(sources)+len(sourcePackages) == 0 {
+
+			sources := s.Sources
+			if len(sources)+len(s.SourcePackages) == 0 {
 				goFile := os.Getenv("GOFILE")
 				if goFile == "" {
 					return fmt.Errorf("no source provided")
@@ -110,54 +122,37 @@ such as circuit breaker, retries, timeouts, etc.
 				}
 				sources = append(sources, path.Join(defSrcFile, goFile))
 			}
-
-			targetAll, err := flags.GetBool("targetall")
-			if err != nil {
-				return err
-			}
-			targets, err := flags.GetStringSlice("target")
-			if err != nil {
-				return err
-			}
-			if len(targets) == 0 && !targetAll {
+			if len(s.Targets) == 0 && !s.TargetsAll {
 				return fmt.Errorf("no targets provided")
 			}
-			outPkg, err := flags.GetString("outpkg")
-			if err != nil {
-				return err
-			}
-			outDir, err := flags.GetString("outputdir")
-			if err != nil {
-				return err
-			}
-			ignoreNoRet, err := flags.GetBool("ignorenoret")
-			if err != nil {
+
+			opts := s.Options
+			if opts.ErrorResult, err = method.ParseErrorResultRule(string(opts.ErrorResult)); err != nil {
 				return err
 			}
 
 			gen, err := exec.Execute(&executor.Parameters{
-				Sources:               sources,
-				SourcePackages:        sourcePackages,
-				Targets:               targets,
-				TargetsAll:            targetAll,
-				OutPkg:                outPkg,
-				IgnoreNoReturnMethods: ignoreNoRet,
+				Sources:        sources,
+				SourcePackages: s.SourcePackages,
+				Targets:        s.Targets,
+				TargetsAll:     s.TargetsAll,
+				OutPkg:         s.OutPkg,
+				Options:        opts,
 			})
 			if err != nil {
 				return fmt.Errorf("failed to generate code; error=%w", err)
 			}
-			if err := writ.Write(outDir, gen); err != nil {
+			if err := writ.Write(s.OutputDir, gen); err != nil {
 				return fmt.Errorf("failed to save generated code; error=%w", err)
 			}
 			return nil
 		},
 	}
 
-	rootCmd.PersistentFlags().
-		StringVar(&cfgFile, "config", "", "config file (default is $HOME/.reinforcer.yaml)")
-
 	flags := rootCmd.Flags()
+	flags.String("config", "", "config file (default is the first .reinforcer.yaml found in the working directory, its parents or $HOME)")
 	flags.BoolP("version", "v", false, "show reinforcer's version")
+	flags.Bool("print-config", false, "prints the effective settings (merged from the flags, the environment variables and the config file) and exits")
 	flags.BoolP("debug", "d", false, "enables debug logs")
 	flags.BoolP("silent", "q", false, "disables logging. Mutually exclusive with the debug flag.")
 	flags.StringSliceP("src", "s", nil, "source files to scan for the target interface or struct. If unspecified the file pointed by the env variable GOFILE will be used.")
@@ -167,10 +162,39 @@ such as circuit breaker, retries, timeouts, etc.
 	flags.StringP("outputdir", "o", "./reinforced", "directory to write the generated code to")
 	flags.StringP("outpkg", "p", "reinforced", "name of generated package")
 	flags.BoolP("ignorenoret", "i", false, "ignores methods that don't return anything (they won't be wrapped in the middleware). By default they'll be wrapped in a middleware and if the middleware emits an error the call will panic.")
+	flags.Bool("ignorepromoted", false, "ignores methods promoted from embedded types, only the methods declared directly on the target types are generated.")
+	flags.StringSlice("idempotent", nil, "methods that are safe to be retried in addition to the ones annotated with //reinforcer:idempotent, either the method's name (GetUser) or qualified with the type (Client.GetUser).")
+	flags.Bool("ctxvariants", false, "generates a context variant of every wrapped method that doesn't receive a context (e.g. GetCtx(ctx, key) for Get(key)), the context is handed to the middlewares.")
+	flags.Bool("abandon", false, "runs the calls to methods that don't receive a context in their own goroutine which is abandoned (and keeps running) once the middlewares' context is done, the call returns context.DeadlineExceeded.")
+	flags.Bool("errvariants", false, "generates an error variant of every wrapped method that doesn't return anything (e.g. NotifyE(msg) error for Notify(msg)) that returns the errors emitted by the middlewares instead of handing them to the no return error handler.")
+	flags.Bool("hedging", false, "keeps the results of the attempts of a call apart so that the attempts can run concurrently with the hedge middleware (see runner.NewHedgeMiddleware), the generated code imports the runner package.")
+	flags.Bool("tracing", false, "traces every call with OpenTelemetry, a call produces a span with a child span per attempt (see the tracing package), the generated code imports the tracing package.")
+	flags.Bool("observer", false, "hands every call and its attempts to the observer configured with the generated WithObserver option, including the errors classified as non-retryable.")
+	flags.Bool("logging", false, "logs the retries, the non-retryable errors and the rejections of the calls with the logger configured with the generated WithLogger option (see the logging package), the generated code imports the logging package.")
+	flags.Bool("recover", false, "recovers the panics of the delegates into a *PanicError that is handed to the error predicate and the middlewares like any other error returned by the delegates.")
+	flags.String("errorresult", string(method.LastErrorResult), "rule that selects which result of a method is the error handed to the middlewares, either last (the last result if it's an error) or first (the first result that is an error). Results annotated with //reinforcer:error=<result> take precedence.")
 
 	return rootCmd
 }
 
+// runJobs generates and saves the code for every job in the settings
+func runJobs(exec Executor, writ Writer, s *settings) error {
+	params, outDirs, err := jobParameters(s)
+	if err != nil {
+		return err
+	}
+	gens, err := exec.ExecuteAll(params)
+	if err != nil {
+		return fmt.Errorf("failed to generate code; error=%w", err)
+	}
+	for idx, gen := range gens {
+		if err := writ.Write(outDirs[idx], gen); err != nil {
+			return fmt.Errorf("failed to save generated code; error=%w", err)
+		}
+	}
+	return nil
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -179,33 +203,3 @@ func Execute() {
 		os.Exit(1)
 	}
 }
-
-func init() {
-	cobra.OnInitialize(initConfig)
-}
-
-// initConfig reads in config file and ENV variables if set.
-func initConfig() {
-	if cfgFile != "" {
-		// Use config file from the flag.
-		viper.SetConfigFile(cfgFile)
-	} else {
-		// Find home directory.
-		home, err := homedir.Dir()
-		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
-		}
-
-		// Search config in home directory with name ".reinforcer" (without extension).
-		viper.AddConfigPath(home)
-		viper.SetConfigName(".reinforcer")
-	}
-
-	viper.AutomaticEnv() // read in environment variables that match
-
-	// If a config file is found, read it in.
-	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
-	}
-}
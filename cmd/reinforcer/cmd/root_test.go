@@ -8,6 +8,7 @@ import (
 	"github.com/clear-street/reinforcer/cmd/reinforcer/cmd/mocks"
 	"github.com/clear-street/reinforcer/internal/generator"
 	"github.com/clear-street/reinforcer/internal/generator/executor"
+	"github.com/clear-street/reinforcer/internal/generator/method"
 	"github.com/stretchr/testify/require"
 )
 
@@ -17,12 +18,24 @@ func TestRootCommand(t *testing.T) {
 	t.Run("Provide Targets", func(t *testing.T) {
 		exec := &mocks.Executor{}
 		exec.On("Execute", &executor.Parameters{
-			Sources:               []string{"/path/to/target.go"},
-			SourcePackages:        []string{},
-			Targets:               []string{"Client", "SomeOtherClient"},
-			TargetsAll:            false,
-			OutPkg:                "reinforced",
-			IgnoreNoReturnMethods: false,
+			Sources:        []string{"/path/to/target.go"},
+			SourcePackages: []string{},
+			Targets:        []string{"Client", "SomeOtherClient"},
+			TargetsAll:     false,
+			OutPkg:         "reinforced",
+			Options: generator.Options{
+				IgnoreNoReturnMethods: false,
+				ErrorResult:           method.LastErrorResult,
+				IdempotentMethods:     []string{},
+				ContextVariants:       false,
+				AbandonOnContextDone:  false,
+				ErrorVariants:         false,
+				Hedging:               false,
+				Tracing:               false,
+				Observer:              false,
+				Logging:               false,
+				RecoverPanics:         false,
+			},
 		}).Return(gen, nil)
 		writ := &mocks.Writer{}
 		writ.On("Write", "./reinforced", gen).Return(nil)
@@ -37,12 +50,24 @@ func TestRootCommand(t *testing.T) {
 	t.Run("Source packages", func(t *testing.T) {
 		exec := &mocks.Executor{}
 		exec.On("Execute", &executor.Parameters{
-			Sources:               []string{},
-			SourcePackages:        []string{"github.com/clear-street/somelib"},
-			Targets:               []string{"Client", "SomeOtherClient"},
-			TargetsAll:            false,
-			OutPkg:                "reinforced",
-			IgnoreNoReturnMethods: false,
+			Sources:        []string{},
+			SourcePackages: []string{"github.com/clear-street/somelib"},
+			Targets:        []string{"Client", "SomeOtherClient"},
+			TargetsAll:     false,
+			OutPkg:         "reinforced",
+			Options: generator.Options{
+				IgnoreNoReturnMethods: false,
+				ErrorResult:           method.LastErrorResult,
+				IdempotentMethods:     []string{},
+				ContextVariants:       false,
+				AbandonOnContextDone:  false,
+				ErrorVariants:         false,
+				Hedging:               false,
+				Tracing:               false,
+				Observer:              false,
+				Logging:               false,
+				RecoverPanics:         false,
+			},
 		}).Return(gen, nil)
 		writ := &mocks.Writer{}
 		writ.On("Write", "./reinforced", gen).Return(nil)
@@ -57,12 +82,24 @@ func TestRootCommand(t *testing.T) {
 	t.Run("Target All", func(t *testing.T) {
 		exec := &mocks.Executor{}
 		exec.On("Execute", &executor.Parameters{
-			Sources:               []string{"/path/to/target.go"},
-			SourcePackages:        []string{},
-			Targets:               []string{},
-			TargetsAll:            true,
-			OutPkg:                "reinforced",
-			IgnoreNoReturnMethods: false,
+			Sources:        []string{"/path/to/target.go"},
+			SourcePackages: []string{},
+			Targets:        []string{},
+			TargetsAll:     true,
+			OutPkg:         "reinforced",
+			Options: generator.Options{
+				IgnoreNoReturnMethods: false,
+				ErrorResult:           method.LastErrorResult,
+				IdempotentMethods:     []string{},
+				ContextVariants:       false,
+				AbandonOnContextDone:  false,
+				ErrorVariants:         false,
+				Hedging:               false,
+				Tracing:               false,
+				Observer:              false,
+				Logging:               false,
+				RecoverPanics:         false,
+			},
 		}).Return(gen, nil)
 		writ := &mocks.Writer{}
 		writ.On("Write", "./reinforced", gen).Return(nil)
@@ -77,12 +114,24 @@ func TestRootCommand(t *testing.T) {
 	t.Run("Ignore No Return Methods", func(t *testing.T) {
 		exec := &mocks.Executor{}
 		exec.On("Execute", &executor.Parameters{
-			Sources:               []string{"/path/to/target.go"},
-			SourcePackages:        []string{},
-			Targets:               []string{"Client", "SomeOtherClient"},
-			TargetsAll:            false,
-			OutPkg:                "reinforced",
-			IgnoreNoReturnMethods: true,
+			Sources:        []string{"/path/to/target.go"},
+			SourcePackages: []string{},
+			Targets:        []string{"Client", "SomeOtherClient"},
+			TargetsAll:     false,
+			OutPkg:         "reinforced",
+			Options: generator.Options{
+				IgnoreNoReturnMethods: true,
+				ErrorResult:           method.LastErrorResult,
+				IdempotentMethods:     []string{},
+				ContextVariants:       false,
+				AbandonOnContextDone:  false,
+				ErrorVariants:         false,
+				Hedging:               false,
+				Tracing:               false,
+				Observer:              false,
+				Logging:               false,
+				RecoverPanics:         false,
+			},
 		}).Return(gen, nil)
 		writ := &mocks.Writer{}
 		writ.On("Write", "./reinforced", gen).Return(nil)
@@ -94,15 +143,271 @@ func TestRootCommand(t *testing.T) {
 		require.NoError(t, c.Execute())
 	})
 
+	t.Run("Context Variants", func(t *testing.T) {
+		exec := &mocks.Executor{}
+		exec.On("Execute", &executor.Parameters{
+			Sources:        []string{"/path/to/target.go"},
+			SourcePackages: []string{},
+			Targets:        []string{"Client"},
+			TargetsAll:     false,
+			OutPkg:         "reinforced",
+			Options: generator.Options{
+				IgnoreNoReturnMethods: false,
+				ErrorResult:           method.LastErrorResult,
+				IdempotentMethods:     []string{},
+				ContextVariants:       true,
+				AbandonOnContextDone:  true,
+				ErrorVariants:         false,
+				Hedging:               false,
+				Tracing:               false,
+				Observer:              false,
+				Logging:               false,
+				RecoverPanics:         false,
+			},
+		}).Return(gen, nil)
+		writ := &mocks.Writer{}
+		writ.On("Write", "./reinforced", gen).Return(nil)
+
+		c := cmd.NewRootCmd(exec, writ)
+		c.SetArgs([]string{"--src=/path/to/target.go", "--target=Client", "--outputdir=./reinforced", "--ctxvariants", "--abandon"})
+		require.NoError(t, c.Execute())
+	})
+
+	t.Run("Error Variants", func(t *testing.T) {
+		exec := &mocks.Executor{}
+		exec.On("Execute", &executor.Parameters{
+			Sources:        []string{"/path/to/target.go"},
+			SourcePackages: []string{},
+			Targets:        []string{"Client"},
+			TargetsAll:     false,
+			OutPkg:         "reinforced",
+			Options: generator.Options{
+				IgnoreNoReturnMethods: false,
+				ErrorResult:           method.LastErrorResult,
+				IdempotentMethods:     []string{},
+				ContextVariants:       false,
+				AbandonOnContextDone:  false,
+				ErrorVariants:         true,
+				Hedging:               false,
+				Tracing:               false,
+				Observer:              false,
+				Logging:               false,
+				RecoverPanics:         false,
+			},
+		}).Return(gen, nil)
+		writ := &mocks.Writer{}
+		writ.On("Write", "./reinforced", gen).Return(nil)
+
+		c := cmd.NewRootCmd(exec, writ)
+		c.SetArgs([]string{"--src=/path/to/target.go", "--target=Client", "--outputdir=./reinforced", "--errvariants"})
+		require.NoError(t, c.Execute())
+	})
+
+	t.Run("Hedging", func(t *testing.T) {
+		exec := &mocks.Executor{}
+		exec.On("Execute", &executor.Parameters{
+			Sources:        []string{"/path/to/target.go"},
+			SourcePackages: []string{},
+			Targets:        []string{"Client"},
+			TargetsAll:     false,
+			OutPkg:         "reinforced",
+			Options: generator.Options{
+				IgnoreNoReturnMethods: false,
+				ErrorResult:           method.LastErrorResult,
+				IdempotentMethods:     []string{},
+				ContextVariants:       false,
+				AbandonOnContextDone:  false,
+				ErrorVariants:         false,
+				Hedging:               true,
+				Tracing:               false,
+				Observer:              false,
+				Logging:               false,
+				RecoverPanics:         false,
+			},
+		}).Return(gen, nil)
+		writ := &mocks.Writer{}
+		writ.On("Write", "./reinforced", gen).Return(nil)
+
+		c := cmd.NewRootCmd(exec, writ)
+		c.SetArgs([]string{"--src=/path/to/target.go", "--target=Client", "--outputdir=./reinforced", "--hedging"})
+		require.NoError(t, c.Execute())
+	})
+
+	t.Run("Tracing", func(t *testing.T) {
+		exec := &mocks.Executor{}
+		exec.On("Execute", &executor.Parameters{
+			Sources:        []string{"/path/to/target.go"},
+			SourcePackages: []string{},
+			Targets:        []string{"Client"},
+			TargetsAll:     false,
+			OutPkg:         "reinforced",
+			Options: generator.Options{
+				IgnoreNoReturnMethods: false,
+				ErrorResult:           method.LastErrorResult,
+				IdempotentMethods:     []string{},
+				ContextVariants:       false,
+				AbandonOnContextDone:  false,
+				ErrorVariants:         false,
+				Hedging:               false,
+				Tracing:               true,
+				Observer:              false,
+				Logging:               false,
+				RecoverPanics:         false,
+			},
+		}).Return(gen, nil)
+		writ := &mocks.Writer{}
+		writ.On("Write", "./reinforced", gen).Return(nil)
+
+		c := cmd.NewRootCmd(exec, writ)
+		c.SetArgs([]string{"--src=/path/to/target.go", "--target=Client", "--outputdir=./reinforced", "--tracing"})
+		require.NoError(t, c.Execute())
+	})
+
+	t.Run("Observer", func(t *testing.T) {
+		exec := &mocks.Executor{}
+		exec.On("Execute", &executor.Parameters{
+			Sources:        []string{"/path/to/target.go"},
+			SourcePackages: []string{},
+			Targets:        []string{"Client"},
+			TargetsAll:     false,
+			OutPkg:         "reinforced",
+			Options: generator.Options{
+				IgnoreNoReturnMethods: false,
+				ErrorResult:           method.LastErrorResult,
+				IdempotentMethods:     []string{},
+				ContextVariants:       false,
+				AbandonOnContextDone:  false,
+				ErrorVariants:         false,
+				Hedging:               false,
+				Tracing:               false,
+				Observer:              true,
+				Logging:               false,
+				RecoverPanics:         false,
+			},
+		}).Return(gen, nil)
+		writ := &mocks.Writer{}
+		writ.On("Write", "./reinforced", gen).Return(nil)
+
+		c := cmd.NewRootCmd(exec, writ)
+		c.SetArgs([]string{"--src=/path/to/target.go", "--target=Client", "--outputdir=./reinforced", "--observer"})
+		require.NoError(t, c.Execute())
+	})
+
+	t.Run("Logging", func(t *testing.T) {
+		exec := &mocks.Executor{}
+		exec.On("Execute", &executor.Parameters{
+			Sources:        []string{"/path/to/target.go"},
+			SourcePackages: []string{},
+			Targets:        []string{"Client"},
+			TargetsAll:     false,
+			OutPkg:         "reinforced",
+			Options: generator.Options{
+				IgnoreNoReturnMethods: false,
+				ErrorResult:           method.LastErrorResult,
+				IdempotentMethods:     []string{},
+				ContextVariants:       false,
+				AbandonOnContextDone:  false,
+				ErrorVariants:         false,
+				Hedging:               false,
+				Tracing:               false,
+				Observer:              false,
+				Logging:               true,
+				RecoverPanics:         false,
+			},
+		}).Return(gen, nil)
+		writ := &mocks.Writer{}
+		writ.On("Write", "./reinforced", gen).Return(nil)
+
+		c := cmd.NewRootCmd(exec, writ)
+		c.SetArgs([]string{"--src=/path/to/target.go", "--target=Client", "--outputdir=./reinforced", "--logging"})
+		require.NoError(t, c.Execute())
+	})
+
+	t.Run("Recover", func(t *testing.T) {
+		exec := &mocks.Executor{}
+		exec.On("Execute", &executor.Parameters{
+			Sources:        []string{"/path/to/target.go"},
+			SourcePackages: []string{},
+			Targets:        []string{"Client"},
+			TargetsAll:     false,
+			OutPkg:         "reinforced",
+			Options: generator.Options{
+				IgnoreNoReturnMethods: false,
+				ErrorResult:           method.LastErrorResult,
+				IdempotentMethods:     []string{},
+				ContextVariants:       false,
+				AbandonOnContextDone:  false,
+				ErrorVariants:         false,
+				Hedging:               false,
+				Tracing:               false,
+				Observer:              false,
+				Logging:               false,
+				RecoverPanics:         true,
+			},
+		}).Return(gen, nil)
+		writ := &mocks.Writer{}
+		writ.On("Write", "./reinforced", gen).Return(nil)
+
+		c := cmd.NewRootCmd(exec, writ)
+		c.SetArgs([]string{"--src=/path/to/target.go", "--target=Client", "--outputdir=./reinforced", "--recover"})
+		require.NoError(t, c.Execute())
+	})
+
+	t.Run("Error Result", func(t *testing.T) {
+		exec := &mocks.Executor{}
+		exec.On("Execute", &executor.Parameters{
+			Sources:        []string{"/path/to/target.go"},
+			SourcePackages: []string{},
+			Targets:        []string{"Client"},
+			TargetsAll:     false,
+			OutPkg:         "reinforced",
+			Options: generator.Options{
+				IgnoreNoReturnMethods: false,
+				ErrorResult:           method.FirstErrorResult,
+				IdempotentMethods:     []string{},
+				ContextVariants:       false,
+				AbandonOnContextDone:  false,
+				ErrorVariants:         false,
+				Hedging:               false,
+				Tracing:               false,
+				Observer:              false,
+				Logging:               false,
+				RecoverPanics:         false,
+			},
+		}).Return(gen, nil)
+		writ := &mocks.Writer{}
+		writ.On("Write", "./reinforced", gen).Return(nil)
+
+		c := cmd.NewRootCmd(exec, writ)
+		c.SetArgs([]string{"--src=/path/to/target.go", "--target=Client", "--outputdir=./reinforced", "--errorresult=first"})
+		require.NoError(t, c.Execute())
+
+		c = cmd.NewRootCmd(&mocks.Executor{}, &mocks.Writer{})
+		c.SetArgs([]string{"--src=/path/to/target.go", "--target=Client", "--errorresult=middle"})
+		require.EqualError(t, c.Execute(), "unknown error result rule=middle, must be one of last or first")
+	})
+
 	t.Run("No targets found", func(t *testing.T) {
 		exec := &mocks.Executor{}
 		exec.On("Execute", &executor.Parameters{
-			Sources:               []string{"/path/to/target.go"},
-			SourcePackages:        []string{},
-			Targets:               []string{},
-			TargetsAll:            true,
-			OutPkg:                "reinforced",
-			IgnoreNoReturnMethods: false,
+			Sources:        []string{"/path/to/target.go"},
+			SourcePackages: []string{},
+			Targets:        []string{},
+			TargetsAll:     true,
+			OutPkg:         "reinforced",
+			Options: generator.Options{
+				IgnoreNoReturnMethods: false,
+				ErrorResult:           method.LastErrorResult,
+				IdempotentMethods:     []string{},
+				ContextVariants:       false,
+				AbandonOnContextDone:  false,
+				ErrorVariants:         false,
+				Hedging:               false,
+				Tracing:               false,
+				Observer:              false,
+				Logging:               false,
+				RecoverPanics:         false,
+			},
 		}).Return(nil, executor.ErrNoTargetableTypesFound)
 		writ := &mocks.Writer{}
 		writ.On("Write", "./reinforced", gen).Return(nil)
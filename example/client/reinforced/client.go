@@ -4,15 +4,55 @@ package reinforced
 
 import "context"
 
+// ClientMethods are the methods in Client
+var ClientMethods = struct {
+	GenerateGreeting string
+	SayHello         string
+}{
+	GenerateGreeting: "GenerateGreeting",
+	SayHello:         "SayHello",
+}
+
+// ClientIdempotentMethods lists the methods in Client that are safe to be retried
+var ClientIdempotentMethods = []string{}
+
+// ClientDescriptor describes Client and its methods
+var ClientDescriptor = &TypeDescriptor{
+	methods: []MethodDescriptor{
+		{
+			HasContext:   true,
+			Idempotent:   false,
+			Name:         ClientMethods.GenerateGreeting,
+			Position:     "github.com/clear-street/reinforcer/example/client/client.go:22",
+			ReturnsError: true,
+			Signature:    "GenerateGreeting(ctx context.Context, name string) (string, error)",
+			Strategy:     "retryable",
+		},
+		{
+			HasContext:   true,
+			Idempotent:   false,
+			Name:         ClientMethods.SayHello,
+			Position:     "github.com/clear-street/reinforcer/example/client/client.go:21",
+			ReturnsError: true,
+			Signature:    "SayHello(ctx context.Context, name string) error",
+			Strategy:     "retryable",
+		},
+	},
+	name: "Client",
+}
+
 type targetClient interface {
-	GenerateGreeting(ctx context.Context, arg1 string) (string, error)
-	SayHello(ctx context.Context, arg1 string) error
+	GenerateGreeting(ctx context.Context, name string) (string, error)
+	SayHello(ctx context.Context, name string) error
 }
+
+// Client wraps a Client delegate, calls to its methods run through the middlewares built by the runner factory
 type Client struct {
 	*base
 	delegate targetClient
 }
 
+// NewClient creates a Client that reinforces the given delegate with the middlewares built by the runner factory
 func NewClient(delegate targetClient, runnerFactory runnerFactory, options ...Option) *Client {
 	if delegate == nil {
 		panic("provided nil delegate")
@@ -22,8 +62,12 @@ func NewClient(delegate targetClient, runnerFactory runnerFactory, options ...Op
 	}
 	c := &Client{
 		base: &base{
-			errorPredicate: RetryAllErrors,
-			runnerFactory:  runnerFactory,
+			errorPredicate:           RetryAllErrors,
+			noReturnErrorHandler:     PanicOnNoReturnError,
+			nonRetryableErrorHandler: IgnoreNonRetryableError,
+			runnerFactory:            runnerFactory,
+			runnerName:               QualifiedRunnerName,
+			typeName:                 "Client",
 		},
 		delegate: delegate,
 	}
@@ -32,36 +76,78 @@ func NewClient(delegate targetClient, runnerFactory runnerFactory, options ...Op
 	}
 	return c
 }
-func (c *Client) GenerateGreeting(ctx context.Context, arg1 string) (string, error) {
+
+// ClientIdempotentRunnerNames returns the names of the runners of the idempotent methods of the given Client, the names
+// are built like the names of the runners its calls run through (see runner.Factory.WithIdempotentMethods)
+func ClientIdempotentRunnerNames(c *Client) []string {
+	names := make([]string, 0, len(ClientIdempotentMethods))
+	for _, name := range ClientIdempotentMethods {
+		names = append(names, c.runnerFor(name))
+	}
+	return names
+}
+
+// WithClientGenerateGreetingFallback configures the fallback of Client.GenerateGreeting, the fallback is called with the error when the middlewares return an error and its results are returned to the caller
+func WithClientGenerateGreetingFallback(fn func(context.Context, error) (string, error)) Option {
+	return func(o *base) {
+		if o.fallbacks == nil {
+			o.fallbacks = map[string]interface{}{}
+		}
+		o.fallbacks["Client.GenerateGreeting"] = fn
+	}
+}
+
+// WithClientSayHelloFallback configures the fallback of Client.SayHello, the fallback is called with the error when the middlewares return an error and its results are returned to the caller
+func WithClientSayHelloFallback(fn func(context.Context, error) error) Option {
+	return func(o *base) {
+		if o.fallbacks == nil {
+			o.fallbacks = map[string]interface{}{}
+		}
+		o.fallbacks["Client.SayHello"] = fn
+	}
+}
+func (c *Client) GenerateGreeting(ctx context.Context, name string) (string, error) {
 	var nonRetryableErr error
 	var r0 string
 	err := c.run(ctx, ClientMethods.GenerateGreeting, func(ctx context.Context) error {
 		var err error
-		r0, err = c.delegate.GenerateGreeting(ctx, arg1)
+		r0, err = c.delegate.GenerateGreeting(ctx, name)
 		if c.errorPredicate(ClientMethods.GenerateGreeting, err) {
 			return err
 		}
+		c.nonRetryableErrorHandler(ctx, ClientMethods.GenerateGreeting, err)
 		nonRetryableErr = err
 		return nil
 	})
 	if nonRetryableErr != nil {
 		return r0, nonRetryableErr
 	}
+	if err != nil {
+		if fallback, ok := c.fallbacks["Client.GenerateGreeting"].(func(context.Context, error) (string, error)); ok {
+			return fallback(ctx, err)
+		}
+	}
 	return r0, err
 }
-func (c *Client) SayHello(ctx context.Context, arg1 string) error {
+func (c *Client) SayHello(ctx context.Context, name string) error {
 	var nonRetryableErr error
 	err := c.run(ctx, ClientMethods.SayHello, func(ctx context.Context) error {
 		var err error
-		err = c.delegate.SayHello(ctx, arg1)
+		err = c.delegate.SayHello(ctx, name)
 		if c.errorPredicate(ClientMethods.SayHello, err) {
 			return err
 		}
+		c.nonRetryableErrorHandler(ctx, ClientMethods.SayHello, err)
 		nonRetryableErr = err
 		return nil
 	})
 	if nonRetryableErr != nil {
 		return nonRetryableErr
 	}
+	if err != nil {
+		if fallback, ok := c.fallbacks["Client.SayHello"].(func(context.Context, error) error); ok {
+			return fallback(ctx, err)
+		}
+	}
 	return err
 }
@@ -8,8 +8,14 @@ import (
 )
 
 type base struct {
-	errorPredicate func(string, error) bool
-	runnerFactory  runnerFactory
+	typeName                 string
+	errorPredicate           func(string, error) bool
+	runnerFactory            runnerFactory
+	runnerName               func(string, string) string
+	runners                  map[string]string
+	noReturnErrorHandler     func(string, error)
+	nonRetryableErrorHandler func(context.Context, string, error)
+	fallbacks                map[string]interface{}
 }
 type runnerFactory interface {
 	GetRunner(name string) goresilience.Runner
@@ -18,6 +24,16 @@ type runnerFactory interface {
 var RetryAllErrors = func(_ string, _ error) bool {
 	return true
 }
+var QualifiedRunnerName = func(typeName string, method string) string {
+	return typeName + "." + method
+}
+var MethodRunnerName = func(_ string, method string) string {
+	return method
+}
+var PanicOnNoReturnError = func(_ string, err error) {
+	panic(err)
+}
+var IgnoreNonRetryableError = func(_ context.Context, _ string, _ error) {}
 
 type Option func(*base)
 
@@ -26,6 +42,78 @@ func WithRetryableErrorPredicate(fn func(string, error) bool) Option {
 		o.errorPredicate = fn
 	}
 }
+func WithNoReturnErrorHandler(fn func(string, error)) Option {
+	return func(o *base) {
+		o.noReturnErrorHandler = fn
+	}
+}
+func WithNonRetryableErrorHandler(fn func(context.Context, string, error)) Option {
+	return func(o *base) {
+		o.nonRetryableErrorHandler = fn
+	}
+}
+func WithRunnerName(fn func(string, string) string) Option {
+	return func(o *base) {
+		o.runnerName = fn
+	}
+}
+func (b *base) runnerFor(name string) string {
+	if runner, ok := b.runners[name]; ok {
+		return runner
+	}
+	return b.runnerName(b.typeName, name)
+}
 func (b *base) run(ctx context.Context, name string, fn func(ctx context.Context) error) error {
-	return b.runnerFactory.GetRunner(name).Run(ctx, fn)
+	return b.runnerFactory.GetRunner(b.runnerFor(name)).Run(ctx, fn)
+}
+
+// MethodDescriptor describes a method of a generated type
+type MethodDescriptor struct {
+	// Name is the name of the method
+	Name string
+	// Signature is the signature of the method in the source type
+	Signature string
+	// HasContext is set when the method receives a context.Context
+	HasContext bool
+	// ReturnsError is set when the method returns the error that is handed to the middlewares
+	ReturnsError bool
+	// Strategy is how the calls to the method are proxied: retryable, noreturn or passthrough
+	Strategy string
+	// Idempotent is set when the method is safe to be retried
+	Idempotent bool
+	// Position is the position of the method in the source (e.g. github.com/org/pkg/client.go:12)
+	Position string
+}
+
+// TypeDescriptor describes a generated type and its methods
+type TypeDescriptor struct {
+	name    string
+	methods []MethodDescriptor
+}
+
+// Name is the name of the generated type
+func (d *TypeDescriptor) Name() string {
+	return d.name
+}
+
+// Methods returns the descriptors of the type's methods
+func (d *TypeDescriptor) Methods() []MethodDescriptor {
+	methods := make([]MethodDescriptor, len(d.methods))
+	copy(methods, d.methods)
+	return methods
+}
+
+// Method returns the descriptor of the method with the given name
+func (d *TypeDescriptor) Method(name string) (MethodDescriptor, bool) {
+	for _, m := range d.methods {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return MethodDescriptor{}, false
+}
+
+// Descriptors returns the descriptors of all the generated types
+func Descriptors() []*TypeDescriptor {
+	return []*TypeDescriptor{ClientDescriptor, ServiceDescriptor, SomeOtherClientDescriptor}
 }
@@ -1,34 +0,0 @@
-// Code generated by reinforcer, DO NOT EDIT.
-
-package reinforced
-
-// ClientMethods are the methods in Client
-var ClientMethods = struct {
-	GenerateGreeting string
-	SayHello         string
-}{
-	GenerateGreeting: "GenerateGreeting",
-	SayHello:         "SayHello",
-}
-
-// ServiceMethods are the methods in Service
-var ServiceMethods = struct {
-	GetData string
-}{
-	GetData: "GetData",
-}
-
-// SomeOtherClientMethods are the methods in SomeOtherClient
-var SomeOtherClientMethods = struct {
-	DoStuff            string
-	GetUser            string
-	MethodWithChannel  string
-	MethodWithWildcard string
-	SaveFile           string
-}{
-	DoStuff:            "DoStuff",
-	GetUser:            "GetUser",
-	MethodWithChannel:  "MethodWithChannel",
-	MethodWithWildcard: "MethodWithWildcard",
-	SaveFile:           "SaveFile",
-}
@@ -4,14 +4,44 @@ package reinforced
 
 import "context"
 
+// ServiceMethods are the methods in Service
+var ServiceMethods = struct {
+	GetData string
+}{
+	GetData: "GetData",
+}
+
+// ServiceIdempotentMethods lists the methods in Service that are safe to be retried
+var ServiceIdempotentMethods = []string{}
+
+// ServiceDescriptor describes Service and its methods
+var ServiceDescriptor = &TypeDescriptor{
+	methods: []MethodDescriptor{
+		{
+			HasContext:   false,
+			Idempotent:   false,
+			Name:         ServiceMethods.GetData,
+			Position:     "github.com/clear-street/reinforcer/example/client/client.go:38",
+			ReturnsError: true,
+			Signature:    "GetData() ([]byte, error)",
+			Strategy:     "retryable",
+		},
+	},
+	name: "Service",
+}
+
 type targetService interface {
+	// GetData retrieves data it might randomly error out
 	GetData() ([]byte, error)
 }
+
+// Service wraps a Service delegate, calls to its methods run through the middlewares built by the runner factory
 type Service struct {
 	*base
 	delegate targetService
 }
 
+// NewService creates a Service that reinforces the given delegate with the middlewares built by the runner factory
 func NewService(delegate targetService, runnerFactory runnerFactory, options ...Option) *Service {
 	if delegate == nil {
 		panic("provided nil delegate")
@@ -21,8 +51,12 @@ func NewService(delegate targetService, runnerFactory runnerFactory, options ...
 	}
 	c := &Service{
 		base: &base{
-			errorPredicate: RetryAllErrors,
-			runnerFactory:  runnerFactory,
+			errorPredicate:           RetryAllErrors,
+			noReturnErrorHandler:     PanicOnNoReturnError,
+			nonRetryableErrorHandler: IgnoreNonRetryableError,
+			runnerFactory:            runnerFactory,
+			runnerName:               QualifiedRunnerName,
+			typeName:                 "Service",
 		},
 		delegate: delegate,
 	}
@@ -31,20 +65,48 @@ func NewService(delegate targetService, runnerFactory runnerFactory, options ...
 	}
 	return c
 }
+
+// ServiceIdempotentRunnerNames returns the names of the runners of the idempotent methods of the given Service, the names
+// are built like the names of the runners its calls run through (see runner.Factory.WithIdempotentMethods)
+func ServiceIdempotentRunnerNames(s *Service) []string {
+	names := make([]string, 0, len(ServiceIdempotentMethods))
+	for _, name := range ServiceIdempotentMethods {
+		names = append(names, s.runnerFor(name))
+	}
+	return names
+}
+
+// WithServiceGetDataFallback configures the fallback of Service.GetData, the fallback is called with the error when the middlewares return an error and its results are returned to the caller
+func WithServiceGetDataFallback(fn func(context.Context, error) ([]byte, error)) Option {
+	return func(o *base) {
+		if o.fallbacks == nil {
+			o.fallbacks = map[string]interface{}{}
+		}
+		o.fallbacks["Service.GetData"] = fn
+	}
+}
+
+// GetData retrieves data it might randomly error out
 func (s *Service) GetData() ([]byte, error) {
 	var nonRetryableErr error
 	var r0 []byte
-	err := s.run(context.Background(), ServiceMethods.GetData, func(_ context.Context) error {
+	err := s.run(context.Background(), ServiceMethods.GetData, func(ctx context.Context) error {
 		var err error
 		r0, err = s.delegate.GetData()
 		if s.errorPredicate(ServiceMethods.GetData, err) {
 			return err
 		}
+		s.nonRetryableErrorHandler(ctx, ServiceMethods.GetData, err)
 		nonRetryableErr = err
 		return nil
 	})
 	if nonRetryableErr != nil {
 		return r0, nonRetryableErr
 	}
+	if err != nil {
+		if fallback, ok := s.fallbacks["Service.GetData"].(func(context.Context, error) ([]byte, error)); ok {
+			return fallback(context.Background(), err)
+		}
+	}
 	return r0, err
 }
@@ -9,18 +9,91 @@ import (
 	"os"
 )
 
+// SomeOtherClientMethods are the methods in SomeOtherClient
+var SomeOtherClientMethods = struct {
+	DoStuff            string
+	GetUser            string
+	MethodWithChannel  string
+	MethodWithWildcard string
+	SaveFile           string
+}{
+	DoStuff:            "DoStuff",
+	GetUser:            "GetUser",
+	MethodWithChannel:  "MethodWithChannel",
+	MethodWithWildcard: "MethodWithWildcard",
+	SaveFile:           "SaveFile",
+}
+
+// SomeOtherClientIdempotentMethods lists the methods in SomeOtherClient that are safe to be retried
+var SomeOtherClientIdempotentMethods = []string{}
+
+// SomeOtherClientDescriptor describes SomeOtherClient and its methods
+var SomeOtherClientDescriptor = &TypeDescriptor{
+	methods: []MethodDescriptor{
+		{
+			HasContext:   false,
+			Idempotent:   false,
+			Name:         SomeOtherClientMethods.DoStuff,
+			Position:     "github.com/clear-street/reinforcer/example/client/client.go:27",
+			ReturnsError: true,
+			Signature:    "DoStuff() error",
+			Strategy:     "retryable",
+		},
+		{
+			HasContext:   true,
+			Idempotent:   false,
+			Name:         SomeOtherClientMethods.GetUser,
+			Position:     "github.com/clear-street/reinforcer/example/client/client.go:29",
+			ReturnsError: true,
+			Signature:    "GetUser(ctx context.Context) (*sub.User, error)",
+			Strategy:     "retryable",
+		},
+		{
+			HasContext:   false,
+			Idempotent:   false,
+			Name:         SomeOtherClientMethods.MethodWithChannel,
+			Position:     "github.com/clear-street/reinforcer/example/client/client.go:30",
+			ReturnsError: true,
+			Signature:    "MethodWithChannel(myChan <-chan bool) error",
+			Strategy:     "retryable",
+		},
+		{
+			HasContext:   false,
+			Idempotent:   false,
+			Name:         SomeOtherClientMethods.MethodWithWildcard,
+			Position:     "github.com/clear-street/reinforcer/example/client/client.go:31",
+			ReturnsError: false,
+			Signature:    "MethodWithWildcard(arg interface{})",
+			Strategy:     "noreturn",
+		},
+		{
+			HasContext:   false,
+			Idempotent:   false,
+			Name:         SomeOtherClientMethods.SaveFile,
+			Position:     "github.com/clear-street/reinforcer/example/client/client.go:28",
+			ReturnsError: true,
+			Signature:    "SaveFile(myFile *client.File, osFile *os.File) error",
+			Strategy:     "retryable",
+		},
+	},
+	name: "SomeOtherClient",
+}
+
 type targetSomeOtherClient interface {
 	DoStuff() error
 	GetUser(ctx context.Context) (*sub.User, error)
-	MethodWithChannel(arg0 <-chan bool) error
-	MethodWithWildcard(arg0 interface{})
-	SaveFile(arg0 *client.File, arg1 *os.File) error
+	MethodWithChannel(myChan <-chan bool) error
+	MethodWithWildcard(arg any)
+	SaveFile(myFile *client.File, osFile *os.File) error
 }
+
+// SomeOtherClient wraps a SomeOtherClient delegate, calls to its methods run through the middlewares built by the runner factory
 type SomeOtherClient struct {
 	*base
 	delegate targetSomeOtherClient
 }
 
+// NewSomeOtherClient creates a SomeOtherClient that reinforces the given delegate with the middlewares built by the runner factory
 func NewSomeOtherClient(delegate targetSomeOtherClient, runnerFactory runnerFactory, options ...Option) *SomeOtherClient {
 	if delegate == nil {
 		panic("provided nil delegate")
@@ -30,8 +103,12 @@ func NewSomeOtherClient(delegate targetSomeOtherClient, runnerFactory runnerFact
 	}
 	c := &SomeOtherClient{
 		base: &base{
-			errorPredicate: RetryAllErrors,
-			runnerFactory:  runnerFactory,
+			errorPredicate:           RetryAllErrors,
+			noReturnErrorHandler:     PanicOnNoReturnError,
+			nonRetryableErrorHandler: IgnoreNonRetryableError,
+			runnerFactory:            runnerFactory,
+			runnerName:               QualifiedRunnerName,
+			typeName:                 "SomeOtherClient",
 		},
 		delegate: delegate,
 	}
@@ -40,20 +117,76 @@ func NewSomeOtherClient(delegate targetSomeOtherClient, runnerFactory runnerFact
 	}
 	return c
 }
+
+// SomeOtherClientIdempotentRunnerNames returns the names of the runners of the idempotent methods of the given SomeOtherClient, the names
+// are built like the names of the runners its calls run through (see runner.Factory.WithIdempotentMethods)
+func SomeOtherClientIdempotentRunnerNames(s *SomeOtherClient) []string {
+	names := make([]string, 0, len(SomeOtherClientIdempotentMethods))
+	for _, name := range SomeOtherClientIdempotentMethods {
+		names = append(names, s.runnerFor(name))
+	}
+	return names
+}
+
+// WithSomeOtherClientDoStuffFallback configures the fallback of SomeOtherClient.DoStuff, the fallback is called with the error when the middlewares return an error and its results are returned to the caller
+func WithSomeOtherClientDoStuffFallback(fn func(context.Context, error) error) Option {
+	return func(o *base) {
+		if o.fallbacks == nil {
+			o.fallbacks = map[string]interface{}{}
+		}
+		o.fallbacks["SomeOtherClient.DoStuff"] = fn
+	}
+}
+
+// WithSomeOtherClientGetUserFallback configures the fallback of SomeOtherClient.GetUser, the fallback is called with the error when the middlewares return an error and its results are returned to the caller
+func WithSomeOtherClientGetUserFallback(fn func(context.Context, error) (*sub.User, error)) Option {
+	return func(o *base) {
+		if o.fallbacks == nil {
+			o.fallbacks = map[string]interface{}{}
+		}
+		o.fallbacks["SomeOtherClient.GetUser"] = fn
+	}
+}
+
+// WithSomeOtherClientMethodWithChannelFallback configures the fallback of SomeOtherClient.MethodWithChannel, the fallback is called with the error when the middlewares return an error and its results are returned to the caller
+func WithSomeOtherClientMethodWithChannelFallback(fn func(context.Context, error) error) Option {
+	return func(o *base) {
+		if o.fallbacks == nil {
+			o.fallbacks = map[string]interface{}{}
+		}
+		o.fallbacks["SomeOtherClient.MethodWithChannel"] = fn
+	}
+}
+
+// WithSomeOtherClientSaveFileFallback configures the fallback of SomeOtherClient.SaveFile, the fallback is called with the error when the middlewares return an error and its results are returned to the caller
+func WithSomeOtherClientSaveFileFallback(fn func(context.Context, error) error) Option {
+	return func(o *base) {
+		if o.fallbacks == nil {
+			o.fallbacks = map[string]interface{}{}
+		}
+		o.fallbacks["SomeOtherClient.SaveFile"] = fn
+	}
+}
 func (s *SomeOtherClient) DoStuff() error {
 	var nonRetryableErr error
-	err := s.run(context.Background(), SomeOtherClientMethods.DoStuff, func(_ context.Context) error {
+	err := s.run(context.Background(), SomeOtherClientMethods.DoStuff, func(ctx context.Context) error {
 		var err error
 		err = s.delegate.DoStuff()
 		if s.errorPredicate(SomeOtherClientMethods.DoStuff, err) {
 			return err
 		}
+		s.nonRetryableErrorHandler(ctx, SomeOtherClientMethods.DoStuff, err)
 		nonRetryableErr = err
 		return nil
 	})
 	if nonRetryableErr != nil {
 		return nonRetryableErr
 	}
+	if err != nil {
+		if fallback, ok := s.fallbacks["SomeOtherClient.DoStuff"].(func(context.Context, error) error); ok {
+			return fallback(context.Background(), err)
+		}
+	}
 	return err
 }
 func (s *SomeOtherClient) GetUser(ctx context.Context) (*sub.User, error) {
@@ -65,52 +198,70 @@ func (s *SomeOtherClient) GetUser(ctx context.Context) (*sub.User, error) {
 		if s.errorPredicate(SomeOtherClientMethods.GetUser, err) {
 			return err
 		}
+		s.nonRetryableErrorHandler(ctx, SomeOtherClientMethods.GetUser, err)
 		nonRetryableErr = err
 		return nil
 	})
 	if nonRetryableErr != nil {
 		return r0, nonRetryableErr
 	}
+	if err != nil {
+		if fallback, ok := s.fallbacks["SomeOtherClient.GetUser"].(func(context.Context, error) (*sub.User, error)); ok {
+			return fallback(ctx, err)
+		}
+	}
 	return r0, err
 }
-func (s *SomeOtherClient) MethodWithChannel(arg0 <-chan bool) error {
+func (s *SomeOtherClient) MethodWithChannel(myChan <-chan bool) error {
 	var nonRetryableErr error
-	err := s.run(context.Background(), SomeOtherClientMethods.MethodWithChannel, func(_ context.Context) error {
+	err := s.run(context.Background(), SomeOtherClientMethods.MethodWithChannel, func(ctx context.Context) error {
 		var err error
-		err = s.delegate.MethodWithChannel(arg0)
+		err = s.delegate.MethodWithChannel(myChan)
 		if s.errorPredicate(SomeOtherClientMethods.MethodWithChannel, err) {
 			return err
 		}
+		s.nonRetryableErrorHandler(ctx, SomeOtherClientMethods.MethodWithChannel, err)
 		nonRetryableErr = err
 		return nil
 	})
 	if nonRetryableErr != nil {
 		return nonRetryableErr
 	}
+	if err != nil {
+		if fallback, ok := s.fallbacks["SomeOtherClient.MethodWithChannel"].(func(context.Context, error) error); ok {
+			return fallback(context.Background(), err)
+		}
+	}
 	return err
 }
-func (s *SomeOtherClient) MethodWithWildcard(arg0 interface{}) {
+func (s *SomeOtherClient) MethodWithWildcard(arg any) {
 	err := s.run(context.Background(), SomeOtherClientMethods.MethodWithWildcard, func(_ context.Context) error {
-		s.delegate.MethodWithWildcard(arg0)
+		s.delegate.MethodWithWildcard(arg)
 		return nil
 	})
 	if err != nil {
-		panic(err)
+		s.noReturnErrorHandler(SomeOtherClientMethods.MethodWithWildcard, err)
 	}
 }
-func (s *SomeOtherClient) SaveFile(arg0 *client.File, arg1 *os.File) error {
+func (s *SomeOtherClient) SaveFile(myFile *client.File, osFile *os.File) error {
 	var nonRetryableErr error
-	err := s.run(context.Background(), SomeOtherClientMethods.SaveFile, func(_ context.Context) error {
+	err := s.run(context.Background(), SomeOtherClientMethods.SaveFile, func(ctx context.Context) error {
 		var err error
-		err = s.delegate.SaveFile(arg0, arg1)
+		err = s.delegate.SaveFile(myFile, osFile)
 		if s.errorPredicate(SomeOtherClientMethods.SaveFile, err) {
 			return err
 		}
+		s.nonRetryableErrorHandler(ctx, SomeOtherClientMethods.SaveFile, err)
 		nonRetryableErr = err
 		return nil
 	})
 	if nonRetryableErr != nil {
 		return nonRetryableErr
 	}
+	if err != nil {
+		if fallback, ok := s.fallbacks["SomeOtherClient.SaveFile"].(func(context.Context, error) error); ok {
+			return fallback(context.Background(), err)
+		}
+	}
 	return err
 }
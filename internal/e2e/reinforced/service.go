@@ -0,0 +1,238 @@
+// Code generated by reinforcer, DO NOT EDIT.
+
+package reinforced
+
+import (
+	"context"
+	runner "github.com/clear-street/reinforcer/pkg/runner"
+)
+
+// ServiceMethods are the methods in Service
+var ServiceMethods = struct {
+	Get    string
+	Load   string
+	Submit string
+}{
+	Get:    "Get",
+	Load:   "Load",
+	Submit: "Submit",
+}
+
+// ServiceIdempotentMethods lists the methods in Service that are safe to be retried
+var ServiceIdempotentMethods = []string{}
+
+// ServiceDescriptor describes Service and its methods
+var ServiceDescriptor = &TypeDescriptor{
+	methods: []MethodDescriptor{
+		{
+			HasContext:   true,
+			Idempotent:   false,
+			Name:         ServiceMethods.Get,
+			Position:     "github.com/clear-street/reinforcer/internal/e2e/service.go:12",
+			ReturnsError: true,
+			Signature:    "Get(ctx context.Context, key string) (string, error)",
+			Strategy:     "retryable",
+		},
+		{
+			HasContext:   false,
+			Idempotent:   false,
+			Name:         ServiceMethods.Load,
+			Position:     "github.com/clear-street/reinforcer/internal/e2e/service.go:14",
+			ReturnsError: true,
+			Signature:    "Load(key string) (string, error)",
+			Strategy:     "retryable",
+		},
+		{
+			HasContext:   true,
+			Idempotent:   false,
+			Name:         ServiceMethods.Submit,
+			Position:     "github.com/clear-street/reinforcer/internal/e2e/service.go:18",
+			ReturnsError: true,
+			Signature:    "Submit(ctx context.Context, order string) error",
+			Strategy:     "retryable",
+		},
+	},
+	name: "Service",
+}
+
+type targetService interface {
+	// Get receives a context so its calls are hedged but never abandoned
+	Get(ctx context.Context, key string) (string, error)
+	// Load doesn't receive a context so its calls are abandoned once the context of the middlewares is done
+	Load(key string) (string, error)
+	// Submit isn't retried but its errors are still handed to the middlewares
+	Submit(ctx context.Context, order string) error
+}
+
+// Service wraps a Service delegate, calls to its methods run through the middlewares built by the runner factory
+type Service struct {
+	*base
+	delegate targetService
+}
+
+// NewService creates a Service that reinforces the given delegate with the middlewares built by the runner factory
+func NewService(delegate targetService, runnerFactory runnerFactory, options ...Option) *Service {
+	if delegate == nil {
+		panic("provided nil delegate")
+	}
+	if runnerFactory == nil {
+		panic("provided nil runner factory")
+	}
+	c := &Service{
+		base: &base{
+			errorPredicate:           RetryAllErrors,
+			noRetry:                  map[string]bool{ServiceMethods.Submit: true},
+			noReturnErrorHandler:     PanicOnNoReturnError,
+			nonRetryableErrorHandler: IgnoreNonRetryableError,
+			runnerFactory:            runnerFactory,
+			runnerName:               QualifiedRunnerName,
+			typeName:                 "Service",
+		},
+		delegate: delegate,
+	}
+	for _, o := range options {
+		o(c.base)
+	}
+	return c
+}
+
+// ServiceIdempotentRunnerNames returns the names of the runners of the idempotent methods of the given Service, the names
+// are built like the names of the runners its calls run through (see runner.Factory.WithIdempotentMethods)
+func ServiceIdempotentRunnerNames(s *Service) []string {
+	names := make([]string, 0, len(ServiceIdempotentMethods))
+	for _, name := range ServiceIdempotentMethods {
+		names = append(names, s.runnerFor(name))
+	}
+	return names
+}
+
+// WithServiceGetFallback configures the fallback of Service.Get, the fallback is called with the error when the middlewares return an error and its results are returned to the caller
+func WithServiceGetFallback(fn func(context.Context, error) (string, error)) Option {
+	return func(o *base) {
+		if o.fallbacks == nil {
+			o.fallbacks = map[string]interface{}{}
+		}
+		o.fallbacks["Service.Get"] = fn
+	}
+}
+
+// WithServiceLoadFallback configures the fallback of Service.Load, the fallback is called with the error when the middlewares return an error and its results are returned to the caller
+func WithServiceLoadFallback(fn func(context.Context, error) (string, error)) Option {
+	return func(o *base) {
+		if o.fallbacks == nil {
+			o.fallbacks = map[string]interface{}{}
+		}
+		o.fallbacks["Service.Load"] = fn
+	}
+}
+
+// WithServiceSubmitFallback configures the fallback of Service.Submit, the fallback is called with the error when the middlewares return an error and its results are returned to the caller
+func WithServiceSubmitFallback(fn func(context.Context, error) error) Option {
+	return func(o *base) {
+		if o.fallbacks == nil {
+			o.fallbacks = map[string]interface{}{}
+		}
+		o.fallbacks["Service.Submit"] = fn
+	}
+}
+
+// Get receives a context so its calls are hedged but never abandoned
+func (s *Service) Get(ctx context.Context, key string) (string, error) {
+	var nonRetryableErr error
+	var r0 string
+	err := s.run(ctx, ServiceMethods.Get, func(ctx context.Context) error {
+		var err error
+		var res0 string
+		func() {
+			defer recoverPanic(ctx, &err)
+			res0, err = s.delegate.Get(ctx, key)
+		}()
+		if !runner.ClaimAttempt(ctx) {
+			return runner.ErrHedgeLost
+		}
+		r0 = res0
+		if s.errorPredicate(ServiceMethods.Get, err) {
+			return err
+		}
+		observeNonRetryable(ctx, err)
+		s.nonRetryableErrorHandler(ctx, ServiceMethods.Get, err)
+		nonRetryableErr = err
+		return nil
+	})
+	if nonRetryableErr != nil {
+		return r0, nonRetryableErr
+	}
+	if err != nil {
+		if fallback, ok := s.fallbacks["Service.Get"].(func(context.Context, error) (string, error)); ok {
+			return fallback(ctx, err)
+		}
+	}
+	return r0, err
+}
+
+// Load doesn't receive a context so its calls are abandoned once the context of the middlewares is done
+func (s *Service) Load(key string) (string, error) {
+	var nonRetryableErr error
+	var r0 string
+	err := s.run(context.Background(), ServiceMethods.Load, func(ctx context.Context) error {
+		var err error
+		var res0 string
+		if abandonErr := abandon(ctx, func() {
+			defer recoverPanic(ctx, &err)
+			res0, err = s.delegate.Load(key)
+		}); abandonErr != nil {
+			return abandonErr
+		}
+		if !runner.ClaimAttempt(ctx) {
+			return runner.ErrHedgeLost
+		}
+		r0 = res0
+		if s.errorPredicate(ServiceMethods.Load, err) {
+			return err
+		}
+		observeNonRetryable(ctx, err)
+		s.nonRetryableErrorHandler(ctx, ServiceMethods.Load, err)
+		nonRetryableErr = err
+		return nil
+	})
+	if nonRetryableErr != nil {
+		return r0, nonRetryableErr
+	}
+	if err != nil {
+		if fallback, ok := s.fallbacks["Service.Load"].(func(context.Context, error) (string, error)); ok {
+			return fallback(context.Background(), err)
+		}
+	}
+	return r0, err
+}
+
+// Submit isn't retried but its errors are still handed to the middlewares
+func (s *Service) Submit(ctx context.Context, order string) error {
+	var nonRetryableErr error
+	err := s.run(ctx, ServiceMethods.Submit, func(ctx context.Context) error {
+		var err error
+		func() {
+			defer recoverPanic(ctx, &err)
+			err = s.delegate.Submit(ctx, order)
+		}()
+		if !runner.ClaimAttempt(ctx) {
+			return runner.ErrHedgeLost
+		}
+		if s.errorPredicate(ServiceMethods.Submit, err) {
+			return err
+		}
+		observeNonRetryable(ctx, err)
+		s.nonRetryableErrorHandler(ctx, ServiceMethods.Submit, err)
+		nonRetryableErr = err
+		return nil
+	})
+	if nonRetryableErr != nil {
+		return nonRetryableErr
+	}
+	if err != nil {
+		if fallback, ok := s.fallbacks["Service.Submit"].(func(context.Context, error) error); ok {
+			return fallback(ctx, err)
+		}
+	}
+	return err
+}
@@ -0,0 +1,34 @@
+//go:generate reinforcer --target=Service --target=Validator --abandon --hedging --observer --recover --outputdir=./reinforced
+
+// Package e2e holds the source of the proxies that the end-to-end tests run against fake delegates, the proxies are
+// generated with the features whose generated code runs concurrently with the middlewares.
+package e2e
+
+import "context"
+
+// Service is the source of the generated proxies
+type Service interface {
+	// Get receives a context so its calls are hedged but never abandoned
+	Get(ctx context.Context, key string) (string, error)
+	// Load doesn't receive a context so its calls are abandoned once the context of the middlewares is done
+	Load(key string) (string, error)
+	// Submit isn't retried but its errors are still handed to the middlewares
+	//
+	//reinforcer:noretry
+	Submit(ctx context.Context, order string) error
+}
+
+// ValidationErr is the custom error type of Validator
+type ValidationErr struct {
+	Reason string
+}
+
+func (e *ValidationErr) Error() string {
+	return e.Reason
+}
+
+// Validator is the source of the generated proxies whose methods return a custom error type
+type Validator interface {
+	// Validate returns a custom error type so the errors emitted by the middlewares go through the error converter
+	Validate(ctx context.Context, input string) *ValidationErr
+}
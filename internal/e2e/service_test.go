@@ -0,0 +1,357 @@
+package e2e_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/clear-street/reinforcer/internal/e2e"
+	"github.com/clear-street/reinforcer/internal/e2e/reinforced"
+	"github.com/clear-street/reinforcer/internal/generator"
+	"github.com/clear-street/reinforcer/internal/generator/executor"
+	"github.com/clear-street/reinforcer/internal/loader"
+	"github.com/clear-street/reinforcer/internal/writer/filename"
+	"github.com/clear-street/reinforcer/pkg/runner"
+	"github.com/slok/goresilience"
+	"github.com/slok/goresilience/circuitbreaker"
+	"github.com/slok/goresilience/errors"
+	"github.com/slok/goresilience/retry"
+	"github.com/slok/goresilience/timeout"
+	"github.com/stretchr/testify/require"
+)
+
+var errFailed = fmt.Errorf("failed")
+
+// fakeService is a Service delegate whose methods are given by the tests
+type fakeService struct {
+	get    func(ctx context.Context, key string) (string, error)
+	load   func(key string) (string, error)
+	submit func(ctx context.Context, order string) error
+}
+
+func (f *fakeService) Get(ctx context.Context, key string) (string, error) {
+	return f.get(ctx, key)
+}
+
+func (f *fakeService) Load(key string) (string, error) {
+	return f.load(key)
+}
+
+func (f *fakeService) Submit(ctx context.Context, order string) error {
+	return f.submit(ctx, order)
+}
+
+// deadlineMiddleware hands a context with the given timeout to the runner and waits for it to return
+func deadlineMiddleware(d time.Duration) goresilience.Middleware {
+	return func(next goresilience.Runner) goresilience.Runner {
+		return goresilience.RunnerFunc(func(ctx context.Context, f goresilience.Func) error {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			return next.Run(ctx, f)
+		})
+	}
+}
+
+func TestGenerated(t *testing.T) {
+	source, err := filepath.Abs("service.go")
+	require.NoError(t, err)
+	got, err := executor.New(loader.DefaultLoader()).Execute(&executor.Parameters{
+		Sources: []string{source},
+		Targets: []string{"Service", "Validator"},
+		OutPkg:  "reinforced",
+		Options: generator.Options{
+			AbandonOnContextDone: true,
+			Hedging:              true,
+			Observer:             true,
+			RecoverPanics:        true,
+		},
+	})
+	require.NoError(t, err)
+
+	// The proxies must be regenerated with go generate whenever the generated code changes
+	common, err := os.ReadFile(filepath.Join("reinforced", "reinforcer_common.go"))
+	require.NoError(t, err)
+	require.Equal(t, string(common), got.Common)
+	require.Len(t, got.Files, 2)
+	for _, file := range got.Files {
+		contents, err := os.ReadFile(filepath.Join("reinforced", filename.SnakeCaseStrategy().GenerateFileName(file.TypeName)+".go"))
+		require.NoError(t, err)
+		require.Equal(t, string(contents), file.Contents)
+	}
+}
+
+func TestService_Abandon(t *testing.T) {
+	t.Run("Abandoned call returns context.DeadlineExceeded", func(t *testing.T) {
+		returned := make(chan struct{})
+		svc := reinforced.NewService(&fakeService{
+			load: func(key string) (string, error) {
+				defer close(returned)
+				time.Sleep(50 * time.Millisecond)
+				return "late", nil
+			},
+		}, runner.NewFactory(deadlineMiddleware(5*time.Millisecond)))
+
+		got, err := svc.Load("key")
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Empty(t, got)
+		select {
+		case <-returned:
+			t.Fatal("the call waited for the delegate")
+		default:
+		}
+		<-returned
+	})
+
+	t.Run("Call that completes in time returns its results", func(t *testing.T) {
+		svc := reinforced.NewService(&fakeService{
+			load: func(key string) (string, error) {
+				return "value", nil
+			},
+		}, runner.NewFactory(deadlineMiddleware(time.Second)))
+
+		got, err := svc.Load("key")
+		require.NoError(t, err)
+		require.Equal(t, "value", got)
+	})
+
+	t.Run("Call that completes as the context expires doesn't race with the caller", func(t *testing.T) {
+		// The timeout middleware returns as soon as the context expires without waiting for the call
+		svc := reinforced.NewService(&fakeService{
+			load: func(key string) (string, error) {
+				time.Sleep(time.Millisecond)
+				return "value", nil
+			},
+		}, runner.NewFactory(timeout.NewMiddleware(timeout.Config{Timeout: time.Millisecond})))
+
+		for i := 0; i < 100; i++ {
+			got, err := svc.Load("key")
+			if err != nil {
+				require.ErrorIs(t, err, errors.ErrTimeout)
+				continue
+			}
+			require.Equal(t, "value", got)
+		}
+	})
+}
+
+func TestService_Hedging(t *testing.T) {
+	newService := func(get func(ctx context.Context, key string) (string, error)) *reinforced.Service {
+		return reinforced.NewService(&fakeService{get: get}, runner.NewFactory(
+			runner.NewHedgeMiddleware(runner.HedgeConfig{Delay: time.Millisecond}),
+		))
+	}
+
+	t.Run("First attempt to complete wins", func(t *testing.T) {
+		var calls int32
+		svc := newService(func(ctx context.Context, key string) (string, error) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				<-ctx.Done()
+				return "first", ctx.Err()
+			}
+			return "second", nil
+		})
+
+		got, err := svc.Get(context.Background(), "key")
+		require.NoError(t, err)
+		require.Equal(t, "second", got)
+	})
+
+	t.Run("Call whose context is done doesn't race with its attempts", func(t *testing.T) {
+		var wg sync.WaitGroup
+		wg.Add(2)
+		// The attempts ignore the context so they complete after the call returned
+		svc := newService(func(ctx context.Context, key string) (string, error) {
+			defer wg.Done()
+			time.Sleep(20 * time.Millisecond)
+			return "late", nil
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+		defer cancel()
+
+		got, err := svc.Get(ctx, "key")
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Empty(t, got)
+		wg.Wait()
+	})
+}
+
+// recordingObserver records the events handed to the Observer
+type recordingObserver struct {
+	mu            sync.Mutex
+	attemptStarts []int
+	attemptErrs   []error
+	callAttempts  []int
+	callErrs      []error
+}
+
+func (o *recordingObserver) OnAttemptStart(_ context.Context, _ string, attempt int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.attemptStarts = append(o.attemptStarts, attempt)
+}
+
+func (o *recordingObserver) OnAttemptEnd(_ context.Context, _ string, _ int, err error, _ time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.attemptErrs = append(o.attemptErrs, err)
+}
+
+func (o *recordingObserver) OnCallEnd(_ context.Context, _ string, attempts int, err error, _ time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.callAttempts = append(o.callAttempts, attempts)
+	o.callErrs = append(o.callErrs, err)
+}
+
+func TestService_Observer(t *testing.T) {
+	factory := runner.NewFactory(retry.NewMiddleware(retry.Config{Times: 2, WaitBase: time.Millisecond}))
+
+	t.Run("Observes the retried attempts", func(t *testing.T) {
+		var calls int32
+		observer := &recordingObserver{}
+		svc := reinforced.NewService(&fakeService{
+			get: func(ctx context.Context, key string) (string, error) {
+				if atomic.AddInt32(&calls, 1) < 3 {
+					return "", errFailed
+				}
+				return "value", nil
+			},
+		}, factory, reinforced.WithObserver(observer))
+
+		got, err := svc.Get(context.Background(), "key")
+		require.NoError(t, err)
+		require.Equal(t, "value", got)
+		require.Equal(t, []int{1, 2, 3}, observer.attemptStarts)
+		require.Equal(t, []error{errFailed, errFailed, nil}, observer.attemptErrs)
+		require.Equal(t, []int{3}, observer.callAttempts)
+		require.Equal(t, []error{nil}, observer.callErrs)
+	})
+
+	t.Run("Observes the non-retryable errors", func(t *testing.T) {
+		observer := &recordingObserver{}
+		svc := reinforced.NewService(&fakeService{
+			get: func(ctx context.Context, key string) (string, error) {
+				return "", errFailed
+			},
+		}, factory, reinforced.WithObserver(observer), reinforced.WithRetryableErrorPredicate(func(string, error) bool {
+			return false
+		}))
+
+		_, err := svc.Get(context.Background(), "key")
+		require.Equal(t, errFailed, err)
+		require.Equal(t, []int{1}, observer.attemptStarts)
+		require.Equal(t, []error{errFailed}, observer.attemptErrs)
+		require.Equal(t, []int{1}, observer.callAttempts)
+		require.Equal(t, []error{errFailed}, observer.callErrs)
+	})
+}
+
+func TestService_RecoverPanics(t *testing.T) {
+	factory := runner.NewFactory(retry.NewMiddleware(retry.Config{Times: 2, WaitBase: time.Millisecond}))
+
+	t.Run("Recovered panic is retried", func(t *testing.T) {
+		var calls int32
+		svc := reinforced.NewService(&fakeService{
+			get: func(ctx context.Context, key string) (string, error) {
+				if atomic.AddInt32(&calls, 1) == 1 {
+					panic("boom")
+				}
+				return "value", nil
+			},
+		}, factory)
+
+		got, err := svc.Get(context.Background(), "key")
+		require.NoError(t, err)
+		require.Equal(t, "value", got)
+		require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("Recovered panic is returned as a PanicError", func(t *testing.T) {
+		svc := reinforced.NewService(&fakeService{
+			get: func(ctx context.Context, key string) (string, error) {
+				panic(errFailed)
+			},
+		}, factory)
+
+		_, err := svc.Get(context.Background(), "key")
+		var panicErr *reinforced.PanicError
+		require.ErrorAs(t, err, &panicErr)
+		require.ErrorIs(t, err, errFailed)
+		require.NotEmpty(t, panicErr.Stack)
+	})
+
+	t.Run("Panic of an abandoned call is recovered", func(t *testing.T) {
+		svc := reinforced.NewService(&fakeService{
+			load: func(key string) (string, error) {
+				panic("boom")
+			},
+		}, runner.NewFactory(deadlineMiddleware(time.Second)))
+
+		_, err := svc.Load("key")
+		var panicErr *reinforced.PanicError
+		require.ErrorAs(t, err, &panicErr)
+		require.Equal(t, "boom", panicErr.Value)
+	})
+}
+
+func TestService_NoRetry(t *testing.T) {
+	var calls int32
+	svc := reinforced.NewService(&fakeService{
+		submit: func(ctx context.Context, order string) error {
+			atomic.AddInt32(&calls, 1)
+			return errFailed
+		},
+	}, runner.NewFactory(
+		circuitbreaker.NewMiddleware(circuitbreaker.Config{
+			ErrorPercentThresholdToOpen: 50,
+			MinimumRequestToOpen:        3,
+			WaitDurationInOpenState:     time.Minute,
+		}),
+		runner.Retry(retry.NewMiddleware(retry.Config{Times: 3, WaitBase: time.Millisecond})),
+	))
+
+	// The errors aren't retried but they're seen by the circuit breaker
+	for i := 0; i < 3; i++ {
+		require.Equal(t, errFailed, svc.Submit(context.Background(), "order"))
+	}
+	require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	require.ErrorIs(t, svc.Submit(context.Background(), "order"), errors.ErrCircuitOpen)
+	require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+// fakeValidator is a Validator delegate whose method is given by the tests
+type fakeValidator func(ctx context.Context, input string) *e2e.ValidationErr
+
+func (f fakeValidator) Validate(ctx context.Context, input string) *e2e.ValidationErr {
+	return f(ctx, input)
+}
+
+func TestValidator_ErrorConverter(t *testing.T) {
+	delegate := fakeValidator(func(ctx context.Context, input string) *e2e.ValidationErr {
+		if input == "" {
+			return &e2e.ValidationErr{Reason: "empty"}
+		}
+		panic("boom")
+	})
+	factory := runner.NewFactory()
+
+	t.Run("Validator can't be created without its error converter", func(t *testing.T) {
+		_, err := reinforced.NewValidator(delegate, factory)
+		require.EqualError(t, err, "no error converter configured for Validator.Validate, see WithValidatorValidateErrorConverter")
+	})
+
+	t.Run("Errors that aren't of the custom error type are converted", func(t *testing.T) {
+		v, err := reinforced.NewValidator(delegate, factory, reinforced.WithValidatorValidateErrorConverter(func(err error) *e2e.ValidationErr {
+			return &e2e.ValidationErr{Reason: "converted: " + err.Error()}
+		}))
+		require.NoError(t, err)
+
+		require.Equal(t, &e2e.ValidationErr{Reason: "empty"}, v.Validate(context.Background(), ""))
+		require.Equal(t, &e2e.ValidationErr{Reason: "converted: delegate panicked: boom"}, v.Validate(context.Background(), "input"))
+	})
+}
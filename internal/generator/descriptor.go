@@ -0,0 +1,130 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/clear-street/reinforcer/internal/generator/method"
+	"github.com/dave/jennifer/jen"
+)
+
+const (
+	// retryableStrategy wraps the calls in the middlewares and hands the method's error to them
+	retryableStrategy = "retryable"
+	// noReturnStrategy wraps the calls to methods that don't return an error in the middlewares
+	noReturnStrategy = "noreturn"
+	// passThroughStrategy calls the delegate without any middleware
+	passThroughStrategy = "passthrough"
+)
+
+// strategyFor determines how the calls to the given method are generated
+func strategyFor(mm *method.Method, ignoreNoReturnMethods bool) string {
+	switch {
+	case mm.PassThrough:
+		return passThroughStrategy
+	case mm.ReturnsError:
+		return retryableStrategy
+	case ignoreNoReturnMethods:
+		return passThroughStrategy
+	default:
+		return noReturnStrategy
+	}
+}
+
+// generateDescriptorTypes declares the types that describe the generated types and their methods, as well as the
+// registry of the descriptors of all the generated types
+func generateDescriptorTypes(f *jen.File, outTypeNames []string) {
+	f.Add(jen.Comment("MethodDescriptor describes a method of a generated type"))
+	f.Add(jen.Type().Id("MethodDescriptor").Struct(
+		jen.Comment("Name is the name of the method"),
+		jen.Id("Name").String(),
+		jen.Comment("Signature is the signature of the method in the source type"),
+		jen.Id("Signature").String(),
+		jen.Comment("HasContext is set when the method receives a context.Context"),
+		jen.Id("HasContext").Bool(),
+		jen.Comment("ReturnsError is set when the method returns the error that is handed to the middlewares"),
+		jen.Id("ReturnsError").Bool(),
+		jen.Comment(fmt.Sprintf("Strategy is how the calls to the method are proxied: %s, %s or %s", retryableStrategy, noReturnStrategy, passThroughStrategy)),
+		jen.Id("Strategy").String(),
+		jen.Comment("Idempotent is set when the method is safe to be retried"),
+		jen.Id("Idempotent").Bool(),
+		jen.Comment("Position is the position of the method in the source (e.g. github.com/org/pkg/client.go:12)"),
+		jen.Id("Position").String(),
+	))
+
+	f.Add(jen.Comment("TypeDescriptor describes a generated type and its methods"))
+	f.Add(jen.Type().Id("TypeDescriptor").Struct(
+		jen.Id("name").String(),
+		jen.Id("methods").Index().Id("MethodDescriptor"),
+	))
+
+	f.Add(jen.Comment("Name is the name of the generated type"))
+	f.Add(jen.Func().Params(jen.Id("d").Op("*").Id("TypeDescriptor")).Id("Name").Params().String().Block(
+		jen.Return(jen.Id("d").Dot("name")),
+	))
+
+	f.Add(jen.Comment("Methods returns the descriptors of the type's methods"))
+	f.Add(jen.Func().Params(jen.Id("d").Op("*").Id("TypeDescriptor")).Id("Methods").Params().Index().Id("MethodDescriptor").Block(
+		jen.Id("methods").Op(":=").Make(jen.Index().Id("MethodDescriptor"), jen.Len(jen.Id("d").Dot("methods"))),
+		jen.Copy(jen.Id("methods"), jen.Id("d").Dot("methods")),
+		jen.Return(jen.Id("methods")),
+	))
+
+	f.Add(jen.Comment("Method returns the descriptor of the method with the given name"))
+	f.Add(jen.Func().Params(jen.Id("d").Op("*").Id("TypeDescriptor")).Id("Method").Params(jen.Id("name").String()).Params(jen.Id("MethodDescriptor"), jen.Bool()).Block(
+		jen.For(jen.List(jen.Id("_"), jen.Id("m")).Op(":=").Range().Id("d").Dot("methods")).Block(
+			jen.If(jen.Id("m").Dot("Name").Op("==").Id("name")).Block(
+				jen.Return(jen.Id("m"), jen.True()),
+			),
+		),
+		jen.Return(jen.Id("MethodDescriptor").Values(), jen.False()),
+	))
+
+	// The types are listed by name so that the generated code doesn't depend on the order the types were loaded in
+	sorted := make([]string, len(outTypeNames))
+	copy(sorted, outTypeNames)
+	sort.Strings(sorted)
+	var descriptors []jen.Code
+	for _, outTypeName := range sorted {
+		descriptors = append(descriptors, jen.Id(descriptorName(outTypeName)))
+	}
+	f.Add(jen.Comment("Descriptors returns the descriptors of all the generated types"))
+	f.Add(jen.Func().Id("Descriptors").Params().Index().Op("*").Id("TypeDescriptor").Block(
+		jen.Return(jen.Index().Op("*").Id("TypeDescriptor").Values(descriptors...)),
+	))
+}
+
+// typeDescriptor declares the descriptor of the given generated type
+func typeDescriptor(fileCfg *FileConfig, methods []*method.Method, ignoreNoReturnMethods bool) []jen.Code {
+	var methodDescriptors []jen.Code
+	for _, mm := range methods {
+		methodDescriptors = append(methodDescriptors, jen.Values(jen.Dict{
+			jen.Id("Name"):         mm.ConstantRef(fileCfg.outTypeName),
+			jen.Id("Signature"):    jen.Lit(mm.Signature),
+			jen.Id("HasContext"):   jen.Lit(mm.HasContext),
+			jen.Id("ReturnsError"): jen.Lit(mm.ReturnsError),
+			jen.Id("Strategy"):     jen.Lit(strategyFor(mm, ignoreNoReturnMethods)),
+			jen.Id("Idempotent"):   jen.Lit(mm.Idempotent),
+			jen.Id("Position"):     jen.Lit(mm.Position),
+		}))
+	}
+	name := descriptorName(fileCfg.outTypeName)
+	return []jen.Code{
+		jen.Comment(fmt.Sprintf("%s describes %s and its methods", name, fileCfg.outTypeName)),
+		jen.Var().Id(name).Op("=").Op("&").Id("TypeDescriptor").Values(jen.Dict{
+			jen.Id("name"): jen.Lit(fileCfg.outTypeName),
+			jen.Id("methods"): jen.Index().Id("MethodDescriptor").ValuesFunc(func(g *jen.Group) {
+				for _, d := range methodDescriptors {
+					g.Line().Add(d)
+				}
+				if len(methodDescriptors) > 0 {
+					g.Line()
+				}
+			}),
+		}),
+	}
+}
+
+func descriptorName(outTypeName string) string {
+	return outTypeName + "Descriptor"
+}
@@ -17,6 +17,7 @@ var ErrNoTargetableTypesFound = fmt.Errorf("no targetable types were discovered"
 type Loader interface {
 	LoadAll(path string, mode loader.LoadMode) (map[string]*loader.Result, error)
 	LoadMatched(path string, expressions []string, mode loader.LoadMode) (map[string]*loader.Result, error)
+	Preload(packagePaths []string, filePaths []string) error
 }
 
 // Parameters are the input parameters for the executor
@@ -31,8 +32,8 @@ type Parameters struct {
 	TargetsAll bool
 	// OutPkg the package name for the output code
 	OutPkg string
-	// IgnoreNoReturnMethods disables proxying of methods that don't return anything
-	IgnoreNoReturnMethods bool
+	// Options are the options of the generated code
+	generator.Options
 }
 
 // Executor is a utility service to orchestrate code generation
@@ -93,9 +94,9 @@ func (e *Executor) Execute(settings *Parameters) (*generator.Generated, error) {
 	}
 
 	code, err := generator.Generate(generator.Config{
-		OutPkg:                settings.OutPkg,
-		IgnoreNoReturnMethods: settings.IgnoreNoReturnMethods,
-		Files:                 cfg,
+		OutPkg:  settings.OutPkg,
+		Files:   cfg,
+		Options: settings.Options,
 	})
 	if err != nil {
 		return nil, err
@@ -103,6 +104,30 @@ func (e *Executor) Execute(settings *Parameters) (*generator.Generated, error) {
 	return code, nil
 }
 
+// ExecuteAll orchestrates code generation for multiple jobs, each job generates its own output package. The sources of
+// every job are loaded in a single pass before any code is generated which is considerably faster than executing each
+// job on its own. The generated code is returned in the same order as the jobs.
+func (e *Executor) ExecuteAll(jobs []*Parameters) ([]*generator.Generated, error) {
+	var sourcePackages, sources []string
+	for _, job := range jobs {
+		sourcePackages = append(sourcePackages, job.SourcePackages...)
+		sources = append(sources, job.Sources...)
+	}
+	if err := e.loader.Preload(sourcePackages, sources); err != nil {
+		return nil, errors.Wrap(err, "failed to preload sources")
+	}
+
+	generated := make([]*generator.Generated, 0, len(jobs))
+	for idx, job := range jobs {
+		code, err := e.Execute(job)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to execute job=%d (outpkg=%s)", idx, job.OutPkg)
+		}
+		generated = append(generated, code)
+	}
+	return generated, nil
+}
+
 func createFileConfigs(discoveredSet map[string]struct{}, match map[string]*loader.Result) ([]*generator.FileConfig, error) {
 	var cfg []*generator.FileConfig
 	for typName, res := range match {
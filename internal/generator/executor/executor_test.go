@@ -4,6 +4,7 @@ import (
 	"go/types"
 	"testing"
 
+	"github.com/clear-street/reinforcer/internal/generator"
 	"github.com/clear-street/reinforcer/internal/generator/executor"
 	"github.com/clear-street/reinforcer/internal/generator/executor/mocks"
 	"github.com/clear-street/reinforcer/internal/generator/method"
@@ -25,10 +26,12 @@ func TestExecutor_Execute(t *testing.T) {
 
 		exec := executor.New(l)
 		got, err := exec.Execute(&executor.Parameters{
-			Sources:               []string{"./testpkg.go"},
-			Targets:               []string{"MyService"},
-			OutPkg:                "testpkg",
-			IgnoreNoReturnMethods: false,
+			Sources: []string{"./testpkg.go"},
+			Targets: []string{"MyService"},
+			OutPkg:  "testpkg",
+			Options: generator.Options{
+				IgnoreNoReturnMethods: false,
+			},
 		})
 		require.NoError(t, err)
 		require.NotNil(t, got)
@@ -49,10 +52,12 @@ func TestExecutor_Execute(t *testing.T) {
 
 		exec := executor.New(l)
 		got, err := exec.Execute(&executor.Parameters{
-			SourcePackages:        []string{"github.com/clear-street/somelib"},
-			Targets:               []string{"MyService"},
-			OutPkg:                "testpkg",
-			IgnoreNoReturnMethods: false,
+			SourcePackages: []string{"github.com/clear-street/somelib"},
+			Targets:        []string{"MyService"},
+			OutPkg:         "testpkg",
+			Options: generator.Options{
+				IgnoreNoReturnMethods: false,
+			},
 		})
 		require.NoError(t, err)
 		require.NotNil(t, got)
@@ -67,16 +72,78 @@ func TestExecutor_Execute(t *testing.T) {
 
 		exec := executor.New(l)
 		got, err := exec.Execute(&executor.Parameters{
-			Sources:               []string{"./testpkg.go"},
-			Targets:               []string{"MyService"},
-			OutPkg:                "testpkg",
-			IgnoreNoReturnMethods: false,
+			Sources: []string{"./testpkg.go"},
+			Targets: []string{"MyService"},
+			OutPkg:  "testpkg",
+			Options: generator.Options{
+				IgnoreNoReturnMethods: false,
+			},
 		})
 		require.EqualError(t, err, executor.ErrNoTargetableTypesFound.Error())
 		require.Nil(t, got)
 	})
 }
 
+func TestExecutor_ExecuteAll(t *testing.T) {
+	t.Run("Executes every job", func(t *testing.T) {
+		l := &mocks.Loader{}
+		l.On("Preload", []string{"github.com/clear-street/somelib"}, []string{"./testpkg.go"}).Return(nil)
+		l.On("LoadMatched", "./testpkg.go", []string{"MyService"}, loader.FileLoadMode).Return(
+			map[string]*loader.Result{
+				"LockService": {
+					Name:    "LockService",
+					Methods: createTestServiceMethods(),
+				},
+			}, nil,
+		)
+		l.On("LoadAll", "github.com/clear-street/somelib", loader.PackageLoadMode).Return(
+			map[string]*loader.Result{
+				"OtherService": {
+					Name:    "OtherService",
+					Methods: createTestServiceMethods(),
+				},
+			}, nil,
+		)
+
+		exec := executor.New(l)
+		got, err := exec.ExecuteAll([]*executor.Parameters{
+			{
+				Sources: []string{"./testpkg.go"},
+				Targets: []string{"MyService"},
+				OutPkg:  "testpkg",
+			},
+			{
+				SourcePackages: []string{"github.com/clear-street/somelib"},
+				TargetsAll:     true,
+				OutPkg:         "somelib",
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, 2, len(got))
+		require.Equal(t, 1, len(got[0].Files))
+		require.Equal(t, "LockService", got[0].Files[0].TypeName)
+		require.Equal(t, 1, len(got[1].Files))
+		require.Equal(t, "OtherService", got[1].Files[0].TypeName)
+		l.AssertExpectations(t)
+	})
+
+	t.Run("Job fails", func(t *testing.T) {
+		l := &mocks.Loader{}
+		l.On("Preload", []string(nil), []string{"./testpkg.go"}).Return(nil)
+		l.On("LoadMatched", "./testpkg.go", []string{"MyService"}, loader.FileLoadMode).
+			Return(map[string]*loader.Result{}, nil)
+
+		exec := executor.New(l)
+		got, err := exec.ExecuteAll([]*executor.Parameters{{
+			Sources: []string{"./testpkg.go"},
+			Targets: []string{"MyService"},
+			OutPkg:  "testpkg",
+		}})
+		require.EqualError(t, err, "failed to execute job=0 (outpkg=testpkg): no targetable types were discovered")
+		require.Nil(t, got)
+	})
+}
+
 func createTestServiceMethods() []*method.Method {
 	nullary := types.NewSignatureType(nil, nil, nil, nil, nil, false) // func()
 	return []*method.Method{
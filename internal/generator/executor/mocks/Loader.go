@@ -57,3 +57,17 @@ func (_m *Loader) LoadMatched(path string, expressions []string, mode loader.Loa
 
 	return r0, r1
 }
+
+// Preload provides a mock function with given fields: packagePaths, filePaths
+func (_m *Loader) Preload(packagePaths []string, filePaths []string) error {
+	ret := _m.Called(packagePaths, filePaths)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]string, []string) error); ok {
+		r0 = rf(packagePaths, filePaths)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -15,6 +15,15 @@ import (
 
 var fileHeader = "Code generated by reinforcer, DO NOT EDIT."
 
+// tracingPkg is the package that traces the calls when the code is generated with tracing
+const tracingPkg = "github.com/clear-street/reinforcer/pkg/tracing"
+
+// loggingPkg is the package that logs the calls when the code is generated with logging
+const loggingPkg = "github.com/clear-street/reinforcer/pkg/logging"
+
+// runnerPkg is the package that skips the retries of the methods annotated with //reinforcer:noretry
+const runnerPkg = "github.com/clear-street/reinforcer/pkg/runner"
+
 // FileConfig holds the code generation configuration for a specific type
 type FileConfig struct {
 	// srcTypeName is the source type that we want to generate code for
@@ -61,8 +70,48 @@ type Config struct {
 	OutPkg string
 	// Files holds the code generation configuration for every file being processed
 	Files []*FileConfig
+	Options
+}
+
+// Options are the options that shape the generated code, they're shared by the command's settings and jobs (hence the
+// tags of the config file) and the executor's parameters
+type Options struct {
 	// IgnoreNoReturnMethods determines whether methods that don't return anything should be wrapped in the middleware or not.
-	IgnoreNoReturnMethods bool
+	IgnoreNoReturnMethods bool `yaml:"ignorenoret" mapstructure:"ignorenoret"`
+	// IgnorePromotedMethods determines whether methods promoted from embedded types are left out of the generated types,
+	// in which case only the methods declared directly on the types are generated.
+	IgnorePromotedMethods bool `yaml:"ignorepromoted" mapstructure:"ignorepromoted"`
+	// ErrorResult is the rule that selects which of the results of a method is the error handed to the middlewares, the
+	// results annotated with a //reinforcer:error=<result> directive take precedence.
+	ErrorResult method.ErrorResultRule `yaml:"errorresult" mapstructure:"errorresult"`
+	// IdempotentMethods lists the methods that are safe to be retried in addition to the methods annotated with the
+	// //reinforcer:idempotent directive, a method is either given by its name (e.g. GetUser) to match the method in every
+	// type or qualified with the source type (e.g. Client.GetUser).
+	IdempotentMethods []string `yaml:"idempotent" mapstructure:"idempotent"`
+	// ContextVariants generates a context variant of every wrapped method without a context (e.g. GetCtx for Get), the
+	// context given to the variant is handed to the middlewares.
+	ContextVariants bool `yaml:"ctxvariants" mapstructure:"ctxvariants"`
+	// AbandonOnContextDone runs the calls to the delegates of the methods without a context in their own goroutine which
+	// is abandoned once the context handed by the middlewares is done, the call returns context.DeadlineExceeded. The
+	// abandoned goroutine keeps running until the delegate returns.
+	AbandonOnContextDone bool `yaml:"abandon" mapstructure:"abandon"`
+	// ErrorVariants generates an error variant of every wrapped method without results (e.g. NotifyE() error for Notify())
+	// that returns the errors emitted by the middlewares instead of handing them to the no return error handler.
+	ErrorVariants bool `yaml:"errvariants" mapstructure:"errvariants"`
+	// Hedging keeps the results of the attempts of a call apart so that the attempts can run concurrently (e.g. with the
+	// hedge middleware), the results of an attempt are only handed out once the attempt claimed the call.
+	Hedging bool `yaml:"hedging" mapstructure:"hedging"`
+	// Tracing traces every call with OpenTelemetry, a call produces a span with a child span per attempt (see the tracing
+	// package).
+	Tracing bool `yaml:"tracing" mapstructure:"tracing"`
+	// Observer hands every call and its attempts to the observer configured with WithObserver.
+	Observer bool `yaml:"observer" mapstructure:"observer"`
+	// Logging logs the retries, the non-retryable errors and the rejections of the calls with the logger configured with
+	// WithLogger (see the logging package).
+	Logging bool `yaml:"logging" mapstructure:"logging"`
+	// RecoverPanics recovers the panics of the delegates into a *PanicError that is handed to the error predicate and the
+	// middlewares like any other error returned by the delegates.
+	RecoverPanics bool `yaml:"recover" mapstructure:"recover"`
 }
 
 // GeneratedFile contains the code generation output for a specific type
@@ -91,7 +140,39 @@ func Generate(cfg Config) (*Generated, error) {
 		return nil, fmt.Errorf("must provide at least one file for generation")
 	}
 
-	c, err := generateCommon(cfg.OutPkg)
+	outTypeNames := make([]string, 0, len(cfg.Files))
+	fileMethods := make([][]*method.Method, 0, len(cfg.Files))
+	// The error converters are only declared when a method has a custom error type
+	errorConverters := false
+	// The retries are only skipped by the generated code when a method is annotated with //reinforcer:noretry
+	noRetry := false
+	for _, fileConfig := range cfg.Files {
+		outTypeNames = append(outTypeNames, fileConfig.outTypeName)
+		methods := fileConfig.methods
+		if cfg.IgnorePromotedMethods {
+			methods = declaredMethods(methods)
+		}
+		methods = unskippedMethods(methods)
+		markIdempotent(fileConfig.srcTypeName, methods, cfg.IdempotentMethods)
+		for _, mm := range methods {
+			if err := mm.SelectErrorResult(cfg.ErrorResult); err != nil {
+				return nil, fmt.Errorf("failed to select the error result of %s; error=%w", fileConfig.srcTypeName, err)
+			}
+			mm.Abandon = cfg.AbandonOnContextDone && !mm.HasContext
+			mm.Hedged = cfg.Hedging && mm.ReturnsError
+			mm.Traced = cfg.Tracing
+			mm.Observed = cfg.Observer && mm.ReturnsError
+			mm.Logged = cfg.Logging && mm.ReturnsError
+			mm.Recovered = cfg.RecoverPanics
+			errorConverters = errorConverters || convertsErrors(mm, cfg.IgnoreNoReturnMethods)
+			noRetry = noRetry || skipsRetries(mm, cfg.IgnoreNoReturnMethods)
+		}
+		fileMethods = append(fileMethods, methods)
+	}
+	if err := checkIdentifiers(cfg, fileMethods); err != nil {
+		return nil, err
+	}
+	c, err := generateCommon(cfg, outTypeNames, errorConverters, noRetry)
 	if err != nil {
 		return nil, err
 	}
@@ -100,9 +181,8 @@ func Generate(cfg Config) (*Generated, error) {
 		Common: c,
 	}
 
-	for _, fileConfig := range cfg.Files {
-		methods := fileConfig.methods
-		s, err := generateFile(cfg.OutPkg, cfg.IgnoreNoReturnMethods, fileConfig, methods)
+	for idx, fileConfig := range cfg.Files {
+		s, err := generateFile(cfg, fileConfig, fileMethods[idx])
 		if err != nil {
 			return nil, err
 		}
@@ -117,16 +197,35 @@ func Generate(cfg Config) (*Generated, error) {
 
 // generateFile generates the proxy code for the given interface, the interface must have at least one method returning an
 // error as those are the only ones wrapped in the middleware
-func generateFile(outPkg string, ignoreNoReturnMethods bool, fileCfg *FileConfig, methods []*method.Method) (string, error) {
-	f := jen.NewFile(outPkg)
+func generateFile(cfg Config, fileCfg *FileConfig, methods []*method.Method) (string, error) {
+	f := jen.NewFile(cfg.OutPkg)
 	f.HeaderComment(fileHeader)
 
+	names := make(map[string]struct{}, len(methods))
+	for _, m := range methods {
+		names[m.Name] = struct{}{}
+	}
+
 	// Compile-time constants
 	var fields []jen.Code
 	var constantAssign []jen.Code
+	var idempotent []jen.Code
 	for _, m := range methods {
+		for _, variant := range proxyVariants(cfg, strategyFor(m, cfg.IgnoreNoReturnMethods), m)[1:] {
+			if _, ok := names[variant.ProxyName()]; !ok {
+				continue
+			}
+			kind := "context"
+			if variant.ErrorVariant {
+				kind = "error"
+			}
+			return "", fmt.Errorf("method %s of %s collides with the %s variant of %s", variant.ProxyName(), fileCfg.srcTypeName, kind, m.Name)
+		}
 		fields = append(fields, jen.Id(m.Name).Id("string"))
 		constantAssign = append(constantAssign, jen.Id(m.Name).Op(":").Lit(m.Name).Op(","))
+		if m.Idempotent {
+			idempotent = append(idempotent, m.ConstantRef(fileCfg.outTypeName))
+		}
 	}
 
 	constObjName := fmt.Sprintf("%sMethods", fileCfg.outTypeName)
@@ -140,9 +239,20 @@ func generateFile(outPkg string, ignoreNoReturnMethods bool, fileCfg *FileConfig
 		),
 	)
 
+	// Declare the list of the idempotent methods
+	idempotentName := idempotentMethodsName(fileCfg.outTypeName)
+	f.Add(jen.Comment(fmt.Sprintf("%s lists the methods in %s that are safe to be retried", idempotentName, fileCfg.outTypeName)))
+	f.Add(jen.Var().Id(idempotentName).Op("=").Index().String().Values(idempotent...))
+
+	// Declare the descriptor of the type
+	for _, c := range typeDescriptor(fileCfg, methods, cfg.IgnoreNoReturnMethods) {
+		f.Add(c)
+	}
+
 	// Declare the target interface we are proxying
 	var declMethods []jen.Code
 	for _, meth := range methods {
+		declMethods = append(declMethods, methodDoc(meth)...)
 		declMethods = append(declMethods, jen.Id(meth.Name).Params(meth.ParametersNameAndType...).Params(meth.ReturnTypes...))
 	}
 	f.Add(jen.Type().Id(fileCfg.targetName()).Types(fileCfg.typeParams...).Interface(
@@ -150,17 +260,71 @@ func generateFile(outPkg string, ignoreNoReturnMethods bool, fileCfg *FileConfig
 	))
 
 	// Declare the proxy implementation
+	f.Add(jen.Comment(fmt.Sprintf("%s wraps a %s delegate, calls to its methods run through the middlewares built by the runner factory", fileCfg.outTypeName, fileCfg.srcTypeName)))
 	f.Add(jen.Type().Id(fileCfg.outTypeName).Types(fileCfg.typeParams...).Struct(
 		jen.Op("*").Id("base"),
 		jen.Id("delegate").Id(fileCfg.targetName()).Types(fileCfg.typeArgs...),
 	))
 
 	// Declare the ctor
-	f.Add(jen.Func().Id("New"+fileCfg.outTypeName).Types(fileCfg.typeParams...).Params(
-		jen.Id("delegate").Id(fileCfg.targetName()).Types(fileCfg.typeArgs...),
-		jen.Id("runnerFactory").Id("runnerFactory"),
-		jen.Id("options").Op("...").Id("Option"),
-	).Op("*").Id(fileCfg.outTypeName).Types(fileCfg.typeArgs...).Block(
+	baseFields := jen.Dict{
+		jen.Id("errorPredicate"):           jen.Id("RetryAllErrors"),
+		jen.Id("noReturnErrorHandler"):     jen.Id("PanicOnNoReturnError"),
+		jen.Id("nonRetryableErrorHandler"): jen.Id("IgnoreNonRetryableError"),
+		jen.Id("runnerFactory"):            jen.Id("runnerFactory"),
+		jen.Id("runnerName"):               jen.Id("QualifiedRunnerName"),
+		jen.Id("typeName"):                 jen.Lit(fileCfg.outTypeName),
+	}
+	// The runners of the methods annotated with a runner name
+	runners := jen.Dict{}
+	for _, mm := range methods {
+		if mm.Runner != "" {
+			runners[mm.ConstantRef(fileCfg.outTypeName)] = jen.Lit(mm.Runner)
+		}
+	}
+	if len(runners) > 0 {
+		baseFields[jen.Id("runners")] = jen.Map(jen.String()).String().Values(runners)
+	}
+	// The methods annotated with //reinforcer:noretry whose retries are skipped
+	noRetry := jen.Dict{}
+	for _, mm := range methods {
+		if skipsRetries(mm, cfg.IgnoreNoReturnMethods) {
+			noRetry[mm.ConstantRef(fileCfg.outTypeName)] = jen.True()
+		}
+	}
+	if len(noRetry) > 0 {
+		baseFields[jen.Id("noRetry")] = jen.Map(jen.String()).Bool().Values(noRetry)
+	}
+	if cfg.Tracing {
+		// The spans are created with the global tracer provider unless a tracer is given
+		baseFields[jen.Id("tracer")] = jen.Qual(tracingPkg, "NewTracer").Call(jen.Nil())
+	}
+	// The errors emitted by the middlewares can only be returned as a custom error type by an error converter, the types
+	// with such methods can't be created without their converters
+	var converterChecks []jen.Code
+	for _, mm := range methods {
+		if !convertsErrors(mm, cfg.IgnoreNoReturnMethods) {
+			continue
+		}
+		// if convert, ok := c.errorConverters["Client.Validate"].(func(error) *ValidationErr); !ok || convert == nil {
+		//   return nil, errors.New("...")
+		// }
+		converterChecks = append(converterChecks, jen.If(
+			jen.List(jen.Id("convert"), jen.Id("ok")).Op(":=").Id("c").Dot("errorConverters").Index(jen.Lit(mm.ErrorConverterKey(fileCfg.outTypeName))).Assert(mm.ErrorConverterType()),
+			jen.Op("!").Id("ok").Op("||").Id("convert").Op("==").Nil(),
+		).Block(
+			jen.Return(jen.Nil(), jen.Qual("errors", "New").Call(jen.Lit(fmt.Sprintf("no error converter configured for %s.%s, see %s", fileCfg.outTypeName, mm.Name, mm.ErrorConverterOptionName(fileCfg.outTypeName))))),
+		))
+	}
+	ctorResults := jen.Op("*").Id(fileCfg.outTypeName).Types(fileCfg.typeArgs...)
+	ctorReturn := jen.Return(jen.Id("c"))
+	f.Add(jen.Comment(fmt.Sprintf("New%s creates a %s that reinforces the given delegate with the middlewares built by the runner factory", fileCfg.outTypeName, fileCfg.outTypeName)))
+	if len(converterChecks) > 0 {
+		ctorResults = jen.Params(ctorResults, jen.Error())
+		ctorReturn = jen.Return(jen.Id("c"), jen.Nil())
+		f.Add(jen.Comment("An error is returned when the error converter of a method with a custom error type isn't configured."))
+	}
+	ctorStatements := []jen.Code{
 		// if delegate == nil
 		jen.If(jen.Id("delegate").Op("==").Nil().Block(
 			// panic("...")
@@ -174,54 +338,281 @@ func generateFile(outPkg string, ignoreNoReturnMethods bool, fileCfg *FileConfig
 		// c:= &OutTypeName{...}
 		jen.Id("c").Op(":=").Add(jen.Op("&").Id(fileCfg.outTypeName).Types(fileCfg.typeArgs...).Values(jen.Dict{
 			// embed the base struct
-			jen.Id("base"): jen.Op("&").Id("base").Values(jen.Dict{
-				jen.Id("errorPredicate"): jen.Id("RetryAllErrors"),
-				jen.Id("runnerFactory"):  jen.Id("runnerFactory"),
-			}),
+			jen.Id("base"):     jen.Op("&").Id("base").Values(baseFields),
 			jen.Id("delegate"): jen.Id("delegate"),
 		})),
 		// for _, o := range options {...}
 		jen.For(jen.Id("_").Op(",").Id("o").Op(":=").Range().Id("options")).Block(
 			jen.Id("o").Call(jen.Id("c").Dot("base")),
 		),
-		jen.Return(jen.Id("c")),
+	}
+	ctorStatements = append(append(ctorStatements, converterChecks...), ctorReturn)
+	f.Add(jen.Func().Id("New"+fileCfg.outTypeName).Types(fileCfg.typeParams...).Params(
+		jen.Id("delegate").Id(fileCfg.targetName()).Types(fileCfg.typeArgs...),
+		jen.Id("runnerFactory").Id("runnerFactory"),
+		jen.Id("options").Op("...").Id("Option"),
+	).Add(ctorResults).Block(ctorStatements...))
+
+	// Declare the function that names the runners of the idempotent methods
+	idempotentRunnersName := idempotentRunnerNamesName(fileCfg.outTypeName)
+	f.Add(jen.Comment(fmt.Sprintf("%s returns the names of the runners of the idempotent methods of the given %s, the names", idempotentRunnersName, fileCfg.outTypeName)))
+	f.Add(jen.Comment("are built like the names of the runners its calls run through (see runner.Factory.WithIdempotentMethods)"))
+	f.Add(jen.Func().Id(idempotentRunnersName).Types(fileCfg.typeParams...).Params(
+		jen.Id(fileCfg.receiverName()).Op("*").Id(fileCfg.outTypeName).Types(fileCfg.typeArgs...),
+	).Index().String().Block(
+		jen.Id("names").Op(":=").Make(jen.Index().String(), jen.Lit(0), jen.Len(jen.Id(idempotentName))),
+		jen.For(jen.List(jen.Id("_"), jen.Id("name")).Op(":=").Range().Id(idempotentName)).Block(
+			jen.Id("names").Op("=").Append(jen.Id("names"), jen.Id(fileCfg.receiverName()).Dot("runnerFor").Call(jen.Id("name"))),
+		),
+		jen.Return(jen.Id("names")),
 	))
 
+	// Declare the options that configure the fallbacks of the methods that return an error
+	for _, mm := range methods {
+		if strategyFor(mm, cfg.IgnoreNoReturnMethods) != retryableStrategy {
+			continue
+		}
+		optionName := mm.FallbackOptionName(fileCfg.outTypeName)
+		f.Add(jen.Comment(fmt.Sprintf("%s configures the fallback of %s.%s, the fallback is called with the error when the middlewares return an error and its results are returned to the caller", optionName, fileCfg.outTypeName, mm.Name)))
+		f.Add(jen.Func().Id(optionName).Types(fileCfg.typeParams...).Params(jen.Id("fn").Add(mm.FallbackType())).Id("Option").Block(
+			jen.Return(jen.Func().Params(jen.Id("o").Op("*").Id("base")).Block(
+				jen.If(jen.Id("o").Dot("fallbacks").Op("==").Nil()).Block(
+					jen.Id("o").Dot("fallbacks").Op("=").Map(jen.String()).Interface().Values(),
+				),
+				jen.Id("o").Dot("fallbacks").Index(jen.Lit(mm.FallbackKey(fileCfg.outTypeName))).Op("=").Id("fn"),
+			)),
+		))
+	}
+
+	// Declare the options that configure the error converters of the methods with a custom error type
+	for _, mm := range methods {
+		if !convertsErrors(mm, cfg.IgnoreNoReturnMethods) {
+			continue
+		}
+		optionName := mm.ErrorConverterOptionName(fileCfg.outTypeName)
+		f.Add(jen.Comment(fmt.Sprintf("%s configures the error converter of %s.%s, the converter is called with the errors emitted by the middlewares", optionName, fileCfg.outTypeName, mm.Name)))
+		f.Add(jen.Comment(fmt.Sprintf("that aren't of the method's error type, it's required to create a %s", fileCfg.outTypeName)))
+		f.Add(jen.Func().Id(optionName).Types(fileCfg.typeParams...).Params(jen.Id("fn").Add(mm.ErrorConverterType())).Id("Option").Block(
+			jen.Return(jen.Func().Params(jen.Id("o").Op("*").Id("base")).Block(
+				jen.If(jen.Id("o").Dot("errorConverters").Op("==").Nil()).Block(
+					jen.Id("o").Dot("errorConverters").Op("=").Map(jen.String()).Interface().Values(),
+				),
+				jen.Id("o").Dot("errorConverters").Index(jen.Lit(mm.ErrorConverterKey(fileCfg.outTypeName))).Op("=").Id("fn"),
+			)),
+		))
+	}
+
 	// Declare all of our proxy methods
 	for _, mm := range methods {
-		if mm.ReturnsError {
-			r := retryable.NewRetryable(mm, fileCfg.outTypeName, fileCfg.typeArgs, fileCfg.receiverName())
-			s, err := r.Statement()
-			if err != nil {
-				return "", err
-			}
-			f.Add(s)
-		} else {
-			var p statement
-			if ignoreNoReturnMethods {
-				p = passthrough.NewPassThrough(mm, fileCfg.outTypeName, fileCfg.typeArgs, fileCfg.receiverName())
+		// Parameters can't shadow the receiver
+		mm.ReserveNames(fileCfg.receiverName())
+		for _, c := range methodDoc(mm) {
+			f.Add(c)
+		}
+		strategy := strategyFor(mm, cfg.IgnoreNoReturnMethods)
+		// Every variant calls the next one, the last one runs the middlewares
+		variants := proxyVariants(cfg, strategy, mm)
+		for i, variant := range variants[:len(variants)-1] {
+			next := variants[i+1]
+			f.Add(variantCall(variant, next, fileCfg))
+			if next.ErrorVariant && !variant.ErrorVariant {
+				f.Add(jen.Comment(fmt.Sprintf("%s calls %s, the errors emitted by the middlewares are returned instead of being handed to the no return error handler", next.ProxyName(), mm.Name)))
 			} else {
-				p = noret.NewNoReturn(mm, fileCfg.outTypeName, fileCfg.typeArgs, fileCfg.receiverName())
-			}
-			s, err := p.Statement()
-			if err != nil {
-				return "", err
+				f.Add(jen.Comment(fmt.Sprintf("%s calls %s, the given context is handed to the middlewares", next.ProxyName(), mm.Name)))
 			}
-			f.Add(s)
 		}
+		s, err := proxyStatement(strategy, variants[len(variants)-1], fileCfg)
+		if err != nil {
+			return "", err
+		}
+		f.Add(s)
 	}
 	return renderToString(f)
 }
 
-func generateCommon(outPkg string) (string, error) {
-	f := jen.NewFile(outPkg)
+// commonIdentifiers lists the exported identifiers declared by the common code
+func commonIdentifiers(cfg Config) []string {
+	identifiers := []string{
+		"RetryAllErrors",
+		"QualifiedRunnerName",
+		"MethodRunnerName",
+		"PanicOnNoReturnError",
+		"IgnoreNonRetryableError",
+		"Option",
+		"WithRetryableErrorPredicate",
+		"WithNoReturnErrorHandler",
+		"WithNonRetryableErrorHandler",
+		"WithRunnerName",
+		"MethodDescriptor",
+		"TypeDescriptor",
+		"Descriptors",
+	}
+	if cfg.Tracing {
+		identifiers = append(identifiers, "WithTracer")
+	}
+	if cfg.Observer {
+		identifiers = append(identifiers, "Observer", "WithObserver")
+	}
+	if cfg.Logging {
+		identifiers = append(identifiers, "WithLogger")
+	}
+	if cfg.RecoverPanics {
+		identifiers = append(identifiers, "PanicError")
+	}
+	return identifiers
+}
+
+// typeIdentifiers lists the exported identifiers declared along with the given generated type
+func typeIdentifiers(cfg Config, fileCfg *FileConfig, methods []*method.Method) []string {
+	identifiers := []string{
+		"New" + fileCfg.outTypeName,
+		fmt.Sprintf("%sMethods", fileCfg.outTypeName),
+		idempotentMethodsName(fileCfg.outTypeName),
+		idempotentRunnerNamesName(fileCfg.outTypeName),
+		descriptorName(fileCfg.outTypeName),
+	}
+	for _, mm := range methods {
+		if strategyFor(mm, cfg.IgnoreNoReturnMethods) == retryableStrategy {
+			identifiers = append(identifiers, mm.FallbackOptionName(fileCfg.outTypeName))
+		}
+		if convertsErrors(mm, cfg.IgnoreNoReturnMethods) {
+			identifiers = append(identifiers, mm.ErrorConverterOptionName(fileCfg.outTypeName))
+		}
+	}
+	return identifiers
+}
+
+// checkIdentifiers makes sure that the generated types aren't named after the other identifiers declared by the
+// generated code, e.g. a type named ClientDescriptor collides with the descriptor of a type named Client
+func checkIdentifiers(cfg Config, fileMethods [][]*method.Method) error {
+	declaredBy := make(map[string]string)
+	for _, identifier := range commonIdentifiers(cfg) {
+		declaredBy[identifier] = "the common code"
+	}
+	for idx, fileCfg := range cfg.Files {
+		for _, identifier := range typeIdentifiers(cfg, fileCfg, fileMethods[idx]) {
+			declaredBy[identifier] = fileCfg.outTypeName
+		}
+	}
+	for _, fileCfg := range cfg.Files {
+		if owner, ok := declaredBy[fileCfg.outTypeName]; ok {
+			return fmt.Errorf("type %s collides with an identifier generated for %s", fileCfg.outTypeName, owner)
+		}
+	}
+	return nil
+}
+
+func idempotentMethodsName(outTypeName string) string {
+	return outTypeName + "IdempotentMethods"
+}
+
+func idempotentRunnerNamesName(outTypeName string) string {
+	return outTypeName + "IdempotentRunnerNames"
+}
+
+// convertsErrors determines whether the given method has a custom error type whose errors emitted by the middlewares go
+// through an error converter
+func convertsErrors(mm *method.Method, ignoreNoReturnMethods bool) bool {
+	return mm.CustomErrorType && strategyFor(mm, ignoreNoReturnMethods) == retryableStrategy
+}
+
+// skipsRetries determines whether the retries of the given method are skipped by the generated code, the methods
+// annotated with //reinforcer:noretry run through the middlewares without their retry middlewares
+func skipsRetries(mm *method.Method, ignoreNoReturnMethods bool) bool {
+	return mm.NoRetry && strategyFor(mm, ignoreNoReturnMethods) != passThroughStrategy
+}
+
+// proxyVariants lists the proxies generated for the given method, the method's own proxy comes first and is followed by
+// its context variant and its error variant when they're generated
+func proxyVariants(cfg Config, strategy string, mm *method.Method) []*method.Method {
+	variants := []*method.Method{mm}
+	if cfg.ContextVariants && !mm.HasContext && strategy != passThroughStrategy {
+		variant := *variants[len(variants)-1]
+		variant.ContextVariant = true
+		variants = append(variants, &variant)
+	}
+	if cfg.ErrorVariants && strategy == noReturnStrategy {
+		variant := *variants[len(variants)-1]
+		variant.ErrorVariant = true
+		variants = append(variants, &variant)
+	}
+	return variants
+}
+
+// proxyStatement generates the proxy of the given method with the given strategy
+func proxyStatement(strategy string, mm *method.Method, fileCfg *FileConfig) (*jen.Statement, error) {
+	var p statement
+	switch strategy {
+	case passThroughStrategy:
+		p = passthrough.NewPassThrough(mm, fileCfg.outTypeName, fileCfg.typeArgs, fileCfg.receiverName())
+	case retryableStrategy:
+		p = retryable.NewRetryable(mm, fileCfg.outTypeName, fileCfg.typeArgs, fileCfg.receiverName())
+	default:
+		p = noret.NewNoReturn(mm, fileCfg.outTypeName, fileCfg.typeArgs, fileCfg.receiverName())
+	}
+	return p.Statement()
+}
+
+// variantCall generates the proxy of a method that calls the next variant of the proxy, methods without a context call
+// their context variant with context.Background() and methods without results hand the error returned by their error
+// variant to the no return error handler
+func variantCall(mm *method.Method, next *method.Method, fileCfg *FileConfig) *jen.Statement {
+	args := mm.ProxyArguments()
+	if next.ContextVariant && !mm.ContextVariant {
+		args = append([]jen.Code{jen.Qual("context", "Background").Call()}, args...)
+	}
+	nextCall := jen.Id(fileCfg.receiverName()).Dot(next.ProxyName()).Call(args...)
+
+	var block []jen.Code
+	switch {
+	case next.ErrorVariant && !mm.ErrorVariant:
+		// if err := r.FnE(args...); err != nil {
+		//   r.noReturnErrorHandler(ResilientMethods.Fn, err)
+		// }
+		block = append(block, jen.If(jen.Id("err").Op(":=").Add(nextCall), jen.Id("err").Op("!=").Nil()).Block(
+			jen.Id(fileCfg.receiverName()).Dot("noReturnErrorHandler").Call(mm.ConstantRef(fileCfg.outTypeName), jen.Id("err")),
+		))
+	case len(mm.ReturnTypes) > 0:
+		block = append(block, jen.Return(nextCall))
+	default:
+		block = append(block, nextCall)
+	}
+	return jen.Func().Params(jen.Id(fileCfg.receiverName()).Op("*").Id(fileCfg.outTypeName).Types(fileCfg.typeArgs...)).Id(mm.ProxyName()).Call(mm.ProxyParameters()...).Params(mm.ReturnTypes...).Block(
+		block...,
+	)
+}
+
+func generateCommon(cfg Config, outTypeNames []string, errorConverters, noRetry bool) (string, error) {
+	f := jen.NewFile(cfg.OutPkg)
 	f.HeaderComment(fileHeader)
 
 	// Declare base impl that will be used to hold the common fields
-	f.Add(jen.Type().Id("base").Struct(
+	baseFields := []jen.Code{
+		jen.Id("typeName").Id("string"),
 		jen.Id("errorPredicate").Add(jen.Func().Params(jen.Id("string"), jen.Id("error")).Params(jen.Bool())),
 		jen.Id("runnerFactory").Id("runnerFactory"),
-	))
+		jen.Id("runnerName").Add(jen.Func().Params(jen.Id("string"), jen.Id("string")).Params(jen.Id("string"))),
+		jen.Id("runners").Map(jen.String()).String(),
+		jen.Id("noReturnErrorHandler").Add(jen.Func().Params(jen.Id("string"), jen.Id("error"))),
+		jen.Id("nonRetryableErrorHandler").Add(jen.Func().Params(jen.Qual("context", "Context"), jen.Id("string"), jen.Id("error"))),
+		jen.Id("fallbacks").Map(jen.String()).Interface(),
+	}
+	if errorConverters {
+		baseFields = append(baseFields, jen.Id("errorConverters").Map(jen.String()).Interface())
+	}
+	if noRetry {
+		baseFields = append(baseFields, jen.Id("noRetry").Map(jen.String()).Bool())
+	}
+	if cfg.Tracing {
+		baseFields = append(baseFields, jen.Id("tracer").Op("*").Qual(tracingPkg, "Tracer"))
+	}
+	if cfg.Observer {
+		baseFields = append(baseFields, jen.Id("observer").Id("Observer"))
+	}
+	if cfg.Logging {
+		baseFields = append(baseFields, jen.Id("logger").Op("*").Qual(loggingPkg, "Logger"))
+	}
+	f.Add(jen.Type().Id("base").Struct(baseFields...))
 
 	// Declares the runner's factory
 	f.Add(jen.Type().Id("runnerFactory").Interface(
@@ -233,6 +624,25 @@ func generateCommon(outPkg string) (string, error) {
 		jen.Return(jen.Lit(true)),
 	))
 
+	// Declare the QualifiedRunnerName function that isolates the runners of every type by prefixing the method with the
+	// type
+	f.Add(jen.Var().Id("QualifiedRunnerName").Op("=").Func().Params(jen.Id("typeName").Id("string"), jen.Id("method").Id("string")).Params(jen.Id("string")).Block(
+		jen.Return(jen.Id("typeName").Op("+").Lit(".").Op("+").Id("method")),
+	))
+
+	// Declare the MethodRunnerName function that shares the runners between methods with the same name across types
+	f.Add(jen.Var().Id("MethodRunnerName").Op("=").Func().Params(jen.Id("_").Id("string"), jen.Id("method").Id("string")).Params(jen.Id("string")).Block(
+		jen.Return(jen.Id("method")),
+	))
+
+	// Declare the PanicOnNoReturnError handler that panics when the middlewares emit an error for a method without results
+	f.Add(jen.Var().Id("PanicOnNoReturnError").Op("=").Func().Params(jen.Id("_").Id("string"), jen.Id("err").Id("error")).Block(
+		jen.Panic(jen.Id("err")),
+	))
+
+	// Declare the IgnoreNonRetryableError handler that does nothing with the errors classified as non-retryable
+	f.Add(jen.Var().Id("IgnoreNonRetryableError").Op("=").Func().Params(jen.Id("_").Qual("context", "Context"), jen.Id("_").Id("string"), jen.Id("_").Id("error")).Block())
+
 	// Declare the Option type that allows to configure the service
 	f.Add(jen.Type().Id("Option").Func().Params(jen.Op("*").Id("base")))
 
@@ -243,17 +653,201 @@ func generateCommon(outPkg string) (string, error) {
 		)),
 	))
 
+	// Declare the WithNoReturnErrorHandler Option which configures the handler of the errors emitted by the middlewares
+	// for methods without results
+	f.Add(jen.Func().Id("WithNoReturnErrorHandler").Params(jen.Id("fn").Id("func").Params(jen.Id("string"), jen.Id("error"))).Params(jen.Id("Option")).Block(
+		jen.Return(jen.Func().Params(jen.Id("o").Op("*").Id("base")).Block(
+			jen.Id("o").Dot("noReturnErrorHandler").Op("=").Id("fn"),
+		)),
+	))
+
+	// Declare the WithNonRetryableErrorHandler Option which configures the handler of the errors classified as
+	// non-retryable by the predicate, the handler is called with the context of the attempt (e.g. see
+	// runner.RecordNonRetryable)
+	f.Add(jen.Func().Id("WithNonRetryableErrorHandler").Params(jen.Id("fn").Id("func").Params(jen.Qual("context", "Context"), jen.Id("string"), jen.Id("error"))).Params(jen.Id("Option")).Block(
+		jen.Return(jen.Func().Params(jen.Id("o").Op("*").Id("base")).Block(
+			jen.Id("o").Dot("nonRetryableErrorHandler").Op("=").Id("fn"),
+		)),
+	))
+
+	// Declare the WithRunnerName Option which configures how the name of the runner for a method is built
+	f.Add(jen.Func().Id("WithRunnerName").Params(jen.Id("fn").Id("func").Params(jen.Id("string"), jen.Id("string")).Params(jen.Id("string"))).Params(jen.Id("Option")).Block(
+		jen.Return(jen.Func().Params(jen.Id("o").Op("*").Id("base")).Block(
+			jen.Id("o").Dot("runnerName").Op("=").Id("fn"),
+		)),
+	))
+
+	if cfg.Tracing {
+		// Declare the WithTracer Option which configures the tracer that creates the spans of the calls
+		f.Add(jen.Func().Id("WithTracer").Params(jen.Id("tracer").Op("*").Qual(tracingPkg, "Tracer")).Params(jen.Id("Option")).Block(
+			jen.Return(jen.Func().Params(jen.Id("o").Op("*").Id("base")).Block(
+				jen.Id("o").Dot("tracer").Op("=").Id("tracer"),
+			)),
+		))
+	}
+
+	if cfg.Logging {
+		// Declare the WithLogger Option which configures the logger of the calls, nothing is logged without a logger
+		f.Add(jen.Func().Id("WithLogger").Params(jen.Id("logger").Op("*").Qual(loggingPkg, "Logger")).Params(jen.Id("Option")).Block(
+			jen.Return(jen.Func().Params(jen.Id("o").Op("*").Id("base")).Block(
+				jen.Id("o").Dot("logger").Op("=").Id("logger"),
+			)),
+		))
+	}
+
+	// Declare the helper that names the runner of a method
+	f.Add(jen.Func().Params(jen.Id("b").Op("*").Id("base")).Id("runnerFor").Params(jen.Id("name").String()).String().Block(
+		// The methods annotated with a runner name use it regardless of the runner name function
+		jen.If(jen.List(jen.Id("runner"), jen.Id("ok")).Op(":=").Id("b").Dot("runners").Index(jen.Id("name")), jen.Id("ok")).Block(
+			jen.Return(jen.Id("runner")),
+		),
+		jen.Return(jen.Id("b").Dot("runnerName").Call(jen.Id("b").Dot("typeName"), jen.Id("name"))),
+	))
+
 	// Declare our runner helper
+	var runStatements []jen.Code
+	getRunner := jen.Id("b").Dot("runnerFactory").Dot("GetRunner").Call(jen.Id("b").Dot("runnerFor").Call(jen.Id("name")))
+	if noRetry {
+		// The calls to the methods annotated with //reinforcer:noretry still run through the other middlewares (e.g. the
+		// circuit breakers) so that they see the errors, only the retry middlewares are skipped
+		runStatements = append(runStatements,
+			jen.Id("r").Op(":=").Add(getRunner),
+			jen.If(jen.Id("b").Dot("noRetry").Index(jen.Id("name"))).Block(
+				jen.Id("r").Op("=").Qual(runnerPkg, "SkipRetries").Call(jen.Id("r")),
+			),
+		)
+		getRunner = jen.Id("r")
+	}
+	if cfg.Logging {
+		// The logger wraps the runner to log the retries and the rejections of the call
+		getRunner = jen.Id("b").Dot("logger").Dot("Runner").Call(jen.Id("b").Dot("typeName"), jen.Id("name"), getRunner)
+	}
+	runCall := jen.Add(getRunner).Dot("Run").Call(jen.Id("ctx"), jen.Id("fn"))
+	if cfg.Observer {
+		runCall = jen.Id("b").Dot("runObserved").Call(jen.Id("ctx"), jen.Id("name"), getRunner, jen.Id("fn"))
+	}
+	if cfg.Tracing {
+		// The span of the call is the parent of the spans of its attempts
+		runStatements = append(runStatements,
+			jen.List(jen.Id("ctx"), jen.Id("call")).Op(":=").Id("b").Dot("tracer").Dot("StartCall").Call(jen.Id("ctx"), jen.Id("b").Dot("typeName"), jen.Id("name")),
+			jen.Id("err").Op(":=").Add(runCall),
+			jen.Id("call").Dot("End").Call(jen.Id("err")),
+			jen.Return(jen.Id("err")),
+		)
+	} else {
+		runStatements = append(runStatements, jen.Return(runCall))
+	}
 	f.Add(jen.Func().Params(jen.Id("b").Op("*").Id("base")).Id("run").Params(
 		jen.Id("ctx").Qual("context", "Context"),
 		jen.Id("name").Id("string"),
 		jen.Id("fn").Func().Params(jen.Id("ctx").Qual("context", "Context")).Id("error"),
-	).Id("error").Block(
-		jen.Return(jen.Id("b").Dot("runnerFactory").Dot("GetRunner").Call(jen.Id("name")).Dot("Run").Call(jen.Id("ctx"), jen.Id("fn"))),
-	))
+	).Id("error").Block(runStatements...))
+
+	if cfg.Observer {
+		// Declare the Observer and the helpers that observe the calls
+		generateObserverTypes(f)
+	}
+
+	if cfg.RecoverPanics {
+		// Declare the PanicError and the helper that recovers the panics of the delegates
+		generatePanicTypes(f, cfg.Logging)
+	}
+
+	if cfg.AbandonOnContextDone {
+		// Declare the helper that abandons the calls once the context is done
+		f.Add(jen.Comment("abandon runs fn in its own goroutine and waits for it to return unless the context is done first, in which case"))
+		f.Add(jen.Comment("the goroutine is abandoned (it keeps running until fn returns) and context.DeadlineExceeded is returned. The"))
+		f.Add(jen.Comment("results of fn must be discarded unless nil is returned since the middlewares may have returned already."))
+		f.Add(jen.Func().Id("abandon").Params(jen.Id("ctx").Qual("context", "Context"), jen.Id("fn").Func().Params()).Error().Block(
+			jen.Id("done").Op(":=").Make(jen.Chan().Struct()),
+			jen.Go().Func().Params().Block(
+				jen.Defer().Close(jen.Id("done")),
+				jen.Id("fn").Call(),
+			).Call(),
+			jen.Select().Block(
+				jen.Case(jen.Op("<-").Id("done")).Block(
+					// The middlewares may have returned already when the call completes as the context expires
+					jen.If(jen.Id("ctx").Dot("Err").Call().Op("!=").Nil()).Block(
+						jen.Return(jen.Qual("context", "DeadlineExceeded")),
+					),
+					jen.Return(jen.Nil()),
+				),
+				jen.Case(jen.Op("<-").Id("ctx").Dot("Done").Call()).Block(
+					jen.Return(jen.Qual("context", "DeadlineExceeded")),
+				),
+			),
+		))
+	}
+
+	// Declare the descriptors of the generated types
+	generateDescriptorTypes(f, outTypeNames)
 	return renderToString(f)
 }
 
+// declaredMethods filters out the methods promoted from embedded types
+func declaredMethods(methods []*method.Method) []*method.Method {
+	var declared []*method.Method
+	for _, mm := range methods {
+		if mm.PromotedFrom == "" {
+			declared = append(declared, mm)
+		} else {
+			log.Debug().Msgf("Ignoring method %s promoted from %s", mm.Name, mm.PromotedFrom)
+		}
+	}
+	return declared
+}
+
+// markIdempotent marks the methods of the given type that are listed as idempotent, the names in the list are either
+// bare method names or qualified with the type name (e.g. Client.GetUser)
+func markIdempotent(typeName string, methods []*method.Method, idempotent []string) {
+	names := make(map[string]struct{}, len(idempotent))
+	for _, name := range idempotent {
+		names[name] = struct{}{}
+	}
+	for _, mm := range methods {
+		_, bare := names[mm.Name]
+		_, qualified := names[typeName+"."+mm.Name]
+		if bare || qualified {
+			mm.Idempotent = true
+		}
+	}
+}
+
+// unskippedMethods filters out the methods annotated to be skipped
+func unskippedMethods(methods []*method.Method) []*method.Method {
+	var unskipped []*method.Method
+	for _, mm := range methods {
+		if mm.Skip {
+			log.Debug().Msgf("Skipping method %s", mm.Name)
+			continue
+		}
+		unskipped = append(unskipped, mm)
+	}
+	return unskipped
+}
+
+// methodDoc renders the doc comment for the given method, promoted methods without a doc comment describe where they're
+// promoted from
+func methodDoc(mm *method.Method) []jen.Code {
+	if mm.Doc == "" && mm.PromotedFrom != "" {
+		return docComment(fmt.Sprintf("%s is promoted from %s", mm.Name, mm.PromotedFrom))
+	}
+	return docComment(mm.Doc)
+}
+
+// docComment renders the given doc comment text as line comments
+func docComment(doc string) []jen.Code {
+	doc = strings.TrimSuffix(doc, "\n")
+	if doc == "" {
+		return nil
+	}
+	var comments []jen.Code
+	for _, line := range strings.Split(doc, "\n") {
+		comments = append(comments, jen.Comment(line))
+	}
+	return comments
+}
+
 func renderToString(f *jen.File) (string, error) {
 	b := &bytes.Buffer{}
 	if err := f.Render(b); err != nil {
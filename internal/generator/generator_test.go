@@ -2,10 +2,16 @@ package generator_test
 
 import (
 	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"regexp"
 	"strings"
 	"testing"
 
 	"github.com/clear-street/reinforcer/internal/generator"
+	"github.com/clear-street/reinforcer/internal/generator/method"
 	"github.com/clear-street/reinforcer/internal/loader"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/tools/go/packages"
@@ -17,33 +23,8 @@ type input struct {
 	code          string
 }
 
-func TestGenerator_Generate(t *testing.T) {
-	tests := []struct {
-		name                  string
-		ignoreNoReturnMethods bool
-		inputs                map[string]input
-		outCode               *generator.Generated
-		wantErr               bool
-	}{
-		{
-			name:                  "Using aliased import",
-			ignoreNoReturnMethods: false,
-			inputs: map[string]input{
-				"my_service.go": {
-					interfaceName: "Service",
-					code: `package fake
-
-import goctx "context"
-
-type Service interface {
-	A(ctx goctx.Context) error
-	B(ctx goctx.Context, fn func(myArg string) (myBool bool)) (func() bool, error)
-}
-`,
-				},
-			},
-			outCode: &generator.Generated{
-				Common: `// Code generated by reinforcer, DO NOT EDIT.
+// wantCommon is the common code generated in every case of TestGenerator_Generate
+const wantCommon = `// Code generated by reinforcer, DO NOT EDIT.
 
 package resilient
 
@@ -53,8 +34,14 @@ import (
 )
 
 type base struct {
-	errorPredicate func(string, error) bool
-	runnerFactory  runnerFactory
+	typeName                 string
+	errorPredicate           func(string, error) bool
+	runnerFactory            runnerFactory
+	runnerName               func(string, string) string
+	runners                  map[string]string
+	noReturnErrorHandler     func(string, error)
+	nonRetryableErrorHandler func(context.Context, string, error)
+	fallbacks                map[string]interface{}
 }
 type runnerFactory interface {
 	GetRunner(name string) goresilience.Runner
@@ -63,6 +50,16 @@ type runnerFactory interface {
 var RetryAllErrors = func(_ string, _ error) bool {
 	return true
 }
+var QualifiedRunnerName = func(typeName string, method string) string {
+	return typeName + "." + method
+}
+var MethodRunnerName = func(_ string, method string) string {
+	return method
+}
+var PanicOnNoReturnError = func(_ string, err error) {
+	panic(err)
+}
+var IgnoreNonRetryableError = func(_ context.Context, _ string, _ error) {}
 
 type Option func(*base)
 
@@ -71,10 +68,59 @@ func WithRetryableErrorPredicate(fn func(string, error) bool) Option {
 		o.errorPredicate = fn
 	}
 }
+func WithNoReturnErrorHandler(fn func(string, error)) Option {
+	return func(o *base) {
+		o.noReturnErrorHandler = fn
+	}
+}
+func WithNonRetryableErrorHandler(fn func(context.Context, string, error)) Option {
+	return func(o *base) {
+		o.nonRetryableErrorHandler = fn
+	}
+}
+func WithRunnerName(fn func(string, string) string) Option {
+	return func(o *base) {
+		o.runnerName = fn
+	}
+}
+func (b *base) runnerFor(name string) string {
+	if runner, ok := b.runners[name]; ok {
+		return runner
+	}
+	return b.runnerName(b.typeName, name)
+}
 func (b *base) run(ctx context.Context, name string, fn func(ctx context.Context) error) error {
-	return b.runnerFactory.GetRunner(name).Run(ctx, fn)
+	return b.runnerFactory.GetRunner(b.runnerFor(name)).Run(ctx, fn)
+}
+`
+
+func TestGenerator_Generate(t *testing.T) {
+	tests := []struct {
+		name                  string
+		ignoreNoReturnMethods bool
+		inputs                map[string]input
+		outCode               *generator.Generated
+		wantErr               bool
+	}{
+		{
+			name:                  "Using aliased import",
+			ignoreNoReturnMethods: false,
+			inputs: map[string]input{
+				"my_service.go": {
+					interfaceName: "Service",
+					code: `package fake
+
+import goctx "context"
+
+type Service interface {
+	A(ctx goctx.Context) error
+	B(ctx goctx.Context, fn func(myArg string) (myBool bool)) (func() bool, error)
 }
 `,
+				},
+			},
+			outCode: &generator.Generated{
+				Common: wantCommon,
 				Files: []*generator.GeneratedFile{
 					{
 						TypeName: "GeneratedService",
@@ -95,13 +141,16 @@ var GeneratedServiceMethods = struct {
 
 type targetService interface {
 	A(ctx context.Context) error
-	B(ctx context.Context, arg1 func(string) bool) (func() bool, error)
+	B(ctx context.Context, fn func(string) bool) (func() bool, error)
 }
+
+// GeneratedService wraps a Service delegate, calls to its methods run through the middlewares built by the runner factory
 type GeneratedService struct {
 	*base
 	delegate targetService
 }
 
+// NewGeneratedService creates a GeneratedService that reinforces the given delegate with the middlewares built by the runner factory
 func NewGeneratedService(delegate targetService, runnerFactory runnerFactory, options ...Option) *GeneratedService {
 	if delegate == nil {
 		panic("provided nil delegate")
@@ -111,8 +160,12 @@ func NewGeneratedService(delegate targetService, runnerFactory runnerFactory, op
 	}
 	c := &GeneratedService{
 		base: &base{
-			errorPredicate: RetryAllErrors,
-			runnerFactory:  runnerFactory,
+			errorPredicate:           RetryAllErrors,
+			noReturnErrorHandler:     PanicOnNoReturnError,
+			nonRetryableErrorHandler: IgnoreNonRetryableError,
+			runnerFactory:            runnerFactory,
+			runnerName:               QualifiedRunnerName,
+			typeName:                 "GeneratedService",
 		},
 		delegate: delegate,
 	}
@@ -129,29 +182,41 @@ func (g *GeneratedService) A(ctx context.Context) error {
 		if g.errorPredicate(GeneratedServiceMethods.A, err) {
 			return err
 		}
+		g.nonRetryableErrorHandler(ctx, GeneratedServiceMethods.A, err)
 		nonRetryableErr = err
 		return nil
 	})
 	if nonRetryableErr != nil {
 		return nonRetryableErr
 	}
+	if err != nil {
+		if fallback, ok := g.fallbacks["GeneratedService.A"].(func(context.Context, error) error); ok {
+			return fallback(ctx, err)
+		}
+	}
 	return err
 }
-func (g *GeneratedService) B(ctx context.Context, arg1 func(string) bool) (func() bool, error) {
+func (g *GeneratedService) B(ctx context.Context, fn func(string) bool) (func() bool, error) {
 	var nonRetryableErr error
 	var r0 func() bool
 	err := g.run(ctx, GeneratedServiceMethods.B, func(ctx context.Context) error {
 		var err error
-		r0, err = g.delegate.B(ctx, arg1)
+		r0, err = g.delegate.B(ctx, fn)
 		if g.errorPredicate(GeneratedServiceMethods.B, err) {
 			return err
 		}
+		g.nonRetryableErrorHandler(ctx, GeneratedServiceMethods.B, err)
 		nonRetryableErr = err
 		return nil
 	})
 	if nonRetryableErr != nil {
 		return r0, nonRetryableErr
 	}
+	if err != nil {
+		if fallback, ok := g.fallbacks["GeneratedService.B"].(func(context.Context, error) (func() bool, error)); ok {
+			return fallback(ctx, err)
+		}
+	}
 	return r0, err
 }
 `,
@@ -177,45 +242,18 @@ type Service interface {
 	A()
 	B(ctx context.Context)
 	C(ctx context.Context, param1 int, param2 *int32, param3 *User)
+	// GetUserID returns the ID of the user
 	GetUserID(ctx context.Context, userID string) (string, error)
 	GetUserID2(ctx context.Context, userID *string) (*User, error)
+	// HasVariadic updates the given fields.
+	//
+	// Deprecated: use GetUserID
 	HasVariadic(ctx context.Context, fields ...string) error
 }`,
 				},
 			},
 			outCode: &generator.Generated{
-				Common: `// Code generated by reinforcer, DO NOT EDIT.
-
-package resilient
-
-import (
-	"context"
-	goresilience "github.com/slok/goresilience"
-)
-
-type base struct {
-	errorPredicate func(string, error) bool
-	runnerFactory  runnerFactory
-}
-type runnerFactory interface {
-	GetRunner(name string) goresilience.Runner
-}
-
-var RetryAllErrors = func(_ string, _ error) bool {
-	return true
-}
-
-type Option func(*base)
-
-func WithRetryableErrorPredicate(fn func(string, error) bool) Option {
-	return func(o *base) {
-		o.errorPredicate = fn
-	}
-}
-func (b *base) run(ctx context.Context, name string, fn func(ctx context.Context) error) error {
-	return b.runnerFactory.GetRunner(name).Run(ctx, fn)
-}
-`,
+				Common: wantCommon,
 				Files: []*generator.GeneratedFile{
 					{
 						TypeName: "GeneratedService",
@@ -248,16 +286,23 @@ var GeneratedServiceMethods = struct {
 type targetService interface {
 	A()
 	B(ctx context.Context)
-	C(ctx context.Context, arg1 int, arg2 *int32, arg3 *unresilient.User)
-	GetUserID(ctx context.Context, arg1 string) (string, error)
-	GetUserID2(ctx context.Context, arg1 *string) (*unresilient.User, error)
-	HasVariadic(ctx context.Context, arg1 ...string) error
+	C(ctx context.Context, param1 int, param2 *int32, param3 *unresilient.User)
+	// GetUserID returns the ID of the user
+	GetUserID(ctx context.Context, userID string) (string, error)
+	GetUserID2(ctx context.Context, userID *string) (*unresilient.User, error)
+	// HasVariadic updates the given fields.
+	//
+	// Deprecated: use GetUserID
+	HasVariadic(ctx context.Context, fields ...string) error
 }
+
+// GeneratedService wraps a Service delegate, calls to its methods run through the middlewares built by the runner factory
 type GeneratedService struct {
 	*base
 	delegate targetService
 }
 
+// NewGeneratedService creates a GeneratedService that reinforces the given delegate with the middlewares built by the runner factory
 func NewGeneratedService(delegate targetService, runnerFactory runnerFactory, options ...Option) *GeneratedService {
 	if delegate == nil {
 		panic("provided nil delegate")
@@ -267,8 +312,12 @@ func NewGeneratedService(delegate targetService, runnerFactory runnerFactory, op
 	}
 	c := &GeneratedService{
 		base: &base{
-			errorPredicate: RetryAllErrors,
-			runnerFactory:  runnerFactory,
+			errorPredicate:           RetryAllErrors,
+			noReturnErrorHandler:     PanicOnNoReturnError,
+			nonRetryableErrorHandler: IgnoreNonRetryableError,
+			runnerFactory:            runnerFactory,
+			runnerName:               QualifiedRunnerName,
+			typeName:                 "GeneratedService",
 		},
 		delegate: delegate,
 	}
@@ -283,7 +332,7 @@ func (g *GeneratedService) A() {
 		return nil
 	})
 	if err != nil {
-		panic(err)
+		g.noReturnErrorHandler(GeneratedServiceMethods.A, err)
 	}
 }
 func (g *GeneratedService) B(ctx context.Context) {
@@ -292,66 +341,90 @@ func (g *GeneratedService) B(ctx context.Context) {
 		return nil
 	})
 	if err != nil {
-		panic(err)
+		g.noReturnErrorHandler(GeneratedServiceMethods.B, err)
 	}
 }
-func (g *GeneratedService) C(ctx context.Context, arg1 int, arg2 *int32, arg3 *unresilient.User) {
+func (g *GeneratedService) C(ctx context.Context, param1 int, param2 *int32, param3 *unresilient.User) {
 	err := g.run(ctx, GeneratedServiceMethods.C, func(ctx context.Context) error {
-		g.delegate.C(ctx, arg1, arg2, arg3)
+		g.delegate.C(ctx, param1, param2, param3)
 		return nil
 	})
 	if err != nil {
-		panic(err)
+		g.noReturnErrorHandler(GeneratedServiceMethods.C, err)
 	}
 }
-func (g *GeneratedService) GetUserID(ctx context.Context, arg1 string) (string, error) {
+
+// GetUserID returns the ID of the user
+func (g *GeneratedService) GetUserID(ctx context.Context, userID string) (string, error) {
 	var nonRetryableErr error
 	var r0 string
 	err := g.run(ctx, GeneratedServiceMethods.GetUserID, func(ctx context.Context) error {
 		var err error
-		r0, err = g.delegate.GetUserID(ctx, arg1)
+		r0, err = g.delegate.GetUserID(ctx, userID)
 		if g.errorPredicate(GeneratedServiceMethods.GetUserID, err) {
 			return err
 		}
+		g.nonRetryableErrorHandler(ctx, GeneratedServiceMethods.GetUserID, err)
 		nonRetryableErr = err
 		return nil
 	})
 	if nonRetryableErr != nil {
 		return r0, nonRetryableErr
 	}
+	if err != nil {
+		if fallback, ok := g.fallbacks["GeneratedService.GetUserID"].(func(context.Context, error) (string, error)); ok {
+			return fallback(ctx, err)
+		}
+	}
 	return r0, err
 }
-func (g *GeneratedService) GetUserID2(ctx context.Context, arg1 *string) (*unresilient.User, error) {
+func (g *GeneratedService) GetUserID2(ctx context.Context, userID *string) (*unresilient.User, error) {
 	var nonRetryableErr error
 	var r0 *unresilient.User
 	err := g.run(ctx, GeneratedServiceMethods.GetUserID2, func(ctx context.Context) error {
 		var err error
-		r0, err = g.delegate.GetUserID2(ctx, arg1)
+		r0, err = g.delegate.GetUserID2(ctx, userID)
 		if g.errorPredicate(GeneratedServiceMethods.GetUserID2, err) {
 			return err
 		}
+		g.nonRetryableErrorHandler(ctx, GeneratedServiceMethods.GetUserID2, err)
 		nonRetryableErr = err
 		return nil
 	})
 	if nonRetryableErr != nil {
 		return r0, nonRetryableErr
 	}
+	if err != nil {
+		if fallback, ok := g.fallbacks["GeneratedService.GetUserID2"].(func(context.Context, error) (*unresilient.User, error)); ok {
+			return fallback(ctx, err)
+		}
+	}
 	return r0, err
 }
-func (g *GeneratedService) HasVariadic(ctx context.Context, arg1 ...string) error {
+
+// HasVariadic updates the given fields.
+//
+// Deprecated: use GetUserID
+func (g *GeneratedService) HasVariadic(ctx context.Context, fields ...string) error {
 	var nonRetryableErr error
 	err := g.run(ctx, GeneratedServiceMethods.HasVariadic, func(ctx context.Context) error {
 		var err error
-		err = g.delegate.HasVariadic(ctx, arg1...)
+		err = g.delegate.HasVariadic(ctx, fields...)
 		if g.errorPredicate(GeneratedServiceMethods.HasVariadic, err) {
 			return err
 		}
+		g.nonRetryableErrorHandler(ctx, GeneratedServiceMethods.HasVariadic, err)
 		nonRetryableErr = err
 		return nil
 	})
 	if nonRetryableErr != nil {
 		return nonRetryableErr
 	}
+	if err != nil {
+		if fallback, ok := g.fallbacks["GeneratedService.HasVariadic"].(func(context.Context, error) error); ok {
+			return fallback(ctx, err)
+		}
+	}
 	return err
 }
 `,
@@ -380,38 +453,7 @@ type Service interface {
 				},
 			},
 			outCode: &generator.Generated{
-				Common: `// Code generated by reinforcer, DO NOT EDIT.
-
-package resilient
-
-import (
-	"context"
-	goresilience "github.com/slok/goresilience"
-)
-
-type base struct {
-	errorPredicate func(string, error) bool
-	runnerFactory  runnerFactory
-}
-type runnerFactory interface {
-	GetRunner(name string) goresilience.Runner
-}
-
-var RetryAllErrors = func(_ string, _ error) bool {
-	return true
-}
-
-type Option func(*base)
-
-func WithRetryableErrorPredicate(fn func(string, error) bool) Option {
-	return func(o *base) {
-		o.errorPredicate = fn
-	}
-}
-func (b *base) run(ctx context.Context, name string, fn func(ctx context.Context) error) error {
-	return b.runnerFactory.GetRunner(name).Run(ctx, fn)
-}
-`,
+				Common: wantCommon,
 				Files: []*generator.GeneratedFile{
 					{
 						TypeName: "GeneratedService",
@@ -432,13 +474,16 @@ var GeneratedServiceMethods = struct {
 
 type targetService interface {
 	A()
-	B(ctx context.Context, arg1 string) (string, error)
+	B(ctx context.Context, userID string) (string, error)
 }
+
+// GeneratedService wraps a Service delegate, calls to its methods run through the middlewares built by the runner factory
 type GeneratedService struct {
 	*base
 	delegate targetService
 }
 
+// NewGeneratedService creates a GeneratedService that reinforces the given delegate with the middlewares built by the runner factory
 func NewGeneratedService(delegate targetService, runnerFactory runnerFactory, options ...Option) *GeneratedService {
 	if delegate == nil {
 		panic("provided nil delegate")
@@ -448,8 +493,12 @@ func NewGeneratedService(delegate targetService, runnerFactory runnerFactory, op
 	}
 	c := &GeneratedService{
 		base: &base{
-			errorPredicate: RetryAllErrors,
-			runnerFactory:  runnerFactory,
+			errorPredicate:           RetryAllErrors,
+			noReturnErrorHandler:     PanicOnNoReturnError,
+			nonRetryableErrorHandler: IgnoreNonRetryableError,
+			runnerFactory:            runnerFactory,
+			runnerName:               QualifiedRunnerName,
+			typeName:                 "GeneratedService",
 		},
 		delegate: delegate,
 	}
@@ -461,21 +510,27 @@ func NewGeneratedService(delegate targetService, runnerFactory runnerFactory, op
 func (g *GeneratedService) A() {
 	g.delegate.A()
 }
-func (g *GeneratedService) B(ctx context.Context, arg1 string) (string, error) {
+func (g *GeneratedService) B(ctx context.Context, userID string) (string, error) {
 	var nonRetryableErr error
 	var r0 string
 	err := g.run(ctx, GeneratedServiceMethods.B, func(ctx context.Context) error {
 		var err error
-		r0, err = g.delegate.B(ctx, arg1)
+		r0, err = g.delegate.B(ctx, userID)
 		if g.errorPredicate(GeneratedServiceMethods.B, err) {
 			return err
 		}
+		g.nonRetryableErrorHandler(ctx, GeneratedServiceMethods.B, err)
 		nonRetryableErr = err
 		return nil
 	})
 	if nonRetryableErr != nil {
 		return r0, nonRetryableErr
 	}
+	if err != nil {
+		if fallback, ok := g.fallbacks["GeneratedService.B"].(func(context.Context, error) (string, error)); ok {
+			return fallback(ctx, err)
+		}
+	}
 	return r0, err
 }
 `,
@@ -501,38 +556,7 @@ type Service interface {
 				},
 			},
 			outCode: &generator.Generated{
-				Common: `// Code generated by reinforcer, DO NOT EDIT.
-
-package resilient
-
-import (
-	"context"
-	goresilience "github.com/slok/goresilience"
-)
-
-type base struct {
-	errorPredicate func(string, error) bool
-	runnerFactory  runnerFactory
-}
-type runnerFactory interface {
-	GetRunner(name string) goresilience.Runner
-}
-
-var RetryAllErrors = func(_ string, _ error) bool {
-	return true
-}
-
-type Option func(*base)
-
-func WithRetryableErrorPredicate(fn func(string, error) bool) Option {
-	return func(o *base) {
-		o.errorPredicate = fn
-	}
-}
-func (b *base) run(ctx context.Context, name string, fn func(ctx context.Context) error) error {
-	return b.runnerFactory.GetRunner(name).Run(ctx, fn)
-}
-`,
+				Common: wantCommon,
 				Files: []*generator.GeneratedFile{
 					{
 						TypeName: "GeneratedService",
@@ -553,13 +577,16 @@ var GeneratedServiceMethods = struct {
 }
 
 type targetService interface {
-	SaveUser(arg0 *unresilient.T) error
+	SaveUser(user *unresilient.T) error
 }
+
+// GeneratedService wraps a Service delegate, calls to its methods run through the middlewares built by the runner factory
 type GeneratedService struct {
 	*base
 	delegate targetService
 }
 
+// NewGeneratedService creates a GeneratedService that reinforces the given delegate with the middlewares built by the runner factory
 func NewGeneratedService(delegate targetService, runnerFactory runnerFactory, options ...Option) *GeneratedService {
 	if delegate == nil {
 		panic("provided nil delegate")
@@ -569,8 +596,12 @@ func NewGeneratedService(delegate targetService, runnerFactory runnerFactory, op
 	}
 	c := &GeneratedService{
 		base: &base{
-			errorPredicate: RetryAllErrors,
-			runnerFactory:  runnerFactory,
+			errorPredicate:           RetryAllErrors,
+			noReturnErrorHandler:     PanicOnNoReturnError,
+			nonRetryableErrorHandler: IgnoreNonRetryableError,
+			runnerFactory:            runnerFactory,
+			runnerName:               QualifiedRunnerName,
+			typeName:                 "GeneratedService",
 		},
 		delegate: delegate,
 	}
@@ -579,20 +610,26 @@ func NewGeneratedService(delegate targetService, runnerFactory runnerFactory, op
 	}
 	return c
 }
-func (g *GeneratedService) SaveUser(arg0 *unresilient.T) error {
+func (g *GeneratedService) SaveUser(user *unresilient.T) error {
 	var nonRetryableErr error
-	err := g.run(context.Background(), GeneratedServiceMethods.SaveUser, func(_ context.Context) error {
+	err := g.run(context.Background(), GeneratedServiceMethods.SaveUser, func(ctx context.Context) error {
 		var err error
-		err = g.delegate.SaveUser(arg0)
+		err = g.delegate.SaveUser(user)
 		if g.errorPredicate(GeneratedServiceMethods.SaveUser, err) {
 			return err
 		}
+		g.nonRetryableErrorHandler(ctx, GeneratedServiceMethods.SaveUser, err)
 		nonRetryableErr = err
 		return nil
 	})
 	if nonRetryableErr != nil {
 		return nonRetryableErr
 	}
+	if err != nil {
+		if fallback, ok := g.fallbacks["GeneratedService.SaveUser"].(func(context.Context, error) error); ok {
+			return fallback(context.Background(), err)
+		}
+	}
 	return err
 }
 `,
@@ -616,38 +653,7 @@ type Service interface {
 				},
 			},
 			outCode: &generator.Generated{
-				Common: `// Code generated by reinforcer, DO NOT EDIT.
-
-package resilient
-
-import (
-	"context"
-	goresilience "github.com/slok/goresilience"
-)
-
-type base struct {
-	errorPredicate func(string, error) bool
-	runnerFactory  runnerFactory
-}
-type runnerFactory interface {
-	GetRunner(name string) goresilience.Runner
-}
-
-var RetryAllErrors = func(_ string, _ error) bool {
-	return true
-}
-
-type Option func(*base)
-
-func WithRetryableErrorPredicate(fn func(string, error) bool) Option {
-	return func(o *base) {
-		o.errorPredicate = fn
-	}
-}
-func (b *base) run(ctx context.Context, name string, fn func(ctx context.Context) error) error {
-	return b.runnerFactory.GetRunner(name).Run(ctx, fn)
-}
-`,
+				Common: wantCommon,
 				Files: []*generator.GeneratedFile{
 					{
 						TypeName: "GeneratedService",
@@ -669,15 +675,18 @@ var GeneratedServiceMethods = struct {
 }
 
 type targetService interface {
-	ReceiveDir(arg0 <-chan error) error
-	SendDir(arg0 chan<- error) error
-	SendReceiveDir(arg0 chan error) error
+	ReceiveDir(myChan <-chan error) error
+	SendDir(myChan chan<- error) error
+	SendReceiveDir(myChan chan error) error
 }
+
+// GeneratedService wraps a Service delegate, calls to its methods run through the middlewares built by the runner factory
 type GeneratedService struct {
 	*base
 	delegate targetService
 }
 
+// NewGeneratedService creates a GeneratedService that reinforces the given delegate with the middlewares built by the runner factory
 func NewGeneratedService(delegate targetService, runnerFactory runnerFactory, options ...Option) *GeneratedService {
 	if delegate == nil {
 		panic("provided nil delegate")
@@ -687,8 +696,12 @@ func NewGeneratedService(delegate targetService, runnerFactory runnerFactory, op
 	}
 	c := &GeneratedService{
 		base: &base{
-			errorPredicate: RetryAllErrors,
-			runnerFactory:  runnerFactory,
+			errorPredicate:           RetryAllErrors,
+			noReturnErrorHandler:     PanicOnNoReturnError,
+			nonRetryableErrorHandler: IgnoreNonRetryableError,
+			runnerFactory:            runnerFactory,
+			runnerName:               QualifiedRunnerName,
+			typeName:                 "GeneratedService",
 		},
 		delegate: delegate,
 	}
@@ -697,52 +710,70 @@ func NewGeneratedService(delegate targetService, runnerFactory runnerFactory, op
 	}
 	return c
 }
-func (g *GeneratedService) ReceiveDir(arg0 <-chan error) error {
+func (g *GeneratedService) ReceiveDir(myChan <-chan error) error {
 	var nonRetryableErr error
-	err := g.run(context.Background(), GeneratedServiceMethods.ReceiveDir, func(_ context.Context) error {
+	err := g.run(context.Background(), GeneratedServiceMethods.ReceiveDir, func(ctx context.Context) error {
 		var err error
-		err = g.delegate.ReceiveDir(arg0)
+		err = g.delegate.ReceiveDir(myChan)
 		if g.errorPredicate(GeneratedServiceMethods.ReceiveDir, err) {
 			return err
 		}
+		g.nonRetryableErrorHandler(ctx, GeneratedServiceMethods.ReceiveDir, err)
 		nonRetryableErr = err
 		return nil
 	})
 	if nonRetryableErr != nil {
 		return nonRetryableErr
 	}
+	if err != nil {
+		if fallback, ok := g.fallbacks["GeneratedService.ReceiveDir"].(func(context.Context, error) error); ok {
+			return fallback(context.Background(), err)
+		}
+	}
 	return err
 }
-func (g *GeneratedService) SendDir(arg0 chan<- error) error {
+func (g *GeneratedService) SendDir(myChan chan<- error) error {
 	var nonRetryableErr error
-	err := g.run(context.Background(), GeneratedServiceMethods.SendDir, func(_ context.Context) error {
+	err := g.run(context.Background(), GeneratedServiceMethods.SendDir, func(ctx context.Context) error {
 		var err error
-		err = g.delegate.SendDir(arg0)
+		err = g.delegate.SendDir(myChan)
 		if g.errorPredicate(GeneratedServiceMethods.SendDir, err) {
 			return err
 		}
+		g.nonRetryableErrorHandler(ctx, GeneratedServiceMethods.SendDir, err)
 		nonRetryableErr = err
 		return nil
 	})
 	if nonRetryableErr != nil {
 		return nonRetryableErr
 	}
+	if err != nil {
+		if fallback, ok := g.fallbacks["GeneratedService.SendDir"].(func(context.Context, error) error); ok {
+			return fallback(context.Background(), err)
+		}
+	}
 	return err
 }
-func (g *GeneratedService) SendReceiveDir(arg0 chan error) error {
+func (g *GeneratedService) SendReceiveDir(myChan chan error) error {
 	var nonRetryableErr error
-	err := g.run(context.Background(), GeneratedServiceMethods.SendReceiveDir, func(_ context.Context) error {
+	err := g.run(context.Background(), GeneratedServiceMethods.SendReceiveDir, func(ctx context.Context) error {
 		var err error
-		err = g.delegate.SendReceiveDir(arg0)
+		err = g.delegate.SendReceiveDir(myChan)
 		if g.errorPredicate(GeneratedServiceMethods.SendReceiveDir, err) {
 			return err
 		}
+		g.nonRetryableErrorHandler(ctx, GeneratedServiceMethods.SendReceiveDir, err)
 		nonRetryableErr = err
 		return nil
 	})
 	if nonRetryableErr != nil {
 		return nonRetryableErr
 	}
+	if err != nil {
+		if fallback, ok := g.fallbacks["GeneratedService.SendReceiveDir"].(func(context.Context, error) error); ok {
+			return fallback(context.Background(), err)
+		}
+	}
 	return err
 }
 `,
@@ -765,38 +796,7 @@ type service interface {
 				},
 			},
 			outCode: &generator.Generated{
-				Common: `// Code generated by reinforcer, DO NOT EDIT.
-
-package resilient
-
-import (
-	"context"
-	goresilience "github.com/slok/goresilience"
-)
-
-type base struct {
-	errorPredicate func(string, error) bool
-	runnerFactory  runnerFactory
-}
-type runnerFactory interface {
-	GetRunner(name string) goresilience.Runner
-}
-
-var RetryAllErrors = func(_ string, _ error) bool {
-	return true
-}
-
-type Option func(*base)
-
-func WithRetryableErrorPredicate(fn func(string, error) bool) Option {
-	return func(o *base) {
-		o.errorPredicate = fn
-	}
-}
-func (b *base) run(ctx context.Context, name string, fn func(ctx context.Context) error) error {
-	return b.runnerFactory.GetRunner(name).Run(ctx, fn)
-}
-`,
+				Common: wantCommon,
 				Files: []*generator.GeneratedFile{
 					{
 						TypeName: "GeneratedService",
@@ -814,13 +814,16 @@ var GeneratedServiceMethods = struct {
 }
 
 type targetService interface {
-	SayHello(arg0 string) error
+	SayHello(name string) error
 }
+
+// GeneratedService wraps a Service delegate, calls to its methods run through the middlewares built by the runner factory
 type GeneratedService struct {
 	*base
 	delegate targetService
 }
 
+// NewGeneratedService creates a GeneratedService that reinforces the given delegate with the middlewares built by the runner factory
 func NewGeneratedService(delegate targetService, runnerFactory runnerFactory, options ...Option) *GeneratedService {
 	if delegate == nil {
 		panic("provided nil delegate")
@@ -830,8 +833,12 @@ func NewGeneratedService(delegate targetService, runnerFactory runnerFactory, op
 	}
 	c := &GeneratedService{
 		base: &base{
-			errorPredicate: RetryAllErrors,
-			runnerFactory:  runnerFactory,
+			errorPredicate:           RetryAllErrors,
+			noReturnErrorHandler:     PanicOnNoReturnError,
+			nonRetryableErrorHandler: IgnoreNonRetryableError,
+			runnerFactory:            runnerFactory,
+			runnerName:               QualifiedRunnerName,
+			typeName:                 "GeneratedService",
 		},
 		delegate: delegate,
 	}
@@ -840,20 +847,26 @@ func NewGeneratedService(delegate targetService, runnerFactory runnerFactory, op
 	}
 	return c
 }
-func (g *GeneratedService) SayHello(arg0 string) error {
+func (g *GeneratedService) SayHello(name string) error {
 	var nonRetryableErr error
-	err := g.run(context.Background(), GeneratedServiceMethods.SayHello, func(_ context.Context) error {
+	err := g.run(context.Background(), GeneratedServiceMethods.SayHello, func(ctx context.Context) error {
 		var err error
-		err = g.delegate.SayHello(arg0)
+		err = g.delegate.SayHello(name)
 		if g.errorPredicate(GeneratedServiceMethods.SayHello, err) {
 			return err
 		}
+		g.nonRetryableErrorHandler(ctx, GeneratedServiceMethods.SayHello, err)
 		nonRetryableErr = err
 		return nil
 	})
 	if nonRetryableErr != nil {
 		return nonRetryableErr
 	}
+	if err != nil {
+		if fallback, ok := g.fallbacks["GeneratedService.SayHello"].(func(context.Context, error) error); ok {
+			return fallback(context.Background(), err)
+		}
+	}
 	return err
 }
 `,
@@ -877,42 +890,11 @@ type Service[T any] interface {
 				},
 			},
 			outCode: &generator.Generated{
-				Common: `// Code generated by reinforcer, DO NOT EDIT.
-
-package resilient
-
-import (
-	"context"
-	goresilience "github.com/slok/goresilience"
-)
-
-type base struct {
-	errorPredicate func(string, error) bool
-	runnerFactory  runnerFactory
-}
-type runnerFactory interface {
-	GetRunner(name string) goresilience.Runner
-}
-
-var RetryAllErrors = func(_ string, _ error) bool {
-	return true
-}
-
-type Option func(*base)
-
-func WithRetryableErrorPredicate(fn func(string, error) bool) Option {
-	return func(o *base) {
-		o.errorPredicate = fn
-	}
-}
-func (b *base) run(ctx context.Context, name string, fn func(ctx context.Context) error) error {
-	return b.runnerFactory.GetRunner(name).Run(ctx, fn)
-}
-`,
-				Files: []*generator.GeneratedFile{
-					{
-						TypeName: "GeneratedService",
-						Contents: `// Code generated by reinforcer, DO NOT EDIT.
+				Common: wantCommon,
+				Files: []*generator.GeneratedFile{
+					{
+						TypeName: "GeneratedService",
+						Contents: `// Code generated by reinforcer, DO NOT EDIT.
 
 package resilient
 
@@ -929,13 +911,16 @@ var GeneratedServiceMethods = struct {
 
 type targetService[T any] interface {
 	DoNothing()
-	SayHello(arg0 T) error
+	SayHello(name T) error
 }
+
+// GeneratedService wraps a Service delegate, calls to its methods run through the middlewares built by the runner factory
 type GeneratedService[T any] struct {
 	*base
 	delegate targetService[T]
 }
 
+// NewGeneratedService creates a GeneratedService that reinforces the given delegate with the middlewares built by the runner factory
 func NewGeneratedService[T any](delegate targetService[T], runnerFactory runnerFactory, options ...Option) *GeneratedService[T] {
 	if delegate == nil {
 		panic("provided nil delegate")
@@ -945,8 +930,12 @@ func NewGeneratedService[T any](delegate targetService[T], runnerFactory runnerF
 	}
 	c := &GeneratedService[T]{
 		base: &base{
-			errorPredicate: RetryAllErrors,
-			runnerFactory:  runnerFactory,
+			errorPredicate:           RetryAllErrors,
+			noReturnErrorHandler:     PanicOnNoReturnError,
+			nonRetryableErrorHandler: IgnoreNonRetryableError,
+			runnerFactory:            runnerFactory,
+			runnerName:               QualifiedRunnerName,
+			typeName:                 "GeneratedService",
 		},
 		delegate: delegate,
 	}
@@ -958,20 +947,26 @@ func NewGeneratedService[T any](delegate targetService[T], runnerFactory runnerF
 func (g *GeneratedService[T]) DoNothing() {
 	g.delegate.DoNothing()
 }
-func (g *GeneratedService[T]) SayHello(arg0 T) error {
+func (g *GeneratedService[T]) SayHello(name T) error {
 	var nonRetryableErr error
-	err := g.run(context.Background(), GeneratedServiceMethods.SayHello, func(_ context.Context) error {
+	err := g.run(context.Background(), GeneratedServiceMethods.SayHello, func(ctx context.Context) error {
 		var err error
-		err = g.delegate.SayHello(arg0)
+		err = g.delegate.SayHello(name)
 		if g.errorPredicate(GeneratedServiceMethods.SayHello, err) {
 			return err
 		}
+		g.nonRetryableErrorHandler(ctx, GeneratedServiceMethods.SayHello, err)
 		nonRetryableErr = err
 		return nil
 	})
 	if nonRetryableErr != nil {
 		return nonRetryableErr
 	}
+	if err != nil {
+		if fallback, ok := g.fallbacks["GeneratedService.SayHello"].(func(context.Context, error) error); ok {
+			return fallback(context.Background(), err)
+		}
+	}
 	return err
 }
 `,
@@ -986,9 +981,11 @@ func (g *GeneratedService[T]) SayHello(arg0 T) error {
 
 			ifaces := loadInterface(t, tt.inputs)
 			got, err := generator.Generate(generator.Config{
-				OutPkg:                "resilient",
-				Files:                 ifaces,
-				IgnoreNoReturnMethods: tt.ignoreNoReturnMethods,
+				OutPkg: "resilient",
+				Files:  ifaces,
+				Options: generator.Options{
+					IgnoreNoReturnMethods: tt.ignoreNoReturnMethods,
+				},
 			})
 
 			if tt.wantErr {
@@ -997,18 +994,241 @@ func (g *GeneratedService[T]) SayHello(arg0 T) error {
 				require.NoError(t, err)
 				require.NotNil(t, got)
 
-				require.Equal(t, tt.outCode.Common, got.Common)
+				require.Equal(t, tt.outCode.Common, withoutFeatureDecls(t, got.Common))
 
+				require.Len(t, got.Files, len(tt.outCode.Files))
 				for idx, genFile := range got.Files {
+					contents := withoutFeatureDecls(t, genFile.Contents)
 					require.Equal(t, tt.outCode.Files[idx].TypeName, genFile.TypeName)
-					require.Equal(t, tt.outCode.Files[idx].Contents, genFile.Contents, "Contents don't match. Got:\n%s", genFile.Contents)
+					require.Equal(t, tt.outCode.Files[idx].Contents, contents, "Contents don't match. Got:\n%s", contents)
 				}
-				require.Equal(t, tt.outCode, got)
 			}
 		})
 	}
 }
 
+// featureDecls matches the declarations generated for the features that are asserted by their own tests (descriptors,
+// idempotent methods and fallbacks), they're left out of the goldens of TestGenerator_Generate
+var featureDecls = regexp.MustCompile(`^(.*Descriptors?|.+IdempotentMethods|.+IdempotentRunnerNames|With.+Fallback)$`)
+
+// withoutFeatureDecls removes the declarations matched by featureDecls (and their doc comments) from the generated code
+func withoutFeatureDecls(t *testing.T, code string) string {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "", code, parser.ParseComments)
+	require.NoError(t, err)
+
+	var b strings.Builder
+	last := 0
+	for _, decl := range file.Decls {
+		start, name := decl.Pos(), ""
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			name = d.Name.Name
+			if d.Recv != nil {
+				name = types.ExprString(d.Recv.List[0].Type)
+			}
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		case *ast.GenDecl:
+			if spec, ok := d.Specs[0].(*ast.ValueSpec); ok {
+				name = spec.Names[0].Name
+			} else if spec, ok := d.Specs[0].(*ast.TypeSpec); ok {
+				name = spec.Name.Name
+			}
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		}
+		if !featureDecls.MatchString(strings.TrimPrefix(name, "*")) {
+			continue
+		}
+		// The file set only holds this file so the positions are the offsets plus one, the blank line before the
+		// declaration is removed with it
+		cut := int(start) - 1
+		if cut > last && code[cut-2:cut] == "\n\n" {
+			cut--
+		}
+		b.WriteString(code[last:cut])
+		last = int(decl.End()) - 1
+		if last < len(code) && code[last] == '\n' {
+			last++
+		}
+	}
+	b.WriteString(code[last:])
+	return b.String()
+}
+
+func TestGenerator_Generate_PromotedMethods(t *testing.T) {
+	inputs := map[string]input{
+		"store.go": {
+			interfaceName: "Store",
+			code: `package fake
+
+type Reader interface {
+	Read(key string) (string, error)
+}
+
+type Store interface {
+	Reader
+	// Write writes the value
+	Write(key, value string) error
+}
+`,
+		},
+	}
+
+	t.Run("Promoted methods are generated", func(t *testing.T) {
+		got, err := generator.Generate(generator.Config{
+			OutPkg: "resilient",
+			Files:  loadInterface(t, inputs),
+		})
+		require.NoError(t, err)
+		require.Contains(t, got.Files[0].Contents, `
+// Read is promoted from Reader
+func (g *GeneratedStore) Read(key string) (string, error) {`)
+		require.Contains(t, got.Files[0].Contents, `
+// Write writes the value
+func (g *GeneratedStore) Write(key string, value string) error {`)
+	})
+
+	t.Run("Ignore promoted methods", func(t *testing.T) {
+		got, err := generator.Generate(generator.Config{
+			OutPkg: "resilient",
+			Files:  loadInterface(t, inputs),
+			Options: generator.Options{
+				IgnorePromotedMethods: true,
+			},
+		})
+		require.NoError(t, err)
+		require.NotContains(t, got.Files[0].Contents, "Read")
+		require.Contains(t, got.Files[0].Contents, "func (g *GeneratedStore) Write(key string, value string) error {")
+	})
+}
+
+func TestGenerator_Generate_Directives(t *testing.T) {
+	got, err := generator.Generate(generator.Config{
+		OutPkg: "resilient",
+		Files: loadInterface(t, map[string]input{
+			"service.go": {
+				interfaceName: "Service",
+				code: `package fake
+
+type Service interface {
+	// Internal isn't exposed
+	//
+	//reinforcer:skip
+	Internal() error
+	//reinforcer:passthrough
+	Ping() error
+	// Submit submits the order
+	//reinforcer:noretry
+	//reinforcer:runner=payments-write
+	Submit(order string) (string, error)
+	Notify(msg string)
+}
+`,
+			},
+		}),
+	})
+	require.NoError(t, err)
+	contents := got.Files[0].Contents
+	require.NotContains(t, contents, "Internal")
+	require.Contains(t, contents, `
+			noRetry:                  map[string]bool{GeneratedServiceMethods.Submit: true},`)
+	require.Contains(t, contents, `
+			runners:                  map[string]string{GeneratedServiceMethods.Submit: "payments-write"},`)
+	// The calls to the methods annotated with //reinforcer:noretry skip the retry middlewares
+	require.Contains(t, got.Common, `
+func (b *base) run(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	r := b.runnerFactory.GetRunner(b.runnerFor(name))
+	if b.noRetry[name] {
+		r = runner.SkipRetries(r)
+	}
+	return r.Run(ctx, fn)
+}`)
+	require.Contains(t, contents, `
+func (g *GeneratedService) Ping() error {
+	return g.delegate.Ping()
+}`)
+	require.Contains(t, contents, `
+// Submit submits the order
+func (g *GeneratedService) Submit(order string) (string, error) {
+	var nonRetryableErr error
+	var r0 string
+	err := g.run(context.Background(), GeneratedServiceMethods.Submit, func(ctx context.Context) error {
+		var err error
+		r0, err = g.delegate.Submit(order)
+		if g.errorPredicate(GeneratedServiceMethods.Submit, err) {
+			return err
+		}
+		g.nonRetryableErrorHandler(ctx, GeneratedServiceMethods.Submit, err)
+		nonRetryableErr = err
+		return nil
+	})`)
+	require.Contains(t, contents, `
+func (g *GeneratedService) Notify(msg string) {
+	err := g.run(context.Background(), GeneratedServiceMethods.Notify, func(_ context.Context) error {`)
+}
+
+func TestGenerator_Generate_Idempotent(t *testing.T) {
+	inputs := map[string]input{
+		"store.go": {
+			interfaceName: "Store",
+			code: `package fake
+
+type Store interface {
+	//reinforcer:idempotent
+	Get(key string) (string, error)
+	//reinforcer:runner=store-list
+	List() ([]string, error)
+	Put(key, value string) error
+	Delete(key string) error
+	Idempotent() error
+}
+`,
+		},
+	}
+
+	got, err := generator.Generate(generator.Config{
+		OutPkg: "resilient",
+		Files:  loadInterface(t, inputs),
+		Options: generator.Options{
+			IdempotentMethods: []string{"Store.List", "Delete", "Other.Put"},
+		},
+	})
+	require.NoError(t, err)
+	contents := got.Files[0].Contents
+	require.Contains(t, contents, `
+// GeneratedStoreIdempotentMethods lists the methods in GeneratedStore that are safe to be retried
+var GeneratedStoreIdempotentMethods = []string{GeneratedStoreMethods.Delete, GeneratedStoreMethods.Get, GeneratedStoreMethods.List}
+`)
+	// The runner names of the idempotent methods are built like the ones of their calls, e.g. List runs through store-list
+	require.Contains(t, contents, `
+			runners:                  map[string]string{GeneratedStoreMethods.List: "store-list"},`)
+	require.Contains(t, contents, `
+func GeneratedStoreIdempotentRunnerNames(g *GeneratedStore) []string {
+	names := make([]string, 0, len(GeneratedStoreIdempotentMethods))
+	for _, name := range GeneratedStoreIdempotentMethods {
+		names = append(names, g.runnerFor(name))
+	}
+	return names
+}`)
+	require.Contains(t, got.Common, `
+func (b *base) runnerFor(name string) string {
+	if runner, ok := b.runners[name]; ok {
+		return runner
+	}
+	return b.runnerName(b.typeName, name)
+}
+func (b *base) run(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	return b.runnerFactory.GetRunner(b.runnerFor(name)).Run(ctx, fn)
+}`)
+	// A method can be named Idempotent
+	require.Contains(t, contents, `
+func (g *GeneratedStore) Idempotent() error {`)
+}
+
 func loadInterface(t *testing.T, filesCode map[string]input) []*generator.FileConfig {
 	pkg := "github.com/clear-street/fake/unresilient"
 	m := map[string]interface{}{}
@@ -1047,3 +1267,735 @@ func loadInterface(t *testing.T, filesCode map[string]input) []*generator.FileCo
 	}
 	return loadedTypes
 }
+
+func TestGenerator_Generate_Descriptor(t *testing.T) {
+	inputs := map[string]input{
+		"service.go": {
+			interfaceName: "Service",
+			code: `package fake
+
+type Service interface {
+	//reinforcer:idempotent
+	Get(key string) (string, error)
+	//reinforcer:passthrough
+	Ping() error
+	Notify(msg string)
+}
+`,
+		},
+	}
+
+	for name, tc := range map[string]struct {
+		ignoreNoReturnMethods bool
+		notifyStrategy        string
+	}{
+		"Wrapped no return methods": {ignoreNoReturnMethods: false, notifyStrategy: "noreturn"},
+		"Ignored no return methods": {ignoreNoReturnMethods: true, notifyStrategy: "passthrough"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := generator.Generate(generator.Config{
+				OutPkg: "resilient",
+				Files:  loadInterface(t, inputs),
+				Options: generator.Options{
+					IgnoreNoReturnMethods: tc.ignoreNoReturnMethods,
+				},
+			})
+			require.NoError(t, err)
+			require.Contains(t, got.Common, `// Descriptors returns the descriptors of all the generated types
+func Descriptors() []*TypeDescriptor {
+	return []*TypeDescriptor{GeneratedServiceDescriptor}
+}`)
+			require.Contains(t, got.Files[0].Contents, `// GeneratedServiceDescriptor describes GeneratedService and its methods
+var GeneratedServiceDescriptor = &TypeDescriptor{
+	methods: []MethodDescriptor{
+		{
+			HasContext:   false,
+			Idempotent:   true,
+			Name:         GeneratedServiceMethods.Get,
+			Position:     "github.com/clear-street/fake/unresilient/service.go:5",
+			ReturnsError: true,
+			Signature:    "Get(key string) (string, error)",
+			Strategy:     "retryable",
+		},
+		{
+			HasContext:   false,
+			Idempotent:   false,
+			Name:         GeneratedServiceMethods.Notify,
+			Position:     "github.com/clear-street/fake/unresilient/service.go:8",
+			ReturnsError: false,
+			Signature:    "Notify(msg string)",
+			Strategy:     "`+tc.notifyStrategy+`",
+		},
+		{
+			HasContext:   false,
+			Idempotent:   false,
+			Name:         GeneratedServiceMethods.Ping,
+			Position:     "github.com/clear-street/fake/unresilient/service.go:7",
+			ReturnsError: true,
+			Signature:    "Ping() error",
+			Strategy:     "passthrough",
+		},
+	},
+	name: "GeneratedService",
+}`)
+		})
+	}
+
+	t.Run("Types are listed by name", func(t *testing.T) {
+		got, err := generator.Generate(generator.Config{
+			OutPkg: "resilient",
+			Files: loadInterface(t, map[string]input{
+				"zebra.go": {
+					interfaceName: "Zebra",
+					code:          "package fake\n\ntype Zebra interface {\n\tRun() error\n}\n",
+				},
+				"aardvark.go": {
+					interfaceName: "Aardvark",
+					code:          "package fake\n\ntype Aardvark interface {\n\tDig() error\n}\n",
+				},
+				"mole.go": {
+					interfaceName: "Mole",
+					code:          "package fake\n\ntype Mole interface {\n\tDig() error\n}\n",
+				},
+			}),
+		})
+		require.NoError(t, err)
+		require.Contains(t, got.Common, `	return []*TypeDescriptor{GeneratedAardvarkDescriptor, GeneratedMoleDescriptor, GeneratedZebraDescriptor}`)
+	})
+}
+
+func TestGenerator_Generate_Collisions(t *testing.T) {
+	newFile := func(name string) *generator.FileConfig {
+		errVar := types.NewVar(token.NoPos, nil, "", types.Universe.Lookup("error").Type())
+		signature := types.NewSignatureType(nil, nil, nil, types.NewTuple(), types.NewTuple(errVar), false)
+		return generator.NewFileConfig(name, name, nil, nil, []*method.Method{method.MustParseMethod("Get", signature)})
+	}
+
+	for name, tc := range map[string]struct {
+		typeNames []string
+		cfg       generator.Config
+		err       string
+	}{
+		"Common identifier": {
+			typeNames: []string{"TypeDescriptor"},
+			err:       "type TypeDescriptor collides with an identifier generated for the common code",
+		},
+		"Tracing option": {
+			typeNames: []string{"WithTracer"},
+			cfg:       generator.Config{Options: generator.Options{Tracing: true}},
+			err:       "type WithTracer collides with an identifier generated for the common code",
+		},
+		"Observer": {
+			typeNames: []string{"Observer"},
+			cfg:       generator.Config{Options: generator.Options{Observer: true}},
+			err:       "type Observer collides with an identifier generated for the common code",
+		},
+		"Logging option": {
+			typeNames: []string{"WithLogger"},
+			cfg:       generator.Config{Options: generator.Options{Logging: true}},
+			err:       "type WithLogger collides with an identifier generated for the common code",
+		},
+		"Panic error": {
+			typeNames: []string{"PanicError"},
+			cfg:       generator.Config{Options: generator.Options{RecoverPanics: true}},
+			err:       "type PanicError collides with an identifier generated for the common code",
+		},
+		"Descriptor of another type": {
+			typeNames: []string{"Client", "ClientDescriptor"},
+			err:       "type ClientDescriptor collides with an identifier generated for Client",
+		},
+		"Option of another type": {
+			typeNames: []string{"WithClientGetFallback", "Client"},
+			err:       "type WithClientGetFallback collides with an identifier generated for Client",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var files []*generator.FileConfig
+			for _, typeName := range tc.typeNames {
+				files = append(files, newFile(typeName))
+			}
+			cfg := tc.cfg
+			cfg.OutPkg = "resilient"
+			cfg.Files = files
+			_, err := generator.Generate(cfg)
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestGenerator_Generate_ContextVariants(t *testing.T) {
+	inputs := map[string]input{
+		"service.go": {
+			interfaceName: "Service",
+			code: `package fake
+
+import "context"
+
+type Service interface {
+	Get(key string) (string, error)
+	Notify(msg string)
+	Put(ctx context.Context, key, value string) error
+	//reinforcer:passthrough
+	Ping() error
+}
+`,
+		},
+	}
+
+	got, err := generator.Generate(generator.Config{
+		OutPkg: "resilient",
+		Files:  loadInterface(t, inputs),
+		Options: generator.Options{
+			ContextVariants:      true,
+			AbandonOnContextDone: true,
+		},
+	})
+	require.NoError(t, err)
+	require.Contains(t, got.Common, `func abandon(ctx context.Context, fn func()) error {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	select {
+	case <-done:
+		if ctx.Err() != nil {
+			return context.DeadlineExceeded
+		}
+		return nil
+	case <-ctx.Done():
+		return context.DeadlineExceeded
+	}
+}`)
+	contents := got.Files[0].Contents
+	require.Contains(t, contents, `func (g *GeneratedService) Get(key string) (string, error) {
+	return g.GetCtx(context.Background(), key)
+}
+
+// GetCtx calls Get, the given context is handed to the middlewares
+func (g *GeneratedService) GetCtx(ctx context.Context, key string) (string, error) {`)
+	require.Contains(t, contents, `func (g *GeneratedService) Notify(msg string) {
+	g.NotifyCtx(context.Background(), msg)
+}
+
+// NotifyCtx calls Notify, the given context is handed to the middlewares
+func (g *GeneratedService) NotifyCtx(ctx context.Context, msg string) {
+	err := g.run(ctx, GeneratedServiceMethods.Notify, func(ctx context.Context) error {
+		return abandon(ctx, func() {
+			g.delegate.Notify(msg)
+		})
+	})`)
+	// Methods with a context and pass through methods don't have a context variant
+	require.NotContains(t, contents, "PutCtx")
+	require.NotContains(t, contents, "PingCtx")
+	require.Contains(t, contents, `		err = g.delegate.Put(ctx, key, value)`)
+
+	t.Run("Without the options", func(t *testing.T) {
+		got, err := generator.Generate(generator.Config{
+			OutPkg: "resilient",
+			Files:  loadInterface(t, inputs),
+		})
+		require.NoError(t, err)
+		require.NotContains(t, got.Common, "abandon")
+		require.NotContains(t, got.Files[0].Contents, "GetCtx")
+	})
+
+	t.Run("Collision", func(t *testing.T) {
+		_, err := generator.Generate(generator.Config{
+			OutPkg: "resilient",
+			Files: loadInterface(t, map[string]input{
+				"store.go": {
+					interfaceName: "Store",
+					code: `package fake
+
+type Store interface {
+	Get(key string) (string, error)
+	GetCtx(key string) (string, error)
+}
+`,
+				},
+			}),
+			Options: generator.Options{
+				ContextVariants: true,
+			},
+		})
+		require.EqualError(t, err, "method GetCtx of Store collides with the context variant of Get")
+	})
+}
+
+func TestGenerator_Generate_ErrorVariants(t *testing.T) {
+	inputs := map[string]input{
+		"service.go": {
+			interfaceName: "Service",
+			code: `package fake
+
+type Service interface {
+	Get(key string) (string, error)
+	Notify(msg string)
+}
+`,
+		},
+	}
+
+	t.Run("Error variants", func(t *testing.T) {
+		got, err := generator.Generate(generator.Config{
+			OutPkg: "resilient",
+			Files:  loadInterface(t, inputs),
+			Options: generator.Options{
+				ErrorVariants: true,
+			},
+		})
+		require.NoError(t, err)
+		contents := got.Files[0].Contents
+		require.Contains(t, contents, `func (g *GeneratedService) Notify(msg string) {
+	if err := g.NotifyE(msg); err != nil {
+		g.noReturnErrorHandler(GeneratedServiceMethods.Notify, err)
+	}
+}
+
+// NotifyE calls Notify, the errors emitted by the middlewares are returned instead of being handed to the no return error handler
+func (g *GeneratedService) NotifyE(msg string) error {
+	return g.run(context.Background(), GeneratedServiceMethods.Notify, func(_ context.Context) error {
+		g.delegate.Notify(msg)
+		return nil
+	})
+}`)
+		// Methods with results don't have an error variant
+		require.NotContains(t, contents, "GetE")
+	})
+
+	t.Run("Context and error variants", func(t *testing.T) {
+		got, err := generator.Generate(generator.Config{
+			OutPkg: "resilient",
+			Files:  loadInterface(t, inputs),
+			Options: generator.Options{
+				ContextVariants: true,
+				ErrorVariants:   true,
+			},
+		})
+		require.NoError(t, err)
+		require.Contains(t, got.Files[0].Contents, `func (g *GeneratedService) Notify(msg string) {
+	g.NotifyCtx(context.Background(), msg)
+}
+
+// NotifyCtx calls Notify, the given context is handed to the middlewares
+func (g *GeneratedService) NotifyCtx(ctx context.Context, msg string) {
+	if err := g.NotifyCtxE(ctx, msg); err != nil {
+		g.noReturnErrorHandler(GeneratedServiceMethods.Notify, err)
+	}
+}
+
+// NotifyCtxE calls Notify, the errors emitted by the middlewares are returned instead of being handed to the no return error handler
+func (g *GeneratedService) NotifyCtxE(ctx context.Context, msg string) error {
+	return g.run(ctx, GeneratedServiceMethods.Notify, func(ctx context.Context) error {
+		g.delegate.Notify(msg)
+		return nil
+	})
+}`)
+	})
+
+	t.Run("Ignored no return methods", func(t *testing.T) {
+		got, err := generator.Generate(generator.Config{
+			OutPkg: "resilient",
+			Files:  loadInterface(t, inputs),
+			Options: generator.Options{
+				IgnoreNoReturnMethods: true,
+				ErrorVariants:         true,
+			},
+		})
+		require.NoError(t, err)
+		require.NotContains(t, got.Files[0].Contents, "NotifyE")
+	})
+
+	t.Run("Collision", func(t *testing.T) {
+		_, err := generator.Generate(generator.Config{
+			OutPkg: "resilient",
+			Files: loadInterface(t, map[string]input{
+				"service.go": {
+					interfaceName: "Service",
+					code: `package fake
+
+type Service interface {
+	Notify(msg string)
+	NotifyE(msg string) error
+}
+`,
+				},
+			}),
+			Options: generator.Options{
+				ErrorVariants: true,
+			},
+		})
+		require.EqualError(t, err, "method NotifyE of Service collides with the error variant of Notify")
+	})
+}
+
+func TestGenerator_Generate_Fallbacks(t *testing.T) {
+	got, err := generator.Generate(generator.Config{
+		OutPkg: "resilient",
+		Files: loadInterface(t, map[string]input{
+			"service.go": {
+				interfaceName: "Service",
+				code: `package fake
+
+import "context"
+
+type User struct {
+	Name string
+}
+
+type Service interface {
+	GetUser(ctx context.Context, id string) (*User, error)
+	Notify(msg string)
+}
+`,
+			},
+		}),
+	})
+	require.NoError(t, err)
+	contents := got.Files[0].Contents
+	require.Contains(t, contents, `// WithGeneratedServiceGetUserFallback configures the fallback of GeneratedService.GetUser, the fallback is called with the error when the middlewares return an error and its results are returned to the caller
+func WithGeneratedServiceGetUserFallback(fn func(context.Context, error) (*unresilient.User, error)) Option {
+	return func(o *base) {
+		if o.fallbacks == nil {
+			o.fallbacks = map[string]interface{}{}
+		}
+		o.fallbacks["GeneratedService.GetUser"] = fn
+	}
+}`)
+	require.Contains(t, contents, `	if err != nil {
+		if fallback, ok := g.fallbacks["GeneratedService.GetUser"].(func(context.Context, error) (*unresilient.User, error)); ok {
+			return fallback(ctx, err)
+		}
+	}
+	return r0, err
+}`)
+	// Methods without an error have nothing to fall back from
+	require.NotContains(t, contents, "WithGeneratedServiceNotifyFallback")
+}
+
+func TestGenerator_Generate_ErrorConverters(t *testing.T) {
+	got, err := generator.Generate(generator.Config{
+		OutPkg: "resilient",
+		Files: loadInterface(t, map[string]input{
+			"service.go": {
+				interfaceName: "Service",
+				code: `package fake
+
+type ValidationError struct {
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Reason
+}
+
+type Service interface {
+	Validate(id string) *ValidationError
+	Get(id string) (string, error)
+}
+`,
+			},
+		}),
+	})
+	require.NoError(t, err)
+	require.Contains(t, got.Common, "	errorConverters          map[string]interface{}\n")
+	contents := got.Files[0].Contents
+	require.Contains(t, contents, `// WithGeneratedServiceValidateErrorConverter configures the error converter of GeneratedService.Validate, the converter is called with the errors emitted by the middlewares
+// that aren't of the method's error type, it's required to create a GeneratedService
+func WithGeneratedServiceValidateErrorConverter(fn func(error) *unresilient.ValidationError) Option {
+	return func(o *base) {
+		if o.errorConverters == nil {
+			o.errorConverters = map[string]interface{}{}
+		}
+		o.errorConverters["GeneratedService.Validate"] = fn
+	}
+}`)
+	require.Contains(t, contents, `	if err != nil {
+		if typedErr, ok := err.(*unresilient.ValidationError); ok {
+			return typedErr
+		}
+		return g.errorConverters["GeneratedService.Validate"].(func(error) *unresilient.ValidationError)(err)
+	}`)
+	// The types can't be created without the error converters of their methods with a custom error type
+	require.Contains(t, contents, `
+// NewGeneratedService creates a GeneratedService that reinforces the given delegate with the middlewares built by the runner factory
+// An error is returned when the error converter of a method with a custom error type isn't configured.
+func NewGeneratedService(delegate targetService, runnerFactory runnerFactory, options ...Option) (*GeneratedService, error) {`)
+	require.Contains(t, contents, `
+	for _, o := range options {
+		o(c.base)
+	}
+	if convert, ok := c.errorConverters["GeneratedService.Validate"].(func(error) *unresilient.ValidationError); !ok || convert == nil {
+		return nil, errors.New("no error converter configured for GeneratedService.Validate, see WithGeneratedServiceValidateErrorConverter")
+	}
+	return c, nil
+}`)
+	// Methods returning an error return the errors emitted by the middlewares as-is
+	require.NotContains(t, contents, "WithGeneratedServiceGetErrorConverter")
+
+	t.Run("No custom error types", func(t *testing.T) {
+		got, err := generator.Generate(generator.Config{
+			OutPkg: "resilient",
+			Files: loadInterface(t, map[string]input{
+				"service.go": {
+					interfaceName: "Service",
+					code: `package fake
+
+type Service interface {
+	Get(id string) (string, error)
+}
+`,
+				},
+			}),
+		})
+		require.NoError(t, err)
+		require.NotContains(t, got.Common, "errorConverters")
+	})
+}
+
+func TestGenerator_Generate_Hedging(t *testing.T) {
+	got, err := generator.Generate(generator.Config{
+		OutPkg: "resilient",
+		Files: loadInterface(t, map[string]input{
+			"service.go": {
+				interfaceName: "Service",
+				code: `package fake
+
+import "context"
+
+type Service interface {
+	Get(ctx context.Context, key string) (string, error)
+	Notify(msg string)
+}
+`,
+			},
+		}),
+		Options: generator.Options{
+			Hedging: true,
+		},
+	})
+	require.NoError(t, err)
+	contents := got.Files[0].Contents
+	require.Contains(t, contents, `	runner "github.com/clear-street/reinforcer/pkg/runner"`)
+	require.Contains(t, contents, `	err := g.run(ctx, GeneratedServiceMethods.Get, func(ctx context.Context) error {
+		var err error
+		var res0 string
+		res0, err = g.delegate.Get(ctx, key)
+		if !runner.ClaimAttempt(ctx) {
+			return runner.ErrHedgeLost
+		}
+		r0 = res0
+`)
+	// Methods without results don't have anything to keep apart
+	require.Contains(t, contents, `	err := g.run(context.Background(), GeneratedServiceMethods.Notify, func(_ context.Context) error {
+		g.delegate.Notify(msg)
+		return nil
+	})`)
+}
+
+func TestGenerator_Generate_Tracing(t *testing.T) {
+	got, err := generator.Generate(generator.Config{
+		OutPkg: "resilient",
+		Files: loadInterface(t, map[string]input{
+			"service.go": {
+				interfaceName: "Service",
+				code: `package fake
+
+import "context"
+
+type Service interface {
+	Get(ctx context.Context, key string) (string, error)
+}
+`,
+			},
+		}),
+		Options: generator.Options{
+			Tracing: true,
+		},
+	})
+	require.NoError(t, err)
+	require.Contains(t, got.Common, `	tracer                   *tracing.Tracer
+}`)
+	require.Contains(t, got.Common, `func WithTracer(tracer *tracing.Tracer) Option {
+	return func(o *base) {
+		o.tracer = tracer
+	}
+}`)
+	require.Contains(t, got.Common, `func (b *base) run(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	ctx, call := b.tracer.StartCall(ctx, b.typeName, name)
+	err := b.runnerFactory.GetRunner(b.runnerFor(name)).Run(ctx, fn)
+	call.End(err)
+	return err
+}`)
+	contents := got.Files[0].Contents
+	require.Contains(t, contents, `			tracer:                   tracing.NewTracer(nil),`)
+	require.Contains(t, contents, `	err := g.run(ctx, GeneratedServiceMethods.Get, func(ctx context.Context) error {
+		ctx, attempt := tracing.StartAttempt(ctx)
+		defer attempt.End()
+`)
+}
+
+func TestGenerator_Generate_Observer(t *testing.T) {
+	got, err := generator.Generate(generator.Config{
+		OutPkg: "resilient",
+		Files: loadInterface(t, map[string]input{
+			"service.go": {
+				interfaceName: "Service",
+				code: `package fake
+
+type Service interface {
+	Get(key string) (string, error)
+}
+`,
+			},
+		}),
+		Options: generator.Options{
+			Observer: true,
+		},
+	})
+	require.NoError(t, err)
+	require.Contains(t, got.Common, `	observer                 Observer
+}`)
+	require.Contains(t, got.Common, `func WithObserver(observer Observer) Option {
+	return func(o *base) {
+		o.observer = observer
+	}
+}`)
+	require.Contains(t, got.Common, `	return b.runObserved(ctx, name, b.runnerFactory.GetRunner(b.runnerFor(name)), fn)
+}`)
+	require.Contains(t, got.Files[0].Contents, `	err := g.run(context.Background(), GeneratedServiceMethods.Get, func(ctx context.Context) error {
+		var err error
+		r0, err = g.delegate.Get(key)
+		if g.errorPredicate(GeneratedServiceMethods.Get, err) {
+			return err
+		}
+		observeNonRetryable(ctx, err)
+		g.nonRetryableErrorHandler(ctx, GeneratedServiceMethods.Get, err)
+		nonRetryableErr = err
+		return nil
+	})`)
+}
+
+func TestGenerator_Generate_Logging(t *testing.T) {
+	got, err := generator.Generate(generator.Config{
+		OutPkg: "resilient",
+		Files: loadInterface(t, map[string]input{
+			"service.go": {
+				interfaceName: "Service",
+				code: `package fake
+
+import "context"
+
+type Service interface {
+	Get(ctx context.Context, key string) (string, error)
+}
+`,
+			},
+		}),
+		Options: generator.Options{
+			Logging:  true,
+			Observer: true,
+		},
+	})
+	require.NoError(t, err)
+	require.Contains(t, got.Common, `	logger                   *logging.Logger
+}`)
+	require.Contains(t, got.Common, `func WithLogger(logger *logging.Logger) Option {
+	return func(o *base) {
+		o.logger = logger
+	}
+}`)
+	require.Contains(t, got.Common, `	return b.runObserved(ctx, name, b.logger.Runner(b.typeName, name, b.runnerFactory.GetRunner(b.runnerFor(name))), fn)
+}`)
+	require.Contains(t, got.Files[0].Contents, `		observeNonRetryable(ctx, err)
+		logging.NonRetryable(ctx, err)
+		g.nonRetryableErrorHandler(ctx, GeneratedServiceMethods.Get, err)
+		nonRetryableErr = err
+`)
+}
+
+func TestGenerator_Generate_RecoverPanics(t *testing.T) {
+	files := loadInterface(t, map[string]input{
+		"service.go": {
+			interfaceName: "Service",
+			code: `package fake
+
+type Service interface {
+	Get(key string) (string, error)
+}
+`,
+		},
+	})
+
+	got, err := generator.Generate(generator.Config{
+		OutPkg: "resilient",
+		Files:  files,
+		Options: generator.Options{
+			RecoverPanics: true,
+		},
+	})
+	require.NoError(t, err)
+	require.Contains(t, got.Common, `// PanicError is the error of an attempt whose delegate panicked, the panic was recovered and the error is handled
+// like any other error returned by the delegate
+type PanicError struct {
+	// Value is the value the delegate panicked with
+	Value interface{}
+	// Stack is the stack trace of the goroutine that panicked
+	Stack []byte
+}
+
+// Error describes the value the delegate panicked with
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("delegate panicked: %v", e.Value)
+}
+
+// Unwrap returns the value the delegate panicked with when it's an error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// recoverPanic recovers the panic of the delegate into a *PanicError set to the given error, it must be deferred by
+// the function that calls the delegate
+func recoverPanic(_ context.Context, err *error) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	panicErr := &PanicError{
+		Stack: debug.Stack(),
+		Value: v,
+	}
+	*err = panicErr
+}`)
+	require.Contains(t, got.Files[0].Contents, `		func() {
+			defer recoverPanic(ctx, &err)
+			r0, err = g.delegate.Get(key)
+		}()
+`)
+
+	t.Run("Logged panics", func(t *testing.T) {
+		got, err := generator.Generate(generator.Config{
+			OutPkg: "resilient",
+			Files:  files,
+			Options: generator.Options{
+				RecoverPanics: true,
+				Logging:       true,
+			},
+		})
+		require.NoError(t, err)
+		require.Contains(t, got.Common, `func recoverPanic(ctx context.Context, err *error) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	panicErr := &PanicError{
+		Stack: debug.Stack(),
+		Value: v,
+	}
+	logging.Panic(ctx, panicErr, panicErr.Stack)
+	*err = panicErr
+}`)
+	})
+}
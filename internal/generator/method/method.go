@@ -2,19 +2,89 @@ package method
 
 import (
 	"fmt"
+	"go/token"
 	"go/types"
+	"regexp"
+	"strconv"
+	"strings"
 
 	rtypes "github.com/clear-street/reinforcer/internal/types"
 	"github.com/dave/jennifer/jen"
 )
 
 const (
-	ctxVarName = "ctx"
+	ctxVarName     = "ctx"
+	attemptVarName = "attempt"
+	tracingPkg     = "github.com/clear-street/reinforcer/pkg/tracing"
 )
 
+// reservedNames are the identifiers used by the generated code that parameters can't be named after
+var reservedNames = map[string]struct{}{
+	ctxVarName:            {},
+	"err":                 {},
+	"nonRetryableErr":     {},
+	"context":             {},
+	"error":               {},
+	"nil":                 {},
+	"panic":               {},
+	"abandon":             {},
+	"abandonErr":          {},
+	"fallback":            {},
+	"typedErr":            {},
+	"runner":              {},
+	"tracing":             {},
+	"logging":             {},
+	"observeNonRetryable": {},
+	"attempt":             {},
+	"panicErr":            {},
+	"nonRetryablePanic":   {},
+	"recoverPanic":        {},
+}
+
+// runnerNameSeparator separates the type name from the method name in the qualified runner names (see runner.SplitName)
+const runnerNameSeparator = "."
+
+// contextVariantSuffix is appended to the name of a method without a context to name its context variant
+const contextVariantSuffix = "Ctx"
+
+// errorVariantSuffix is appended to the name of the proxy of a method without results to name its error variant
+const errorVariantSuffix = "E"
+
+// ErrorResultRule determines which of the results of a method is the error that is handed to the middlewares, the other
+// results are treated as plain values even when they're errors
+type ErrorResultRule string
+
+const (
+	// LastErrorResult selects the last result when it's an error, which is the convention in Go
+	LastErrorResult ErrorResultRule = "last"
+	// FirstErrorResult selects the first result that is an error
+	FirstErrorResult ErrorResultRule = "first"
+)
+
+// ParseErrorResultRule parses the name of an ErrorResultRule, an empty name defaults to LastErrorResult
+func ParseErrorResultRule(name string) (ErrorResultRule, error) {
+	switch rule := ErrorResultRule(name); rule {
+	case "":
+		return LastErrorResult, nil
+	case LastErrorResult, FirstErrorResult:
+		return rule, nil
+	default:
+		return "", fmt.Errorf("unknown error result rule=%s, must be one of %s or %s", name, LastErrorResult, FirstErrorResult)
+	}
+}
+
+// resultVarName matches the names of the variables that hold the results in the generated code (e.g. r0)
+var resultVarName = regexp.MustCompile(`^r\d+$`)
+
+// attemptResultVarName matches the names of the variables that hold the results of an attempt when hedging (e.g. res0)
+var attemptResultVarName = regexp.MustCompile(`^res\d+$`)
+
 // Method holds all of the data for code generation on a specific method signature
 type Method struct {
-	Name                  string
+	Name string
+	// Signature is the method's signature as declared in the source, with the types qualified by their package's name
+	// (e.g. GetUser(ctx context.Context, id string) (*api.User, error))
+	Signature             string
 	HasContext            bool
 	ReturnsError          bool
 	HasVariadic           bool
@@ -23,6 +93,56 @@ type Method struct {
 	ReturnTypes           []jen.Code
 	ContextParameter      *int
 	ReturnErrorIndex      *int
+	// CustomErrorType is set when the type of the error result isn't error but a type implementing it (e.g. *MyErr)
+	CustomErrorType bool
+	// ErrorResult is the result (either its name or its index) annotated in the source as the error result of the
+	// method, it takes precedence over the ErrorResultRule
+	ErrorResult string
+	// Skip is set when the method is left out of the generated type
+	Skip bool
+	// PassThrough is set when the calls to the method go straight to the delegate without running through the middlewares
+	PassThrough bool
+	// NoRetry is set when the errors returned by the method must not be retried, its calls run through the middlewares
+	// without the retry middlewares (see runner.Retry)
+	NoRetry bool
+	// Idempotent is set when the method is safe to be retried
+	Idempotent bool
+	// ContextVariant is set when the proxy is the variant of a method without a context that receives the context handed
+	// to the middlewares (e.g. GetCtx(ctx context.Context, key string) for Get(key string))
+	ContextVariant bool
+	// ErrorVariant is set when the proxy is the companion of a method without results that returns the errors emitted by
+	// the middlewares instead of handing them to the no return error handler (e.g. NotifyE() error for Notify())
+	ErrorVariant bool
+	// Abandon is set when the calls to the delegate run in their own goroutine that is abandoned once the context handed
+	// by the middlewares is done, it only applies to methods without a context
+	Abandon bool
+	// Hedged is set when attempts of a call can run concurrently (e.g. with the hedge middleware), the results of every
+	// attempt are kept apart until the attempt claims the call
+	Hedged bool
+	// Traced is set when every attempt of a call produces a span (see the tracing package)
+	Traced bool
+	// Observed is set when the errors classified as non-retryable are handed to the observer of the attempt, the
+	// middlewares never see those errors
+	Observed bool
+	// Logged is set when the errors classified as non-retryable are logged (see the logging package)
+	Logged bool
+	// Recovered is set when the panics of the delegate are recovered into a *PanicError that is handled like any other
+	// error returned by the delegate
+	Recovered bool
+	// Runner is the name of the runner for the method, it takes precedence over the runner name of the generated type
+	Runner string
+	// Doc is the text of the method's doc comment in the source, without the comment markers
+	Doc string
+	// PromotedFrom is the embedded type that declares the method when the method is promoted, it's empty for methods
+	// declared directly on the type
+	PromotedFrom string
+	// Position is the position of the method's declaration in the source (e.g. github.com/org/pkg/client.go:12)
+	Position string
+
+	// parameterTypes holds the types of the parameters so they can be renamed
+	parameterTypes []jen.Code
+	// results holds the results of the signature so the error result can be selected
+	results *types.Tuple
 }
 
 // ConstantRef is the reference to the constant for this method's name
@@ -31,20 +151,132 @@ func (m *Method) ConstantRef(parentTypeName string) jen.Code {
 	return jen.Id(constantsStructName).Dot(m.Name)
 }
 
+// FallbackKey is the key of the method's fallback in the fallbacks of the generated type
+func (m *Method) FallbackKey(parentTypeName string) string {
+	return parentTypeName + "." + m.Name
+}
+
+// FallbackOptionName is the name of the option that configures the method's fallback (e.g. WithClientGetUserFallback)
+func (m *Method) FallbackOptionName(parentTypeName string) string {
+	return "With" + parentTypeName + m.Name + "Fallback"
+}
+
+// FallbackType is the type of the method's fallback, the fallback receives the call's context and the error returned by
+// the middlewares and returns the method's results
+func (m *Method) FallbackType() *jen.Statement {
+	return jen.Func().Params(jen.Qual("context", "Context"), jen.Error()).Params(m.ReturnTypes...)
+}
+
+// ErrorConverterKey is the key of the method's error converter in the error converters of the generated type
+func (m *Method) ErrorConverterKey(parentTypeName string) string {
+	return parentTypeName + "." + m.Name
+}
+
+// ErrorConverterOptionName is the name of the option that configures the method's error converter (e.g.
+// WithClientGetUserErrorConverter)
+func (m *Method) ErrorConverterOptionName(parentTypeName string) string {
+	return "With" + parentTypeName + m.Name + "ErrorConverter"
+}
+
+// ErrorConverterType is the type of the error converter of a method with a custom error type, the converter receives an
+// error emitted by the middlewares and returns it as the method's error type
+func (m *Method) ErrorConverterType() *jen.Statement {
+	return jen.Func().Params(jen.Error()).Add(m.ReturnTypes[*m.ReturnErrorIndex])
+}
+
 // ContextParam generates the param name and type for a context arg for the given method
 func (m *Method) ContextParam() (ctxParamName string, ctxParam jen.Code) {
 	ctxParamName = ctxVarName
-	if m.HasContext {
+	if m.HasContext || m.ContextVariant {
 		// Passes down the context if one is present in the signature
 		ctxParam = jen.Id(ctxVarName)
 	} else {
 		// Use context.Background() if no context is present in signature
 		ctxParam = jen.Qual("context", "Background").Call()
-		ctxParamName = "_"
+		if !m.Abandon && !m.Hedged && !m.Traced && !m.Observed && !m.Logged && !m.Recovered {
+			// The context handed by the middlewares is only needed to abandon the call, claim the attempt, trace it,
+			// observe it, log it or recover its panics
+			ctxParamName = "_"
+		}
 	}
 	return
 }
 
+// StartAttempt generates the statements that start the span of the attempt when the method is traced, the span ends
+// when the function handed to the middlewares returns
+func (m *Method) StartAttempt() []jen.Code {
+	if !m.Traced {
+		return nil
+	}
+	return []jen.Code{
+		// ctx, attempt := tracing.StartAttempt(ctx)
+		jen.List(jen.Id(ctxVarName), jen.Id(attemptVarName)).Op(":=").Qual(tracingPkg, "StartAttempt").Call(jen.Id(ctxVarName)),
+		// defer attempt.End()
+		jen.Defer().Id(attemptVarName).Dot("End").Call(),
+	}
+}
+
+// RecoverPanic generates the statement deferred by the function that calls the delegate to recover its panics into the
+// given error variable
+func (m *Method) RecoverPanic(errVarName string) jen.Code {
+	// defer recoverPanic(ctx, &err)
+	return jen.Defer().Id("recoverPanic").Call(jen.Id(ctxVarName), jen.Op("&").Id(errVarName))
+}
+
+// RecoveredCall wraps the given call to the delegate in a function that recovers its panics into the given error
+// variable when the method recovers panics, the call is returned as-is otherwise
+func (m *Method) RecoveredCall(call *jen.Statement, errVarName string) *jen.Statement {
+	if !m.Recovered {
+		return call
+	}
+	// func() {
+	//   defer recoverPanic(ctx, &err)
+	//   r0, err = r.delegate.Fn(args...)
+	// }()
+	return jen.Func().Params().Block(m.RecoverPanic(errVarName), call).Call()
+}
+
+// ProxyName is the name of the proxy of the method in the generated type
+func (m *Method) ProxyName() string {
+	name := m.Name
+	if m.ContextVariant {
+		name += contextVariantSuffix
+	}
+	if m.ErrorVariant {
+		name += errorVariantSuffix
+	}
+	return name
+}
+
+// ContextVariantName is the name of the context variant of the method (e.g. GetCtx)
+func (m *Method) ContextVariantName() string {
+	return m.Name + contextVariantSuffix
+}
+
+// ErrorVariantName is the name of the error variant of the method's proxy (e.g. NotifyE or NotifyCtxE)
+func (m *Method) ErrorVariantName() string {
+	variant := *m
+	variant.ErrorVariant = true
+	return variant.ProxyName()
+}
+
+// ProxyParameters are the parameters of the proxy of the method in the generated type, context variants receive the
+// context before the method's parameters
+func (m *Method) ProxyParameters() []jen.Code {
+	if !m.ContextVariant {
+		return m.ParametersNameAndType
+	}
+	return append([]jen.Code{jen.Id(ctxVarName).Qual("context", "Context")}, m.ParametersNameAndType...)
+}
+
+// ProxyArguments generates the arguments to call the method's proxy with the parameters of the proxy
+func (m *Method) ProxyArguments() []jen.Code {
+	if !m.ContextVariant {
+		return m.Parameters()
+	}
+	return append([]jen.Code{jen.Id(ctxVarName)}, m.Parameters()...)
+}
+
 // Parameters generates code for parameter names to be used in codegen
 func (m *Method) Parameters() []jen.Code {
 	var params []jen.Code
@@ -58,6 +290,134 @@ func (m *Method) Parameters() []jen.Code {
 	return params
 }
 
+// ReserveNames renames the parameters that are named after any of the given names (e.g. the name of the receiver in the
+// generated code) to generated names
+func (m *Method) ReserveNames(names ...string) {
+	reserved := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		reserved[name] = struct{}{}
+	}
+	used := make(map[string]struct{}, len(m.ParameterNames))
+	for _, name := range m.ParameterNames {
+		used[name] = struct{}{}
+	}
+	for i, name := range m.ParameterNames {
+		if _, ok := reserved[name]; !ok || (m.ContextParameter != nil && *m.ContextParameter == i) {
+			continue
+		}
+		m.ParameterNames[i] = generatedParamName(i, used)
+		m.ParametersNameAndType[i] = jen.Id(m.ParameterNames[i]).Add(m.parameterTypes[i])
+	}
+}
+
+// ApplyDirective applies the given directive (the text following //reinforcer: in a comment, e.g. "runner=payments") to
+// the method, the supported directives are skip, passthrough, noretry, idempotent, runner=<name> and error=<result>
+func (m *Method) ApplyDirective(directive string) error {
+	name, value, hasValue := strings.Cut(strings.TrimSpace(directive), "=")
+	if hasValue != (name == "runner" || name == "error") {
+		return fmt.Errorf("invalid directive=%s for method=%s", directive, m.Name)
+	}
+	switch name {
+	case "skip":
+		m.Skip = true
+	case "passthrough":
+		m.PassThrough = true
+	case "noretry":
+		m.NoRetry = true
+	case "idempotent":
+		m.Idempotent = true
+	case "runner":
+		if value == "" {
+			return fmt.Errorf("empty runner name for method=%s", m.Name)
+		}
+		// The runner names are split on their last dot into the type and method labels of the metrics and the spans
+		if strings.Contains(value, runnerNameSeparator) {
+			return fmt.Errorf("invalid runner name=%s for method=%s, runner names can't contain %q", value, m.Name, runnerNameSeparator)
+		}
+		m.Runner = value
+	case "error":
+		if value == "" {
+			return fmt.Errorf("empty error result for method=%s", m.Name)
+		}
+		m.ErrorResult = value
+	default:
+		return fmt.Errorf("unknown directive=%s for method=%s", directive, m.Name)
+	}
+	return nil
+}
+
+// SelectErrorResult selects the result that is handed to the middlewares as the method's error, the annotated result is
+// used if there's one otherwise the result is selected with the given rule (an empty rule defaults to LastErrorResult)
+func (m *Method) SelectErrorResult(rule ErrorResultRule) error {
+	m.ReturnsError = false
+	m.ReturnErrorIndex = nil
+	m.CustomErrorType = false
+
+	index := -1
+	switch {
+	case m.ErrorResult != "":
+		index = m.resultIndex(m.ErrorResult)
+		if index < 0 {
+			return fmt.Errorf("result=%s of method=%s not found", m.ErrorResult, m.Name)
+		}
+		if !isErrorResult(m.results.At(index).Type()) {
+			return fmt.Errorf("result=%s of method=%s is not an error", m.ErrorResult, m.Name)
+		}
+	case rule == LastErrorResult || rule == "":
+		if last := m.results.Len() - 1; last >= 0 && isErrorResult(m.results.At(last).Type()) {
+			index = last
+		}
+	case rule == FirstErrorResult:
+		for i := 0; i < m.results.Len(); i++ {
+			if isErrorResult(m.results.At(i).Type()) {
+				index = i
+				break
+			}
+		}
+	default:
+		return fmt.Errorf("unknown error result rule=%s", rule)
+	}
+	if index < 0 {
+		return nil
+	}
+	m.ReturnsError = true
+	m.ReturnErrorIndex = new(int)
+	*m.ReturnErrorIndex = index
+	// Custom error types are compared by name as the predeclared error type isn't always the one in the universe scope
+	m.CustomErrorType = m.results.At(index).Type().String() != "error"
+	return nil
+}
+
+// isErrorResult determines if a result of the given type can be the method's error, the type must implement error and
+// be nillable so that a nil value can signal the success of the call
+func isErrorResult(t types.Type) bool {
+	if !rtypes.IsErrorType(t) {
+		return false
+	}
+	switch t.Underlying().(type) {
+	case *types.Pointer, *types.Interface, *types.Map, *types.Slice, *types.Chan, *types.Signature:
+		return true
+	default:
+		return false
+	}
+}
+
+// resultIndex finds the index of the result with the given name or index, -1 is returned if there's no such result
+func (m *Method) resultIndex(result string) int {
+	if i, err := strconv.Atoi(result); err == nil {
+		if i < 0 || i >= m.results.Len() {
+			return -1
+		}
+		return i
+	}
+	for i := 0; i < m.results.Len(); i++ {
+		if m.results.At(i).Name() == result {
+			return i
+		}
+	}
+	return -1
+}
+
 // MustParseMethod parses the given types.Signature and generates a Method, if there's an error this method will panic
 func MustParseMethod(name string, signature *types.Signature) *Method {
 	m, err := ParseMethod(name, signature)
@@ -67,17 +427,21 @@ func MustParseMethod(name string, signature *types.Signature) *Method {
 	return m
 }
 
-// ParseMethod parses the given types.Signature and generates a Method
+// ParseMethod parses the given types.Signature and generates a Method, the last result is the method's error if it's an
+// error (see SelectErrorResult to select a different result)
 func ParseMethod(name string, signature *types.Signature) (*Method, error) {
 	m := &Method{
 		Name:             name,
 		ReturnErrorIndex: nil,
 		ContextParameter: nil,
 		HasVariadic:      signature.Variadic(),
+		Signature:        signatureString(name, signature),
+		results:          signature.Results(),
 	}
 
 	isVariadic := signature.Variadic()
 	numParams := signature.Params().Len()
+	paramNames := parameterNames(signature)
 	for i, lastIndex := 0, numParams-1; i < numParams; i++ {
 		param := signature.Params().At(i)
 		if rtypes.IsContextType(param.Type()) {
@@ -86,8 +450,9 @@ func ParseMethod(name string, signature *types.Signature) (*Method, error) {
 			*m.ContextParameter = i
 			m.ParametersNameAndType = append(m.ParametersNameAndType, jen.Id(ctxVarName).Add(jen.Qual("context", "Context")))
 			m.ParameterNames = append(m.ParameterNames, ctxVarName)
+			m.parameterTypes = append(m.parameterTypes, jen.Qual("context", "Context"))
 		} else {
-			paramName := fmt.Sprintf("arg%d", i)
+			paramName := paramNames[i]
 
 			paramType, err := rtypes.ToType(param.Type(), isVariadic && i == lastIndex)
 			if err != nil {
@@ -95,6 +460,7 @@ func ParseMethod(name string, signature *types.Signature) (*Method, error) {
 			}
 			m.ParametersNameAndType = append(m.ParametersNameAndType, jen.Id(paramName).Add(paramType))
 			m.ParameterNames = append(m.ParameterNames, paramName)
+			m.parameterTypes = append(m.parameterTypes, paramType)
 		}
 	}
 	for i := 0; i < signature.Results().Len(); i++ {
@@ -103,15 +469,76 @@ func ParseMethod(name string, signature *types.Signature) (*Method, error) {
 		if err != nil {
 			panic(err)
 		}
-		if rtypes.IsErrorType(res.Type()) {
-			if m.ReturnErrorIndex != nil {
-				return nil, fmt.Errorf("multiple errors returned by method signature")
-			}
-			m.ReturnsError = true
-			m.ReturnErrorIndex = new(int)
-			*m.ReturnErrorIndex = i
-		}
 		m.ReturnTypes = append(m.ReturnTypes, resType)
 	}
+	if err := m.SelectErrorResult(LastErrorResult); err != nil {
+		return nil, err
+	}
 	return m, nil
 }
+
+// signatureString describes the signature of the method with the given name, types are qualified by their package's
+// name
+func signatureString(name string, signature *types.Signature) string {
+	sig := types.TypeString(signature, func(pkg *types.Package) string {
+		return pkg.Name()
+	})
+	return name + strings.TrimPrefix(sig, "func")
+}
+
+// parameterNames determines the names of the signature's parameters, the source names are kept unless they're missing,
+// blank or would collide with the identifiers used in the generated code in which case a name is generated (e.g. arg1)
+func parameterNames(signature *types.Signature) []string {
+	reserved := make(map[string]struct{}, len(reservedNames))
+	for name := range reservedNames {
+		reserved[name] = struct{}{}
+	}
+	// The names of the packages referenced in the signature can't be shadowed either since the generated code refers to
+	// them
+	qualifier := func(pkg *types.Package) string {
+		reserved[pkg.Name()] = struct{}{}
+		return pkg.Name()
+	}
+	types.TypeString(signature, qualifier)
+
+	params := signature.Params()
+	names := make([]string, params.Len())
+	used := make(map[string]struct{}, params.Len())
+	for i := 0; i < params.Len(); i++ {
+		if rtypes.IsContextType(params.At(i).Type()) {
+			names[i] = ctxVarName
+			continue
+		}
+		name := params.At(i).Name()
+		if _, ok := reserved[name]; ok || !isValidParamName(name) {
+			continue
+		}
+		names[i] = name
+		used[name] = struct{}{}
+	}
+	for i, name := range names {
+		if name == "" {
+			names[i] = generatedParamName(i, used)
+		}
+	}
+	return names
+}
+
+func isValidParamName(name string) bool {
+	return name != "_" && token.IsIdentifier(name) && !resultVarName.MatchString(name) &&
+		!attemptResultVarName.MatchString(name)
+}
+
+// generatedParamName generates a name for the parameter at the given index that isn't in use, the name is marked as
+// used
+func generatedParamName(i int, used map[string]struct{}) string {
+	name := fmt.Sprintf("arg%d", i)
+	for {
+		if _, ok := used[name]; !ok {
+			break
+		}
+		name += "_"
+	}
+	used[name] = struct{}{}
+	return name
+}
@@ -41,6 +41,7 @@ func TestNewMethod(t *testing.T) {
 			},
 			want: &method.Method{
 				Name:                  "Fn",
+				Signature:             "Fn()",
 				HasContext:            false,
 				ParameterNames:        nil,
 				ParametersNameAndType: nil,
@@ -55,8 +56,8 @@ func TestNewMethod(t *testing.T) {
 			want: &method.Method{
 				Name:                  "Fn",
 				HasContext:            false,
-				ParameterNames:        []string{"arg0"},
-				ParametersNameAndType: []jen.Code{jen.Id("arg0").Add(jen.Id("string"))},
+				ParameterNames:        []string{"myArg"},
+				ParametersNameAndType: []jen.Code{jen.Id("myArg").Add(jen.Id("string"))},
 			},
 		},
 		{
@@ -67,9 +68,10 @@ func TestNewMethod(t *testing.T) {
 			},
 			want: &method.Method{
 				Name:                  "Fn",
+				Signature:             "Fn(args ...string)",
 				HasContext:            false,
-				ParameterNames:        []string{"arg0"},
-				ParametersNameAndType: []jen.Code{jen.Id("arg0").Add(jen.Op("...").Add(jen.Id("string")))},
+				ParameterNames:        []string{"args"},
+				ParametersNameAndType: []jen.Code{jen.Id("args").Add(jen.Op("...").Add(jen.Id("string")))},
 			},
 		},
 		{
@@ -81,10 +83,11 @@ func TestNewMethod(t *testing.T) {
 			},
 			want: &method.Method{
 				Name:                  "Fn",
+				Signature:             "Fn(arg0 string, args ...string)",
 				HasContext:            false,
 				HasVariadic:           true,
-				ParameterNames:        []string{"arg0", "arg1"},
-				ParametersNameAndType: []jen.Code{jen.Id("arg0").Add(jen.Id("string")), jen.Id("arg1").Add(jen.Op("...").Add(jen.Id("string")))},
+				ParameterNames:        []string{"arg0", "args"},
+				ParametersNameAndType: []jen.Code{jen.Id("arg0").Add(jen.Id("string")), jen.Id("args").Add(jen.Op("...").Add(jen.Id("string")))},
 			},
 		},
 		{
@@ -101,8 +104,8 @@ func TestNewMethod(t *testing.T) {
 				HasContext:            true,
 				ContextParameter:      zero,
 				ReturnsError:          false,
-				ParameterNames:        []string{"ctx", "arg1"},
-				ParametersNameAndType: []jen.Code{jen.Id("ctx").Add(jen.Qual("context", "Context")), jen.Id("arg1").Add(jen.Id("string"))},
+				ParameterNames:        []string{"ctx", "myArg"},
+				ParametersNameAndType: []jen.Code{jen.Id("ctx").Add(jen.Qual("context", "Context")), jen.Id("myArg").Add(jen.Id("string"))},
 				ReturnTypes:           nil,
 			},
 		},
@@ -119,8 +122,8 @@ func TestNewMethod(t *testing.T) {
 				Name:                  "Fn",
 				HasContext:            true,
 				ReturnsError:          true,
-				ParameterNames:        []string{"ctx", "arg1"},
-				ParametersNameAndType: []jen.Code{jen.Id("ctx").Add(jen.Qual("context", "Context")), jen.Id("arg1").Add(jen.Id("string"))},
+				ParameterNames:        []string{"ctx", "myArg"},
+				ParametersNameAndType: []jen.Code{jen.Id("ctx").Add(jen.Qual("context", "Context")), jen.Id("myArg").Add(jen.Id("string"))},
 				ReturnTypes:           []jen.Code{jen.Id("error")},
 			},
 		},
@@ -139,9 +142,9 @@ func TestNewMethod(t *testing.T) {
 				Name:           "Fn",
 				HasContext:     false,
 				ReturnsError:   false,
-				ParameterNames: []string{"arg0"},
+				ParameterNames: []string{"myArg"},
 				ParametersNameAndType: []jen.Code{
-					jen.Id("arg0").Add(jen.Func().Params().Parens(jen.List(jen.Id("string"), jen.Id("error")))),
+					jen.Id("myArg").Add(jen.Func().Params().Parens(jen.List(jen.Id("string"), jen.Id("error")))),
 				},
 				ReturnTypes: []jen.Code{},
 			},
@@ -162,8 +165,8 @@ func TestNewMethod(t *testing.T) {
 				Name:                  "Fn",
 				HasContext:            true,
 				ReturnsError:          true,
-				ParameterNames:        []string{"ctx", "arg1"},
-				ParametersNameAndType: []jen.Code{jen.Id("ctx").Add(jen.Qual("context", "Context")), jen.Id("arg1").Add(jen.Id("string"))},
+				ParameterNames:        []string{"ctx", "myArg"},
+				ParametersNameAndType: []jen.Code{jen.Id("ctx").Add(jen.Qual("context", "Context")), jen.Id("myArg").Add(jen.Id("string"))},
 				ReturnTypes:           []jen.Code{jen.Id("string"), jen.Id("error")},
 			},
 		},
@@ -179,8 +182,8 @@ func TestNewMethod(t *testing.T) {
 			want: &method.Method{
 				Name:                  "Fn",
 				HasContext:            false,
-				ParameterNames:        []string{"arg0"},
-				ParametersNameAndType: []jen.Code{jen.Id("arg0").Add(jen.Id("any"))},
+				ParameterNames:        []string{"arg"},
+				ParametersNameAndType: []jen.Code{jen.Id("arg").Add(jen.Id("any"))},
 				ReturnTypes:           []jen.Code{jen.Id("any")},
 			},
 		},
@@ -196,8 +199,8 @@ func TestNewMethod(t *testing.T) {
 			want: &method.Method{
 				Name:                  "Fn",
 				HasContext:            false,
-				ParameterNames:        []string{"arg0"},
-				ParametersNameAndType: []jen.Code{jen.Id("arg0").Add(jen.Map(jen.Id("string")).Add(jen.Id("any")))},
+				ParameterNames:        []string{"arg"},
+				ParametersNameAndType: []jen.Code{jen.Id("arg").Add(jen.Map(jen.Id("string")).Add(jen.Id("any")))},
 				ReturnTypes:           []jen.Code{jen.Map(jen.Id("string")).Add(jen.Id("int"))},
 			},
 		},
@@ -222,9 +225,9 @@ func TestNewMethod(t *testing.T) {
 			want: &method.Method{
 				Name:           "Fn",
 				HasContext:     false,
-				ParameterNames: []string{"arg0"},
+				ParameterNames: []string{"argFn"},
 				ParametersNameAndType: []jen.Code{
-					jen.Id("arg0").Add(jen.Func().Params(jen.String()).Add(jen.Bool()))},
+					jen.Id("argFn").Add(jen.Func().Params(jen.String()).Add(jen.Bool()))},
 				ReturnTypes: []jen.Code{jen.Map(jen.Id("string")).Add(jen.Id("int"))},
 			},
 		},
@@ -240,11 +243,85 @@ func TestNewMethod(t *testing.T) {
 			want: &method.Method{
 				Name:                  "Fn",
 				HasContext:            false,
-				ParameterNames:        []string{"arg0"},
-				ParametersNameAndType: []jen.Code{jen.Id("arg0").Add(jen.Qual("github.com/clear-street/fake", "genericType").Types(jen.String()))},
+				ParameterNames:        []string{"arg"},
+				ParametersNameAndType: []jen.Code{jen.Id("arg").Add(jen.Qual("github.com/clear-street/fake", "genericType").Types(jen.String()))},
 				ReturnTypes:           []jen.Code{},
 			},
 		},
+		{
+			name: "Fn(string, _ int, arg1 bool)",
+			args: args{
+				name: "Fn",
+				signature: types.NewSignatureType(nil, nil, nil,
+					types.NewTuple(
+						types.NewVar(token.NoPos, nil, "", types.Typ[types.String]),
+						types.NewVar(token.NoPos, nil, "_", types.Typ[types.Int]),
+						types.NewVar(token.NoPos, nil, "arg1", types.Typ[types.Bool]),
+					),
+					types.NewTuple(),
+					false),
+			},
+			want: &method.Method{
+				Name:           "Fn",
+				ParameterNames: []string{"arg0", "arg1_", "arg1"},
+				ParametersNameAndType: []jen.Code{
+					jen.Id("arg0").Add(jen.Id("string")),
+					jen.Id("arg1_").Add(jen.Id("int")),
+					jen.Id("arg1").Add(jen.Id("bool")),
+				},
+				ReturnTypes: []jen.Code{},
+			},
+		},
+		{
+			name: "Fn(err error, r0 string, nonRetryableErr int, ctx bool, fake genericType[string])",
+			args: args{
+				name: "Fn",
+				signature: types.NewSignatureType(nil, nil, nil,
+					types.NewTuple(
+						types.NewVar(token.NoPos, nil, "err", rtypes.ErrType),
+						types.NewVar(token.NoPos, nil, "r0", types.Typ[types.String]),
+						types.NewVar(token.NoPos, nil, "nonRetryableErr", types.Typ[types.Int]),
+						types.NewVar(token.NoPos, nil, "ctx", types.Typ[types.Bool]),
+						types.NewVar(token.NoPos, nil, "fake", typedType),
+					),
+					types.NewTuple(),
+					false),
+			},
+			want: &method.Method{
+				Name:           "Fn",
+				ParameterNames: []string{"arg0", "arg1", "arg2", "arg3", "arg4"},
+				ParametersNameAndType: []jen.Code{
+					jen.Id("arg0").Add(jen.Id("error")),
+					jen.Id("arg1").Add(jen.Id("string")),
+					jen.Id("arg2").Add(jen.Id("int")),
+					jen.Id("arg3").Add(jen.Id("bool")),
+					jen.Id("arg4").Add(jen.Qual("github.com/clear-street/fake", "genericType").Types(jen.String())),
+				},
+				ReturnTypes: []jen.Code{},
+			},
+		},
+		{
+			name: "Fn(res0 string, res int)",
+			args: args{
+				name: "Fn",
+				signature: types.NewSignatureType(nil, nil, nil,
+					types.NewTuple(
+						types.NewVar(token.NoPos, nil, "res0", types.Typ[types.String]),
+						types.NewVar(token.NoPos, nil, "res", types.Typ[types.Int]),
+					),
+					types.NewTuple(),
+					false),
+			},
+			want: &method.Method{
+				Name:           "Fn",
+				ParameterNames: []string{"arg0", "res"},
+				ParametersNameAndType: []jen.Code{
+					jen.Id("arg0").Add(jen.Id("string")),
+					jen.Id("res").Add(jen.Id("int")),
+				},
+				ReturnTypes: []jen.Code{},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -254,6 +331,9 @@ func TestNewMethod(t *testing.T) {
 			require.Equal(t, tt.want.Name, got.Name)
 			require.Equal(t, tt.want.HasContext, got.HasContext)
 			require.Equal(t, tt.want.ReturnsError, got.ReturnsError)
+			if tt.want.Signature != "" {
+				require.Equal(t, tt.want.Signature, got.Signature)
+			}
 			if tt.want.ContextParameter != nil {
 				require.Equal(t, *tt.want.ContextParameter, *got.ContextParameter)
 			}
@@ -268,3 +348,162 @@ func TestNewMethod(t *testing.T) {
 		})
 	}
 }
+
+func TestMethod_ReserveNames(t *testing.T) {
+	m := method.MustParseMethod("Fn", types.NewSignatureType(nil, nil, nil,
+		types.NewTuple(
+			types.NewVar(token.NoPos, nil, "ctx", rtypes.ContextType),
+			types.NewVar(token.NoPos, nil, "c", types.Typ[types.String]),
+			types.NewVar(token.NoPos, nil, "arg1", types.Typ[types.Int]),
+		),
+		types.NewTuple(),
+		false))
+	m.ReserveNames("c", "ctx")
+	require.Equal(t, []string{"ctx", "arg1_", "arg1"}, m.ParameterNames)
+	require.Equal(t, []jen.Code{
+		jen.Id("ctx").Add(jen.Qual("context", "Context")),
+		jen.Id("arg1_").Add(jen.Id("string")),
+		jen.Id("arg1").Add(jen.Id("int")),
+	}, m.ParametersNameAndType)
+}
+
+func TestMethod_SelectErrorResult(t *testing.T) {
+	fakePkg := types.NewPackage("github.com/clear-street/fake", "fake")
+	customErr := types.NewNamed(types.NewTypeName(token.NoPos, fakePkg, "MyError", nil), rtypes.ErrType.Underlying(), nil)
+	// A struct implementing error can't be nil so it can't signal the success of the call
+	valueErr := types.NewNamed(types.NewTypeName(token.NoPos, fakePkg, "ValueError", nil), types.NewStruct(nil, nil), nil)
+	valueErr.AddMethod(types.NewFunc(token.NoPos, fakePkg, "Error", types.NewSignatureType(
+		types.NewVar(token.NoPos, fakePkg, "e", valueErr), nil, nil,
+		types.NewTuple(),
+		types.NewTuple(types.NewVar(token.NoPos, nil, "", types.Typ[types.String])),
+		false,
+	)))
+	signature := types.NewSignatureType(nil, nil, nil,
+		types.NewTuple(),
+		types.NewTuple(
+			types.NewVar(token.NoPos, nil, "first", rtypes.ErrType),
+			types.NewVar(token.NoPos, nil, "count", types.Typ[types.Int]),
+			types.NewVar(token.NoPos, nil, "custom", customErr),
+			types.NewVar(token.NoPos, nil, "value", valueErr),
+		),
+		false)
+
+	tests := []struct {
+		name        string
+		rule        method.ErrorResultRule
+		errorResult string
+		wantIndex   *int
+		wantCustom  bool
+		wantErr     bool
+	}{
+		{
+			name: "Last result isn't a nillable error",
+			rule: method.LastErrorResult,
+		},
+		{
+			name:      "First error result",
+			rule:      method.FirstErrorResult,
+			wantIndex: intPtr(0),
+		},
+		{
+			name:        "Annotated result name",
+			rule:        method.FirstErrorResult,
+			errorResult: "custom",
+			wantIndex:   intPtr(2),
+			wantCustom:  true,
+		},
+		{
+			name:        "Annotated result index",
+			rule:        method.LastErrorResult,
+			errorResult: "0",
+			wantIndex:   intPtr(0),
+		},
+		{
+			name:        "Annotated result isn't an error",
+			rule:        method.LastErrorResult,
+			errorResult: "count",
+			wantErr:     true,
+		},
+		{
+			name:        "Annotated result can't be nil",
+			rule:        method.LastErrorResult,
+			errorResult: "value",
+			wantErr:     true,
+		},
+		{
+			name:        "Annotated result not found",
+			rule:        method.LastErrorResult,
+			errorResult: "4",
+			wantErr:     true,
+		},
+		{
+			name:    "Unknown rule",
+			rule:    "middle",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := method.MustParseMethod("Fn", signature)
+			m.ErrorResult = tt.errorResult
+			err := m.SelectErrorResult(tt.rule)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantIndex != nil, m.ReturnsError)
+			require.Equal(t, tt.wantIndex, m.ReturnErrorIndex)
+			require.Equal(t, tt.wantCustom, m.CustomErrorType)
+		})
+	}
+}
+
+func TestMethod_ApplyDirective(t *testing.T) {
+	tests := []struct {
+		directive string
+		want      func(m *method.Method) bool
+		wantErr   bool
+	}{
+		{directive: "skip", want: func(m *method.Method) bool { return m.Skip }},
+		{directive: "passthrough", want: func(m *method.Method) bool { return m.PassThrough }},
+		{directive: "noretry", want: func(m *method.Method) bool { return m.NoRetry }},
+		{directive: "idempotent ", want: func(m *method.Method) bool { return m.Idempotent }},
+		{directive: "runner=payments-write", want: func(m *method.Method) bool { return m.Runner == "payments-write" }},
+		{directive: "error=err", want: func(m *method.Method) bool { return m.ErrorResult == "err" }},
+		{directive: "runner=", wantErr: true},
+		{directive: "runner=payments.write", wantErr: true},
+		{directive: "runner", wantErr: true},
+		{directive: "skip=true", wantErr: true},
+		{directive: "retry", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.directive, func(t *testing.T) {
+			m := method.MustParseMethod("Fn", types.NewSignatureType(nil, nil, nil, types.NewTuple(), types.NewTuple(), false))
+			err := m.ApplyDirective(tt.directive)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, tt.want(m))
+		})
+	}
+}
+
+func TestParseErrorResultRule(t *testing.T) {
+	rule, err := method.ParseErrorResultRule("")
+	require.NoError(t, err)
+	require.Equal(t, method.LastErrorResult, rule)
+
+	rule, err = method.ParseErrorResultRule("first")
+	require.NoError(t, err)
+	require.Equal(t, method.FirstErrorResult, rule)
+
+	_, err = method.ParseErrorResultRule("middle")
+	require.Error(t, err)
+}
+
+func intPtr(i int) *int {
+	return &i
+}
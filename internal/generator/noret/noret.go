@@ -25,22 +25,64 @@ func NewNoReturn(method *method.Method, structName string, structTypeArgs []jen.
 
 // Statement generates the jen.Statement for this method
 func (p *NoReturn) Statement() (*jen.Statement, error) {
-	methodArgParams := p.method.ParametersNameAndType
+	methodArgParams := p.method.ProxyParameters()
 	params := p.method.Parameters()
 	ctxParamName, ctxParam := p.method.ContextParam()
 
 	// anonymous function passed to the middleware
-	call := jen.Func().Call(jen.Id(ctxParamName).Qual("context", "Context")).Params(jen.Id("error")).Block(
-		// r.delegate.Fn(args...)
-		jen.Id(p.receiverName).Dot("delegate").Dot(p.method.Name).Call(params...),
-		// return nil
-		jen.Return(jen.Nil()),
-	)
+	delegateCall := jen.Id(p.receiverName).Dot("delegate").Dot(p.method.Name).Call(params...)
+	callStatements := p.method.StartAttempt()
+	switch {
+	case p.method.Recovered && p.method.Abandon:
+		// var err error
+		// if abandonErr := abandon(ctx, func() {...}); abandonErr != nil {
+		//   return abandonErr
+		// }
+		// return err
+		callStatements = append(callStatements,
+			jen.Var().Id("err").Error(),
+			jen.If(
+				jen.Id("abandonErr").Op(":=").Id("abandon").Call(jen.Id("ctx"), jen.Func().Params().Block(p.method.RecoverPanic("err"), delegateCall)),
+				jen.Id("abandonErr").Op("!=").Nil(),
+			).Block(
+				jen.Return(jen.Id("abandonErr")),
+			),
+			jen.Return(jen.Id("err")),
+		)
+	case p.method.Recovered:
+		// var err error
+		// func() {...}()
+		// return err
+		callStatements = append(callStatements,
+			jen.Var().Id("err").Error(),
+			p.method.RecoveredCall(delegateCall, "err"),
+			jen.Return(jen.Id("err")),
+		)
+	case p.method.Abandon:
+		// return abandon(ctx, func() {...})
+		callStatements = append(callStatements, jen.Return(jen.Id("abandon").Call(jen.Id("ctx"), jen.Func().Params().Block(delegateCall))))
+	default:
+		callStatements = append(callStatements,
+			// r.delegate.Fn(args...)
+			delegateCall,
+			// return nil
+			jen.Return(jen.Nil()),
+		)
+	}
+	call := jen.Func().Call(jen.Id(ctxParamName).Qual("context", "Context")).Params(jen.Id("error")).Block(callStatements...)
 
-	return jen.Func().Params(jen.Id(p.receiverName).Op("*").Id(p.structName).Types(p.structTypeArgs...)).Id(p.method.Name).Call(methodArgParams...).Block(
-		jen.Id("err").Op(":=").Id(p.receiverName).Dot("run").Call(ctxParam, p.method.ConstantRef(p.structName), call),
+	runCall := jen.Id(p.receiverName).Dot("run").Call(ctxParam, p.method.ConstantRef(p.structName), call)
+	proxy := jen.Func().Params(jen.Id(p.receiverName).Op("*").Id(p.structName).Types(p.structTypeArgs...)).Id(p.method.ProxyName()).Call(methodArgParams...)
+	if p.method.ErrorVariant {
+		// The errors emitted by the middlewares are returned to the caller
+		return proxy.Error().Block(
+			jen.Return(runCall),
+		), nil
+	}
+	return proxy.Block(
+		jen.Id("err").Op(":=").Add(runCall),
 		jen.If(jen.Id("err").Op("!=").Nil()).Block(
-			jen.Panic(jen.Id("err")),
+			jen.Id(p.receiverName).Dot("noReturnErrorHandler").Call(p.method.ConstantRef(p.structName), jen.Id("err")),
 		),
 	), nil
 }
@@ -21,6 +21,11 @@ func TestNoReturn_Statement(t *testing.T) {
 		methodName     string
 		structTypeArgs []jen.Code
 		signature      *types.Signature
+		contextVariant bool
+		errorVariant   bool
+		abandon        bool
+		traced         bool
+		recovered      bool
 		want           string
 		wantErr        bool
 	}{
@@ -34,7 +39,7 @@ func TestNoReturn_Statement(t *testing.T) {
 		return nil
 	})
 	if err != nil {
-		panic(err)
+		r.noReturnErrorHandler(ResilientMethods.MyFunction, err)
 	}
 }`,
 			wantErr: false,
@@ -46,13 +51,105 @@ func TestNoReturn_Statement(t *testing.T) {
 				ctxVar,
 				types.NewVar(token.NoPos, nil, "myArg", types.Typ[types.String]),
 			), types.NewTuple(types.NewVar(token.NoPos, nil, "", types.Typ[types.String])), false),
-			want: `func (r *Resilient) MyFunction(ctx context.Context, arg1 string) {
+			want: `func (r *Resilient) MyFunction(ctx context.Context, myArg string) {
 	err := r.run(ctx, ResilientMethods.MyFunction, func(ctx context.Context) error {
-		r.delegate.MyFunction(ctx, arg1)
+		r.delegate.MyFunction(ctx, myArg)
 		return nil
 	})
 	if err != nil {
-		panic(err)
+		r.noReturnErrorHandler(ResilientMethods.MyFunction, err)
+	}
+}`,
+			wantErr: false,
+		},
+		{
+			name:           "MyFunctionCtx(ctx context.Context, arg1 string)",
+			methodName:     "MyFunction",
+			signature:      types.NewSignatureType(nil, nil, nil, types.NewTuple(types.NewVar(token.NoPos, nil, "myArg", types.Typ[types.String])), types.NewTuple(), false),
+			contextVariant: true,
+			abandon:        true,
+			want: `func (r *Resilient) MyFunctionCtx(ctx context.Context, myArg string) {
+	err := r.run(ctx, ResilientMethods.MyFunction, func(ctx context.Context) error {
+		return abandon(ctx, func() {
+			r.delegate.MyFunction(myArg)
+		})
+	})
+	if err != nil {
+		r.noReturnErrorHandler(ResilientMethods.MyFunction, err)
+	}
+}`,
+			wantErr: false,
+		},
+		{
+			name:         "MyFunctionE(arg1 string) error",
+			methodName:   "MyFunction",
+			signature:    types.NewSignatureType(nil, nil, nil, types.NewTuple(types.NewVar(token.NoPos, nil, "myArg", types.Typ[types.String])), types.NewTuple(), false),
+			errorVariant: true,
+			want: `func (r *Resilient) MyFunctionE(myArg string) error {
+	return r.run(context.Background(), ResilientMethods.MyFunction, func(_ context.Context) error {
+		r.delegate.MyFunction(myArg)
+		return nil
+	})
+}`,
+			wantErr: false,
+		},
+		{
+			name:       "MyFunction() is traced",
+			methodName: "MyFunction",
+			signature:  types.NewSignatureType(nil, nil, nil, types.NewTuple(), types.NewTuple(), false),
+			traced:     true,
+			want: `func (r *Resilient) MyFunction() {
+	err := r.run(context.Background(), ResilientMethods.MyFunction, func(ctx context.Context) error {
+		ctx, attempt := tracing.StartAttempt(ctx)
+		defer attempt.End()
+		r.delegate.MyFunction()
+		return nil
+	})
+	if err != nil {
+		r.noReturnErrorHandler(ResilientMethods.MyFunction, err)
+	}
+}`,
+			wantErr: false,
+		},
+		{
+			name:       "MyFunction() recovers panics",
+			methodName: "MyFunction",
+			signature:  types.NewSignatureType(nil, nil, nil, types.NewTuple(), types.NewTuple(), false),
+			recovered:  true,
+			want: `func (r *Resilient) MyFunction() {
+	err := r.run(context.Background(), ResilientMethods.MyFunction, func(ctx context.Context) error {
+		var err error
+		func() {
+			defer recoverPanic(ctx, &err)
+			r.delegate.MyFunction()
+		}()
+		return err
+	})
+	if err != nil {
+		r.noReturnErrorHandler(ResilientMethods.MyFunction, err)
+	}
+}`,
+			wantErr: false,
+		},
+		{
+			name:       "MyFunction() recovers the panics of the abandoned call",
+			methodName: "MyFunction",
+			signature:  types.NewSignatureType(nil, nil, nil, types.NewTuple(), types.NewTuple(), false),
+			abandon:    true,
+			recovered:  true,
+			want: `func (r *Resilient) MyFunction() {
+	err := r.run(context.Background(), ResilientMethods.MyFunction, func(ctx context.Context) error {
+		var err error
+		if abandonErr := abandon(ctx, func() {
+			defer recoverPanic(ctx, &err)
+			r.delegate.MyFunction()
+		}); abandonErr != nil {
+			return abandonErr
+		}
+		return err
+	})
+	if err != nil {
+		r.noReturnErrorHandler(ResilientMethods.MyFunction, err)
 	}
 }`,
 			wantErr: false,
@@ -68,7 +165,7 @@ func TestNoReturn_Statement(t *testing.T) {
 		return nil
 	})
 	if err != nil {
-		panic(err)
+		r.noReturnErrorHandler(ResilientMethods.MyFunction, err)
 	}
 }`,
 			wantErr: false,
@@ -79,6 +176,11 @@ func TestNoReturn_Statement(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			m, err := method.ParseMethod(tt.methodName, tt.signature)
 			require.NoError(t, err)
+			m.ContextVariant = tt.contextVariant
+			m.ErrorVariant = tt.errorVariant
+			m.Abandon = tt.abandon
+			m.Traced = tt.traced
+			m.Recovered = tt.recovered
 			ret := noret.NewNoReturn(m, "Resilient", tt.structTypeArgs, "r")
 			buf := &bytes.Buffer{}
 			s, err := ret.Statement()
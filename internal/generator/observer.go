@@ -0,0 +1,94 @@
+package generator
+
+import (
+	"github.com/dave/jennifer/jen"
+)
+
+// generateObserverTypes declares the Observer of the calls and the helpers that hand the calls and their attempts to it
+func generateObserverTypes(f *jen.File) {
+	ctxParam := jen.Id("ctx").Qual("context", "Context")
+
+	f.Add(jen.Comment("Observer observes the calls to the generated types and their attempts, the method is the name of the method in"))
+	f.Add(jen.Comment("the XxxMethods of the generated type. Implementations must be safe for concurrent use."))
+	f.Add(jen.Type().Id("Observer").Interface(
+		jen.Comment("OnAttemptStart is called before every attempt of a call, the attempts are numbered from 1"),
+		jen.Id("OnAttemptStart").Params(ctxParam.Clone(), jen.Id("method").String(), jen.Id("attempt").Int()),
+		jen.Comment("OnAttemptEnd is called once an attempt returns with the error of the delegate, including the errors classified as"),
+		jen.Comment("non-retryable, or the error that ended the attempt (e.g. the call was abandoned)"),
+		jen.Id("OnAttemptEnd").Params(ctxParam.Clone(), jen.Id("method").String(), jen.Id("attempt").Int(), jen.Id("err").Error(), jen.Id("duration").Qual("time", "Duration")),
+		jen.Comment("OnCallEnd is called once the call returns with its number of attempts and the error returned by the middlewares or"),
+		jen.Comment("the error classified as non-retryable"),
+		jen.Id("OnCallEnd").Params(ctxParam.Clone(), jen.Id("method").String(), jen.Id("attempts").Int(), jen.Id("err").Error(), jen.Id("duration").Qual("time", "Duration")),
+	))
+
+	// Declare the WithObserver Option which configures the observer of the calls
+	f.Add(jen.Func().Id("WithObserver").Params(jen.Id("observer").Id("Observer")).Params(jen.Id("Option")).Block(
+		jen.Return(jen.Func().Params(jen.Id("o").Op("*").Id("base")).Block(
+			jen.Id("o").Dot("observer").Op("=").Id("observer"),
+		)),
+	))
+
+	f.Add(jen.Comment("observedAttemptKey is the context key of the attempt being observed"))
+	f.Add(jen.Type().Id("observedAttemptKey").Struct())
+
+	f.Add(jen.Comment("observedAttempt holds the error of an attempt classified as non-retryable, the attempt returns nil to the middlewares"))
+	f.Add(jen.Type().Id("observedAttempt").Struct(
+		jen.Id("nonRetryableErr").Error(),
+	))
+
+	f.Add(jen.Comment("observeNonRetryable hands the error classified as non-retryable to the attempt observed with the given context"))
+	f.Add(jen.Func().Id("observeNonRetryable").Params(ctxParam.Clone(), jen.Id("err").Error()).Block(
+		jen.If(
+			jen.List(jen.Id("attempt"), jen.Id("ok")).Op(":=").Id("ctx").Dot("Value").Call(jen.Id("observedAttemptKey").Values()).Assert(jen.Op("*").Id("observedAttempt")),
+			jen.Id("ok"),
+		).Block(
+			jen.Id("attempt").Dot("nonRetryableErr").Op("=").Id("err"),
+		),
+	))
+
+	f.Add(jen.Comment("runObserved runs fn with the given runner, the attempts and the call are handed to the observer"))
+	f.Add(jen.Func().Params(jen.Id("b").Op("*").Id("base")).Id("runObserved").Params(
+		ctxParam.Clone(),
+		jen.Id("name").String(),
+		jen.Id("r").Qual("github.com/slok/goresilience", "Runner"),
+		jen.Id("fn").Func().Params(ctxParam.Clone()).Error(),
+	).Error().Block(
+		jen.If(jen.Id("b").Dot("observer").Op("==").Nil()).Block(
+			jen.Return(jen.Id("r").Dot("Run").Call(jen.Id("ctx"), jen.Id("fn"))),
+		),
+		jen.Var().Id("mu").Qual("sync", "Mutex"),
+		jen.Var().Id("attempts").Int(),
+		jen.Var().Id("nonRetryableErr").Error(),
+		jen.Id("start").Op(":=").Qual("time", "Now").Call(),
+		jen.Id("err").Op(":=").Id("r").Dot("Run").Call(jen.Id("ctx"), jen.Func().Params(ctxParam.Clone()).Error().Block(
+			jen.Id("mu").Dot("Lock").Call(),
+			jen.Id("attempts").Op("++"),
+			jen.Id("attempt").Op(":=").Id("attempts"),
+			jen.Id("mu").Dot("Unlock").Call(),
+			jen.Line(),
+			jen.Id("b").Dot("observer").Dot("OnAttemptStart").Call(jen.Id("ctx"), jen.Id("name"), jen.Id("attempt")),
+			jen.Id("observed").Op(":=").Op("&").Id("observedAttempt").Values(),
+			jen.Id("attemptStart").Op(":=").Qual("time", "Now").Call(),
+			jen.Id("err").Op(":=").Id("fn").Call(jen.Qual("context", "WithValue").Call(jen.Id("ctx"), jen.Id("observedAttemptKey").Values(), jen.Id("observed"))),
+			jen.Id("attemptErr").Op(":=").Id("err"),
+			jen.If(jen.Id("err").Op("==").Nil().Op("&&").Id("observed").Dot("nonRetryableErr").Op("!=").Nil()).Block(
+				jen.Id("attemptErr").Op("=").Id("observed").Dot("nonRetryableErr"),
+				jen.Id("mu").Dot("Lock").Call(),
+				jen.Id("nonRetryableErr").Op("=").Id("attemptErr"),
+				jen.Id("mu").Dot("Unlock").Call(),
+			),
+			jen.Id("b").Dot("observer").Dot("OnAttemptEnd").Call(jen.Id("ctx"), jen.Id("name"), jen.Id("attempt"), jen.Id("attemptErr"), jen.Qual("time", "Since").Call(jen.Id("attemptStart"))),
+			jen.Return(jen.Id("err")),
+		)),
+		jen.Line(),
+		jen.Id("mu").Dot("Lock").Call(),
+		jen.Id("callErr").Op(":=").Id("err"),
+		jen.If(jen.Id("callErr").Op("==").Nil()).Block(
+			jen.Id("callErr").Op("=").Id("nonRetryableErr"),
+		),
+		jen.Id("callAttempts").Op(":=").Id("attempts"),
+		jen.Id("mu").Dot("Unlock").Call(),
+		jen.Id("b").Dot("observer").Dot("OnCallEnd").Call(jen.Id("ctx"), jen.Id("name"), jen.Id("callAttempts"), jen.Id("callErr"), jen.Qual("time", "Since").Call(jen.Id("start"))),
+		jen.Return(jen.Id("err")),
+	))
+}
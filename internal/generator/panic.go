@@ -0,0 +1,53 @@
+package generator
+
+import (
+	"github.com/dave/jennifer/jen"
+)
+
+// generatePanicTypes declares the PanicError of the recovered panics and the helper that recovers the panics of the
+// delegates, the recovered panics are logged when the code is generated with logging
+func generatePanicTypes(f *jen.File, logging bool) {
+	f.Add(jen.Comment("PanicError is the error of an attempt whose delegate panicked, the panic was recovered and the error is handled"))
+	f.Add(jen.Comment("like any other error returned by the delegate"))
+	f.Add(jen.Type().Id("PanicError").Struct(
+		jen.Comment("Value is the value the delegate panicked with"),
+		jen.Id("Value").Interface(),
+		jen.Comment("Stack is the stack trace of the goroutine that panicked"),
+		jen.Id("Stack").Index().Byte(),
+	))
+
+	f.Add(jen.Comment("Error describes the value the delegate panicked with"))
+	f.Add(jen.Func().Params(jen.Id("e").Op("*").Id("PanicError")).Id("Error").Params().String().Block(
+		jen.Return(jen.Qual("fmt", "Sprintf").Call(jen.Lit("delegate panicked: %v"), jen.Id("e").Dot("Value"))),
+	))
+
+	f.Add(jen.Comment("Unwrap returns the value the delegate panicked with when it's an error"))
+	f.Add(jen.Func().Params(jen.Id("e").Op("*").Id("PanicError")).Id("Unwrap").Params().Error().Block(
+		jen.List(jen.Id("err"), jen.Id("_")).Op(":=").Id("e").Dot("Value").Assert(jen.Error()),
+		jen.Return(jen.Id("err")),
+	))
+
+	ctxParamName := "_"
+	statements := []jen.Code{
+		jen.Id("v").Op(":=").Recover(),
+		jen.If(jen.Id("v").Op("==").Nil()).Block(
+			jen.Return(),
+		),
+		jen.Id("panicErr").Op(":=").Op("&").Id("PanicError").Values(jen.Dict{
+			jen.Id("Value"): jen.Id("v"),
+			jen.Id("Stack"): jen.Qual("runtime/debug", "Stack").Call(),
+		}),
+	}
+	if logging {
+		ctxParamName = "ctx"
+		statements = append(statements, jen.Qual(loggingPkg, "Panic").Call(jen.Id("ctx"), jen.Id("panicErr"), jen.Id("panicErr").Dot("Stack")))
+	}
+	statements = append(statements, jen.Op("*").Id("err").Op("=").Id("panicErr"))
+
+	f.Add(jen.Comment("recoverPanic recovers the panic of the delegate into a *PanicError set to the given error, it must be deferred by"))
+	f.Add(jen.Comment("the function that calls the delegate"))
+	f.Add(jen.Func().Id("recoverPanic").Params(
+		jen.Id(ctxParamName).Qual("context", "Context"),
+		jen.Id("err").Op("*").Error(),
+	).Block(statements...))
+}
@@ -36,7 +36,7 @@ func (p *PassThrough) Statement() (*jen.Statement, error) {
 		block = append(block, delegateCall)
 	}
 
-	return jen.Func().Params(jen.Id(p.receiverName).Op("*").Id(p.structName).Types(p.structTypeArgs...)).Id(p.method.Name).Call(methodArgParams...).Block(
+	return jen.Func().Params(jen.Id(p.receiverName).Op("*").Id(p.structName).Types(p.structTypeArgs...)).Id(p.method.Name).Call(methodArgParams...).Params(p.method.ReturnTypes...).Block(
 		block...,
 	), nil
 }
@@ -31,8 +31,8 @@ func TestPassThrough_Statement(t *testing.T) {
 				ctxVar,
 				types.NewVar(token.NoPos, nil, "myArg", types.Typ[types.String]),
 			), types.NewTuple(types.NewVar(token.NoPos, nil, "", types.Typ[types.String])), false),
-			want: `func (r *resilient) MyFunction(ctx context.Context, arg1 string) {
-	return r.delegate.MyFunction(ctx, arg1)
+			want: `func (r *resilient) MyFunction(ctx context.Context, myArg string) string {
+	return r.delegate.MyFunction(ctx, myArg)
 }`,
 			wantErr: false,
 		},
@@ -43,8 +43,8 @@ func TestPassThrough_Statement(t *testing.T) {
 				ctxVar,
 				types.NewVar(token.NoPos, nil, "myArg", types.Typ[types.String]),
 			), types.NewTuple(types.NewVar(token.NoPos, nil, "", types.Typ[types.String])), false),
-			want: `func (r *resilient) MyFunction(ctx context.Context, arg1 string) {
-	return r.delegate.MyFunction(ctx, arg1)
+			want: `func (r *resilient) MyFunction(ctx context.Context, myArg string) string {
+	return r.delegate.MyFunction(ctx, myArg)
 }`,
 			wantErr: false,
 		},
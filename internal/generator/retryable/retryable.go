@@ -8,8 +8,16 @@ import (
 )
 
 const (
-	errVarName             = "err"
-	nonRetryableErrVarName = "nonRetryableErr"
+	errVarName               = "err"
+	nonRetryableErrVarName   = "nonRetryableErr"
+	panicErrVarName          = "panicErr"
+	nonRetryablePanicVarName = "nonRetryablePanic"
+	typedErrVarName          = "typedErr"
+	abandonErrVarName        = "abandonErr"
+	attemptVarName           = "attempt"
+	fallbackVarName          = "fallback"
+	runnerPkg                = "github.com/clear-street/reinforcer/pkg/runner"
+	loggingPkg               = "github.com/clear-street/reinforcer/pkg/logging"
 )
 
 // Retryable is a code generator for a method that can be retried on error
@@ -40,7 +48,7 @@ func (r *Retryable) Statement() (*jen.Statement, error) {
 	if err != nil {
 		return nil, err
 	}
-	return jen.Func().Params(jen.Id(r.receiverName).Op("*").Id(r.structName).Types(r.structTypeArgs...)).Id(r.method.Name).Call(r.method.ParametersNameAndType...).Params(r.method.ReturnTypes...).Block(
+	return jen.Func().Params(jen.Id(r.receiverName).Op("*").Id(r.structName).Types(r.structTypeArgs...)).Id(r.method.ProxyName()).Call(r.method.ProxyParameters()...).Params(r.method.ReturnTypes...).Block(
 		methodCallStatements...,
 	), nil
 }
@@ -48,8 +56,22 @@ func (r *Retryable) Statement() (*jen.Statement, error) {
 func (r *Retryable) methodCall() ([]jen.Code, error) {
 	params := r.method.Parameters()
 
+	// Custom error types (e.g. *MyErr) are kept in variables of their own type so they can be returned as-is
+	var errType jen.Code = jen.Id("error")
+	if r.method.CustomErrorType {
+		errType = r.method.ReturnTypes[*r.method.ReturnErrorIndex]
+	}
+
 	statements := []jen.Code{
-		jen.Var().Id(nonRetryableErrVarName).Id("error"),
+		jen.Var().Id(nonRetryableErrVarName).Add(errType),
+	}
+
+	// The recovered panics can't be kept in variables of custom error types
+	recoverPanicsApart := r.method.Recovered && r.method.CustomErrorType
+	recoverErrVarName := errVarName
+	if recoverPanicsApart {
+		recoverErrVarName = panicErrVarName
+		statements = append(statements, jen.Var().Id(nonRetryablePanicVarName).Error())
 	}
 
 	// Declare the return vars
@@ -72,28 +94,61 @@ func (r *Retryable) methodCall() ([]jen.Code, error) {
 		statements = append(statements, jen.Var().Id(varName).Add(r.method.ReturnTypes[i]))
 	}
 
-	ctxParamName, ctxParam := r.method.ContextParam()
+	// The context handed by the middlewares is always needed to hand the errors classified as non-retryable to their
+	// handler
+	_, ctxParam := r.method.ContextParam()
+	ctxParamName := "ctx"
 
-	// anonymous function passed to the middleware
-	call := jen.Func().Call(jen.Id(ctxParamName).Qual("context", "Context")).Params(jen.Id("error")).Block(
+	callStatements := append(r.method.StartAttempt(),
 		// var err error
-		jen.Var().Id("err").Id("error"),
+		jen.Var().Id("err").Add(errType),
+	)
+	if recoverPanicsApart {
+		// var panicErr error
+		callStatements = append(callStatements, jen.Var().Id(panicErrVarName).Error())
+	}
+	if r.method.Abandon || r.method.Hedged {
+		callStatements = append(callStatements, r.attemptCall(returnVars, params, recoverErrVarName)...)
+	} else {
 		// r0, r1, ..., err = r.delegate.Fn(args...)
-		jen.List(returnVars...).Op("=").Id(r.receiverName).Dot("delegate").Dot(r.method.Name).Call(params...),
-		// if r.errorPredicate(methodName, err) {
-		//  return err
+		callStatements = append(callStatements, r.method.RecoveredCall(jen.List(returnVars...).Op("=").Id(r.receiverName).Dot("delegate").Dot(r.method.Name).Call(params...), recoverErrVarName))
+	}
+	if recoverPanicsApart {
+		// if panicErr != nil {
+		//   ...
+		//   nonRetryablePanic = panicErr
+		//   return nil
 		// }
-		jen.If(jen.Id(r.receiverName).Dot("errorPredicate").Call(r.method.ConstantRef(r.structName), jen.Id(errVarName))).Block(
-			jen.Return(jen.Id("err")),
-		),
-		// nonRetryableErr = err
-		jen.Id(nonRetryableErrVarName).Op("=").Id(errVarName),
-		// return nil
-		jen.Return(jen.Nil()),
+		callStatements = append(callStatements, jen.If(jen.Id(panicErrVarName).Op("!=").Nil()).Block(
+			r.handleError(panicErrVarName, nonRetryablePanicVarName)...,
+		))
+	}
+	if r.method.CustomErrorType {
+		// A nil custom error must not be converted to a non-nil error interface
+		// if err == nil {
+		//   return nil
+		// }
+		callStatements = append(callStatements, jen.If(jen.Id(errVarName).Op("==").Nil()).Block(
+			jen.Return(jen.Nil()),
+		))
+	}
+
+	// anonymous function passed to the middleware
+	call := jen.Func().Call(jen.Id(ctxParamName).Qual("context", "Context")).Params(jen.Id("error")).Block(
+		append(callStatements, r.handleError(errVarName, nonRetryableErrVarName)...)...,
 	)
 
 	statements = append(statements, jen.Id("err").Op(":=").Id(r.receiverName).Dot("run").Call(ctxParam, r.method.ConstantRef(r.structName), call))
 
+	if recoverPanicsApart {
+		// if nonRetryablePanic != nil {
+		//   return r0, r1, ..., r.errorConverters["Resilient.Fn"].(func(error) *MyErr)(nonRetryablePanic)
+		// }
+		statements = append(statements, jen.If(jen.Id(nonRetryablePanicVarName).Op("!=").Nil()).Block(
+			r.convertError(returnVars, nonRetryablePanicVarName),
+		))
+	}
+
 	nonRetryErrReturns := make([]jen.Code, len(returnVars))
 	copy(nonRetryErrReturns, returnVars)
 	nonRetryErrReturns[*r.method.ReturnErrorIndex] = jen.Id(nonRetryableErrVarName)
@@ -105,8 +160,158 @@ func (r *Retryable) methodCall() ([]jen.Code, error) {
 		jen.Return(nonRetryErrReturns...),
 	))
 
-	// return r0, r1, ..., err
-	statements = append(statements, jen.Return(returnVars...))
+	// if err != nil {
+	//   if fallback, ok := r.fallbacks["Resilient.Fn"].(func(context.Context, error) (string, error)); ok {
+	//     return fallback(ctx, err)
+	//   }
+	// }
+	statements = append(statements, jen.If(jen.Id(errVarName).Op("!=").Nil()).Block(
+		jen.If(
+			jen.List(jen.Id(fallbackVarName), jen.Id("ok")).Op(":=").Id(r.receiverName).Dot("fallbacks").Index(jen.Lit(r.method.FallbackKey(r.structName))).Assert(r.method.FallbackType()),
+			jen.Id("ok"),
+		).Block(
+			jen.Return(jen.Id(fallbackVarName).Call(ctxParam, jen.Id(errVarName))),
+		),
+	))
+
+	if !r.method.CustomErrorType {
+		// return r0, r1, ..., err
+		statements = append(statements, jen.Return(returnVars...))
+		return statements, nil
+	}
+
+	typedErrReturns := make([]jen.Code, len(returnVars))
+	copy(typedErrReturns, returnVars)
+	typedErrReturns[*r.method.ReturnErrorIndex] = jen.Id(typedErrVarName)
+	nilErrReturns := make([]jen.Code, len(returnVars))
+	copy(nilErrReturns, returnVars)
+	nilErrReturns[*r.method.ReturnErrorIndex] = jen.Nil()
 
+	// if err != nil {
+	//   if typedErr, ok := err.(*MyErr); ok {
+	//     return r0, r1, ..., typedErr
+	//   }
+	//   return r0, r1, ..., r.errorConverters["Resilient.Fn"].(func(error) *MyErr)(err)
+	// }
+	// return r0, r1, ..., nil
+	statements = append(statements,
+		jen.If(jen.Id(errVarName).Op("!=").Nil()).Block(
+			jen.If(
+				jen.List(jen.Id(typedErrVarName), jen.Id("ok")).Op(":=").Id(errVarName).Assert(errType),
+				jen.Id("ok"),
+			).Block(
+				jen.Return(typedErrReturns...),
+			),
+			r.convertError(returnVars, errVarName),
+		),
+		jen.Return(nilErrReturns...),
+	)
 	return statements, nil
 }
+
+// convertError generates the statement that returns the given error converted to the method's custom error type by the
+// method's error converter, the generated type can't be created without it
+func (r *Retryable) convertError(returnVars []jen.Code, errVar string) jen.Code {
+	convertedErrReturns := make([]jen.Code, len(returnVars))
+	copy(convertedErrReturns, returnVars)
+	// r.errorConverters["Resilient.Fn"].(func(error) *MyErr)(err)
+	convertedErrReturns[*r.method.ReturnErrorIndex] = jen.Id(r.receiverName).Dot("errorConverters").Index(jen.Lit(r.method.ErrorConverterKey(r.structName))).Assert(r.method.ErrorConverterType()).Call(jen.Id(errVar))
+
+	// return r0, r1, ..., r.errorConverters["Resilient.Fn"].(func(error) *MyErr)(err)
+	return jen.Return(convertedErrReturns...)
+}
+
+// handleError generates the statements that hand the given error to the middlewares unless the predicate classifies it
+// as non-retryable, in which case the error is kept in the given variable and the attempt succeeds
+func (r *Retryable) handleError(errVar, nonRetryableVar string) []jen.Code {
+	var statements []jen.Code
+	if r.method.Traced {
+		// attempt.RecordError(err)
+		statements = append(statements, jen.Id(attemptVarName).Dot("RecordError").Call(jen.Id(errVar)))
+	}
+
+	// if r.errorPredicate(methodName, err) {
+	//  return err
+	// }
+	statements = append(statements, jen.If(jen.Id(r.receiverName).Dot("errorPredicate").Call(r.method.ConstantRef(r.structName), jen.Id(errVar))).Block(
+		jen.Return(jen.Id(errVar)),
+	))
+
+	if r.method.Traced {
+		// attempt.NonRetryable(err)
+		statements = append(statements, jen.Id(attemptVarName).Dot("NonRetryable").Call(jen.Id(errVar)))
+	}
+
+	if r.method.Observed {
+		// observeNonRetryable(ctx, err)
+		statements = append(statements, jen.Id("observeNonRetryable").Call(jen.Id("ctx"), jen.Id(errVar)))
+	}
+
+	if r.method.Logged {
+		// logging.NonRetryable(ctx, err)
+		statements = append(statements, jen.Qual(loggingPkg, "NonRetryable").Call(jen.Id("ctx"), jen.Id(errVar)))
+	}
+
+	return append(statements,
+		// r.nonRetryableErrorHandler(ctx, methodName, err)
+		jen.Id(r.receiverName).Dot("nonRetryableErrorHandler").Call(jen.Id("ctx"), r.method.ConstantRef(r.structName), jen.Id(errVar)),
+		// nonRetryableErr = err
+		jen.Id(nonRetryableVar).Op("=").Id(errVar),
+		// return nil
+		jen.Return(jen.Nil()),
+	)
+}
+
+// attemptCall keeps the results of the call to the delegate in variables of the attempt, the results are only assigned
+// once the call completes before the context is done so that an abandoned call doesn't hand out its results once the
+// middlewares returned, and once the attempt claimed the call so that concurrent attempts of a hedged call can't race
+// with each other
+func (r *Retryable) attemptCall(returnVars []jen.Code, params []jen.Code, recoverErrVarName string) []jen.Code {
+	var statements []jen.Code
+	var assignments []jen.Code
+	resultVars := make([]jen.Code, len(returnVars))
+	for i, returnVar := range returnVars {
+		if *r.method.ReturnErrorIndex == i {
+			resultVars[i] = returnVar
+			continue
+		}
+		// var res0 string
+		resultVar := fmt.Sprintf("res%d", i)
+		statements = append(statements, jen.Var().Id(resultVar).Add(r.method.ReturnTypes[i]))
+		resultVars[i] = jen.Id(resultVar)
+		// r0 = res0
+		assignments = append(assignments, jen.Add(returnVar).Op("=").Id(resultVar))
+	}
+
+	// res0, res1, ..., err = r.delegate.Fn(args...)
+	call := jen.List(resultVars...).Op("=").Id(r.receiverName).Dot("delegate").Dot(r.method.Name).Call(params...)
+	if r.method.Abandon {
+		// The panics are recovered in the goroutine of the abandoned call
+		abandoned := []jen.Code{call}
+		if r.method.Recovered {
+			abandoned = append([]jen.Code{r.method.RecoverPanic(recoverErrVarName)}, abandoned...)
+		}
+		// if abandonErr := abandon(ctx, func() {...}); abandonErr != nil {
+		//   return abandonErr
+		// }
+		call = jen.If(
+			jen.Id(abandonErrVarName).Op(":=").Id("abandon").Call(jen.Id("ctx"), jen.Func().Params().Block(abandoned...)),
+			jen.Id(abandonErrVarName).Op("!=").Nil(),
+		).Block(
+			jen.Return(jen.Id(abandonErrVarName)),
+		)
+	} else {
+		call = r.method.RecoveredCall(call, recoverErrVarName)
+	}
+	statements = append(statements, call)
+
+	if r.method.Hedged {
+		// if !runner.ClaimAttempt(ctx) {
+		//   return runner.ErrHedgeLost
+		// }
+		statements = append(statements, jen.If(jen.Op("!").Qual(runnerPkg, "ClaimAttempt").Call(jen.Id("ctx"))).Block(
+			jen.Return(jen.Qual(runnerPkg, "ErrHedgeLost")),
+		))
+	}
+	return append(statements, assignments...)
+}
@@ -16,12 +16,22 @@ import (
 func TestRetryable_Statement(t *testing.T) {
 	errVar := types.NewVar(token.NoPos, nil, "", rtypes.ErrType)
 	ctxVar := types.NewVar(token.NoPos, nil, "ctx", rtypes.ContextType)
+	fakePkg := types.NewPackage("github.com/clear-street/fake", "fake")
+	customErrVar := types.NewVar(token.NoPos, nil, "", types.NewNamed(types.NewTypeName(token.NoPos, fakePkg, "MyError", nil), rtypes.ErrType.Underlying(), nil))
 
 	tests := []struct {
 		name           string
 		methodName     string
 		structTypeArgs []jen.Code
 		signature      *types.Signature
+		noRetry        bool
+		contextVariant bool
+		abandon        bool
+		hedged         bool
+		traced         bool
+		observed       bool
+		logged         bool
+		recovered      bool
 		want           string
 		wantErr        bool
 	}{
@@ -31,18 +41,24 @@ func TestRetryable_Statement(t *testing.T) {
 			signature:  types.NewSignatureType(nil, nil, nil, types.NewTuple(), types.NewTuple(errVar), false),
 			want: `func (r *Resilient) MyFunction() error {
 	var nonRetryableErr error
-	err := r.run(context.Background(), ResilientMethods.MyFunction, func(_ context.Context) error {
+	err := r.run(context.Background(), ResilientMethods.MyFunction, func(ctx context.Context) error {
 		var err error
 		err = r.delegate.MyFunction()
 		if r.errorPredicate(ResilientMethods.MyFunction, err) {
 			return err
 		}
+		r.nonRetryableErrorHandler(ctx, ResilientMethods.MyFunction, err)
 		nonRetryableErr = err
 		return nil
 	})
 	if nonRetryableErr != nil {
 		return nonRetryableErr
 	}
+	if err != nil {
+		if fallback, ok := r.fallbacks["Resilient.MyFunction"].(func(context.Context, error) error); ok {
+			return fallback(context.Background(), err)
+		}
+	}
 	return err
 }`,
 			wantErr: false,
@@ -54,18 +70,24 @@ func TestRetryable_Statement(t *testing.T) {
 			want: `func (r *Resilient) MyFunction() (string, error) {
 	var nonRetryableErr error
 	var r0 string
-	err := r.run(context.Background(), ResilientMethods.MyFunction, func(_ context.Context) error {
+	err := r.run(context.Background(), ResilientMethods.MyFunction, func(ctx context.Context) error {
 		var err error
 		r0, err = r.delegate.MyFunction()
 		if r.errorPredicate(ResilientMethods.MyFunction, err) {
 			return err
 		}
+		r.nonRetryableErrorHandler(ctx, ResilientMethods.MyFunction, err)
 		nonRetryableErr = err
 		return nil
 	})
 	if nonRetryableErr != nil {
 		return r0, nonRetryableErr
 	}
+	if err != nil {
+		if fallback, ok := r.fallbacks["Resilient.MyFunction"].(func(context.Context, error) (string, error)); ok {
+			return fallback(context.Background(), err)
+		}
+	}
 	return r0, err
 }`,
 			wantErr: false,
@@ -77,21 +99,27 @@ func TestRetryable_Statement(t *testing.T) {
 				ctxVar,
 				types.NewVar(token.NoPos, nil, "myArg", types.Typ[types.String]),
 			), types.NewTuple(types.NewVar(token.NoPos, nil, "", types.Typ[types.String]), errVar), false),
-			want: `func (r *Resilient) MyFunction(ctx context.Context, arg1 string) (string, error) {
+			want: `func (r *Resilient) MyFunction(ctx context.Context, myArg string) (string, error) {
 	var nonRetryableErr error
 	var r0 string
 	err := r.run(ctx, ResilientMethods.MyFunction, func(ctx context.Context) error {
 		var err error
-		r0, err = r.delegate.MyFunction(ctx, arg1)
+		r0, err = r.delegate.MyFunction(ctx, myArg)
 		if r.errorPredicate(ResilientMethods.MyFunction, err) {
 			return err
 		}
+		r.nonRetryableErrorHandler(ctx, ResilientMethods.MyFunction, err)
 		nonRetryableErr = err
 		return nil
 	})
 	if nonRetryableErr != nil {
 		return r0, nonRetryableErr
 	}
+	if err != nil {
+		if fallback, ok := r.fallbacks["Resilient.MyFunction"].(func(context.Context, error) (string, error)); ok {
+			return fallback(ctx, err)
+		}
+	}
 	return r0, err
 }`,
 			wantErr: false,
@@ -103,19 +131,486 @@ func TestRetryable_Statement(t *testing.T) {
 			signature:      types.NewSignatureType(nil, nil, nil, types.NewTuple(), types.NewTuple(errVar), false),
 			want: `func (r *Resilient[T]) MyFunction() error {
 	var nonRetryableErr error
-	err := r.run(context.Background(), ResilientMethods.MyFunction, func(_ context.Context) error {
+	err := r.run(context.Background(), ResilientMethods.MyFunction, func(ctx context.Context) error {
+		var err error
+		err = r.delegate.MyFunction()
+		if r.errorPredicate(ResilientMethods.MyFunction, err) {
+			return err
+		}
+		r.nonRetryableErrorHandler(ctx, ResilientMethods.MyFunction, err)
+		nonRetryableErr = err
+		return nil
+	})
+	if nonRetryableErr != nil {
+		return nonRetryableErr
+	}
+	if err != nil {
+		if fallback, ok := r.fallbacks["Resilient.MyFunction"].(func(context.Context, error) error); ok {
+			return fallback(context.Background(), err)
+		}
+	}
+	return err
+}`,
+			wantErr: false,
+		},
+		{
+			name:       "Function returns multiple errors",
+			methodName: "MyFunction",
+			signature:  types.NewSignatureType(nil, nil, nil, types.NewTuple(), types.NewTuple(errVar, errVar), false),
+			want: `func (r *Resilient) MyFunction() (error, error) {
+	var nonRetryableErr error
+	var r0 error
+	err := r.run(context.Background(), ResilientMethods.MyFunction, func(ctx context.Context) error {
+		var err error
+		r0, err = r.delegate.MyFunction()
+		if r.errorPredicate(ResilientMethods.MyFunction, err) {
+			return err
+		}
+		r.nonRetryableErrorHandler(ctx, ResilientMethods.MyFunction, err)
+		nonRetryableErr = err
+		return nil
+	})
+	if nonRetryableErr != nil {
+		return r0, nonRetryableErr
+	}
+	if err != nil {
+		if fallback, ok := r.fallbacks["Resilient.MyFunction"].(func(context.Context, error) (error, error)); ok {
+			return fallback(context.Background(), err)
+		}
+	}
+	return r0, err
+}`,
+			wantErr: false,
+		},
+		{
+			name:       "Function errors that aren't retried are handed to the middlewares",
+			methodName: "MyFunction",
+			signature:  types.NewSignatureType(nil, nil, nil, types.NewTuple(), types.NewTuple(errVar), false),
+			noRetry:    true,
+			want: `func (r *Resilient) MyFunction() error {
+	var nonRetryableErr error
+	err := r.run(context.Background(), ResilientMethods.MyFunction, func(ctx context.Context) error {
+		var err error
+		err = r.delegate.MyFunction()
+		if r.errorPredicate(ResilientMethods.MyFunction, err) {
+			return err
+		}
+		r.nonRetryableErrorHandler(ctx, ResilientMethods.MyFunction, err)
+		nonRetryableErr = err
+		return nil
+	})
+	if nonRetryableErr != nil {
+		return nonRetryableErr
+	}
+	if err != nil {
+		if fallback, ok := r.fallbacks["Resilient.MyFunction"].(func(context.Context, error) error); ok {
+			return fallback(context.Background(), err)
+		}
+	}
+	return err
+}`,
+			wantErr: false,
+		},
+		{
+			name:           "Function context variant abandons the call",
+			methodName:     "MyFunction",
+			signature:      types.NewSignatureType(nil, nil, nil, types.NewTuple(types.NewVar(token.NoPos, nil, "myArg", types.Typ[types.String])), types.NewTuple(types.NewVar(token.NoPos, nil, "", types.Typ[types.String]), errVar), false),
+			contextVariant: true,
+			abandon:        true,
+			want: `func (r *Resilient) MyFunctionCtx(ctx context.Context, myArg string) (string, error) {
+	var nonRetryableErr error
+	var r0 string
+	err := r.run(ctx, ResilientMethods.MyFunction, func(ctx context.Context) error {
+		var err error
+		var res0 string
+		if abandonErr := abandon(ctx, func() {
+			res0, err = r.delegate.MyFunction(myArg)
+		}); abandonErr != nil {
+			return abandonErr
+		}
+		r0 = res0
+		if r.errorPredicate(ResilientMethods.MyFunction, err) {
+			return err
+		}
+		r.nonRetryableErrorHandler(ctx, ResilientMethods.MyFunction, err)
+		nonRetryableErr = err
+		return nil
+	})
+	if nonRetryableErr != nil {
+		return r0, nonRetryableErr
+	}
+	if err != nil {
+		if fallback, ok := r.fallbacks["Resilient.MyFunction"].(func(context.Context, error) (string, error)); ok {
+			return fallback(ctx, err)
+		}
+	}
+	return r0, err
+}`,
+			wantErr: false,
+		},
+		{
+			name:       "Function abandons the call",
+			methodName: "MyFunction",
+			signature:  types.NewSignatureType(nil, nil, nil, types.NewTuple(), types.NewTuple(errVar), false),
+			abandon:    true,
+			want: `func (r *Resilient) MyFunction() error {
+	var nonRetryableErr error
+	err := r.run(context.Background(), ResilientMethods.MyFunction, func(ctx context.Context) error {
+		var err error
+		if abandonErr := abandon(ctx, func() {
+			err = r.delegate.MyFunction()
+		}); abandonErr != nil {
+			return abandonErr
+		}
+		if r.errorPredicate(ResilientMethods.MyFunction, err) {
+			return err
+		}
+		r.nonRetryableErrorHandler(ctx, ResilientMethods.MyFunction, err)
+		nonRetryableErr = err
+		return nil
+	})
+	if nonRetryableErr != nil {
+		return nonRetryableErr
+	}
+	if err != nil {
+		if fallback, ok := r.fallbacks["Resilient.MyFunction"].(func(context.Context, error) error); ok {
+			return fallback(context.Background(), err)
+		}
+	}
+	return err
+}`,
+			wantErr: false,
+		},
+		{
+			name:       "Function is hedged",
+			methodName: "MyFunction",
+			signature:  types.NewSignatureType(nil, nil, nil, types.NewTuple(), types.NewTuple(types.NewVar(token.NoPos, nil, "", types.Typ[types.String]), errVar), false),
+			hedged:     true,
+			want: `func (r *Resilient) MyFunction() (string, error) {
+	var nonRetryableErr error
+	var r0 string
+	err := r.run(context.Background(), ResilientMethods.MyFunction, func(ctx context.Context) error {
+		var err error
+		var res0 string
+		res0, err = r.delegate.MyFunction()
+		if !runner.ClaimAttempt(ctx) {
+			return runner.ErrHedgeLost
+		}
+		r0 = res0
+		if r.errorPredicate(ResilientMethods.MyFunction, err) {
+			return err
+		}
+		r.nonRetryableErrorHandler(ctx, ResilientMethods.MyFunction, err)
+		nonRetryableErr = err
+		return nil
+	})
+	if nonRetryableErr != nil {
+		return r0, nonRetryableErr
+	}
+	if err != nil {
+		if fallback, ok := r.fallbacks["Resilient.MyFunction"].(func(context.Context, error) (string, error)); ok {
+			return fallback(context.Background(), err)
+		}
+	}
+	return r0, err
+}`,
+			wantErr: false,
+		},
+		{
+			name:       "Function is hedged and abandons the call",
+			methodName: "MyFunction",
+			signature:  types.NewSignatureType(nil, nil, nil, types.NewTuple(), types.NewTuple(errVar), false),
+			abandon:    true,
+			hedged:     true,
+			want: `func (r *Resilient) MyFunction() error {
+	var nonRetryableErr error
+	err := r.run(context.Background(), ResilientMethods.MyFunction, func(ctx context.Context) error {
+		var err error
+		if abandonErr := abandon(ctx, func() {
+			err = r.delegate.MyFunction()
+		}); abandonErr != nil {
+			return abandonErr
+		}
+		if !runner.ClaimAttempt(ctx) {
+			return runner.ErrHedgeLost
+		}
+		if r.errorPredicate(ResilientMethods.MyFunction, err) {
+			return err
+		}
+		r.nonRetryableErrorHandler(ctx, ResilientMethods.MyFunction, err)
+		nonRetryableErr = err
+		return nil
+	})
+	if nonRetryableErr != nil {
+		return nonRetryableErr
+	}
+	if err != nil {
+		if fallback, ok := r.fallbacks["Resilient.MyFunction"].(func(context.Context, error) error); ok {
+			return fallback(context.Background(), err)
+		}
+	}
+	return err
+}`,
+			wantErr: false,
+		},
+		{
+			name:       "Function is traced",
+			methodName: "MyFunction",
+			signature: types.NewSignatureType(nil, nil, nil, types.NewTuple(
+				ctxVar,
+				types.NewVar(token.NoPos, nil, "myArg", types.Typ[types.String]),
+			), types.NewTuple(types.NewVar(token.NoPos, nil, "", types.Typ[types.String]), errVar), false),
+			traced: true,
+			want: `func (r *Resilient) MyFunction(ctx context.Context, myArg string) (string, error) {
+	var nonRetryableErr error
+	var r0 string
+	err := r.run(ctx, ResilientMethods.MyFunction, func(ctx context.Context) error {
+		ctx, attempt := tracing.StartAttempt(ctx)
+		defer attempt.End()
+		var err error
+		r0, err = r.delegate.MyFunction(ctx, myArg)
+		attempt.RecordError(err)
+		if r.errorPredicate(ResilientMethods.MyFunction, err) {
+			return err
+		}
+		attempt.NonRetryable(err)
+		r.nonRetryableErrorHandler(ctx, ResilientMethods.MyFunction, err)
+		nonRetryableErr = err
+		return nil
+	})
+	if nonRetryableErr != nil {
+		return r0, nonRetryableErr
+	}
+	if err != nil {
+		if fallback, ok := r.fallbacks["Resilient.MyFunction"].(func(context.Context, error) (string, error)); ok {
+			return fallback(ctx, err)
+		}
+	}
+	return r0, err
+}`,
+			wantErr: false,
+		},
+		{
+			name:       "Function is observed",
+			methodName: "MyFunction",
+			signature:  types.NewSignatureType(nil, nil, nil, types.NewTuple(), types.NewTuple(types.NewVar(token.NoPos, nil, "", types.Typ[types.String]), errVar), false),
+			observed:   true,
+			want: `func (r *Resilient) MyFunction() (string, error) {
+	var nonRetryableErr error
+	var r0 string
+	err := r.run(context.Background(), ResilientMethods.MyFunction, func(ctx context.Context) error {
+		var err error
+		r0, err = r.delegate.MyFunction()
+		if r.errorPredicate(ResilientMethods.MyFunction, err) {
+			return err
+		}
+		observeNonRetryable(ctx, err)
+		r.nonRetryableErrorHandler(ctx, ResilientMethods.MyFunction, err)
+		nonRetryableErr = err
+		return nil
+	})
+	if nonRetryableErr != nil {
+		return r0, nonRetryableErr
+	}
+	if err != nil {
+		if fallback, ok := r.fallbacks["Resilient.MyFunction"].(func(context.Context, error) (string, error)); ok {
+			return fallback(context.Background(), err)
+		}
+	}
+	return r0, err
+}`,
+			wantErr: false,
+		},
+		{
+			name:       "Function is logged",
+			methodName: "MyFunction",
+			signature:  types.NewSignatureType(nil, nil, nil, types.NewTuple(), types.NewTuple(errVar), false),
+			logged:     true,
+			want: `func (r *Resilient) MyFunction() error {
+	var nonRetryableErr error
+	err := r.run(context.Background(), ResilientMethods.MyFunction, func(ctx context.Context) error {
 		var err error
 		err = r.delegate.MyFunction()
 		if r.errorPredicate(ResilientMethods.MyFunction, err) {
 			return err
 		}
+		logging.NonRetryable(ctx, err)
+		r.nonRetryableErrorHandler(ctx, ResilientMethods.MyFunction, err)
 		nonRetryableErr = err
 		return nil
 	})
 	if nonRetryableErr != nil {
 		return nonRetryableErr
 	}
+	if err != nil {
+		if fallback, ok := r.fallbacks["Resilient.MyFunction"].(func(context.Context, error) error); ok {
+			return fallback(context.Background(), err)
+		}
+	}
 	return err
+}`,
+			wantErr: false,
+		},
+		{
+			name:       "Function recovers panics",
+			methodName: "MyFunction",
+			signature:  types.NewSignatureType(nil, nil, nil, types.NewTuple(), types.NewTuple(types.NewVar(token.NoPos, nil, "", types.Typ[types.String]), errVar), false),
+			recovered:  true,
+			want: `func (r *Resilient) MyFunction() (string, error) {
+	var nonRetryableErr error
+	var r0 string
+	err := r.run(context.Background(), ResilientMethods.MyFunction, func(ctx context.Context) error {
+		var err error
+		func() {
+			defer recoverPanic(ctx, &err)
+			r0, err = r.delegate.MyFunction()
+		}()
+		if r.errorPredicate(ResilientMethods.MyFunction, err) {
+			return err
+		}
+		r.nonRetryableErrorHandler(ctx, ResilientMethods.MyFunction, err)
+		nonRetryableErr = err
+		return nil
+	})
+	if nonRetryableErr != nil {
+		return r0, nonRetryableErr
+	}
+	if err != nil {
+		if fallback, ok := r.fallbacks["Resilient.MyFunction"].(func(context.Context, error) (string, error)); ok {
+			return fallback(context.Background(), err)
+		}
+	}
+	return r0, err
+}`,
+			wantErr: false,
+		},
+		{
+			name:       "Function recovers the panics of the abandoned call",
+			methodName: "MyFunction",
+			signature:  types.NewSignatureType(nil, nil, nil, types.NewTuple(), types.NewTuple(types.NewVar(token.NoPos, nil, "", types.Typ[types.String]), errVar), false),
+			abandon:    true,
+			recovered:  true,
+			want: `func (r *Resilient) MyFunction() (string, error) {
+	var nonRetryableErr error
+	var r0 string
+	err := r.run(context.Background(), ResilientMethods.MyFunction, func(ctx context.Context) error {
+		var err error
+		var res0 string
+		if abandonErr := abandon(ctx, func() {
+			defer recoverPanic(ctx, &err)
+			res0, err = r.delegate.MyFunction()
+		}); abandonErr != nil {
+			return abandonErr
+		}
+		r0 = res0
+		if r.errorPredicate(ResilientMethods.MyFunction, err) {
+			return err
+		}
+		r.nonRetryableErrorHandler(ctx, ResilientMethods.MyFunction, err)
+		nonRetryableErr = err
+		return nil
+	})
+	if nonRetryableErr != nil {
+		return r0, nonRetryableErr
+	}
+	if err != nil {
+		if fallback, ok := r.fallbacks["Resilient.MyFunction"].(func(context.Context, error) (string, error)); ok {
+			return fallback(context.Background(), err)
+		}
+	}
+	return r0, err
+}`,
+			wantErr: false,
+		},
+		{
+			name:       "Function with custom error type recovers panics",
+			methodName: "MyFunction",
+			signature:  types.NewSignatureType(nil, nil, nil, types.NewTuple(), types.NewTuple(customErrVar), false),
+			recovered:  true,
+			want: `func (r *Resilient) MyFunction() fake.MyError {
+	var nonRetryableErr fake.MyError
+	var nonRetryablePanic error
+	err := r.run(context.Background(), ResilientMethods.MyFunction, func(ctx context.Context) error {
+		var err fake.MyError
+		var panicErr error
+		func() {
+			defer recoverPanic(ctx, &panicErr)
+			err = r.delegate.MyFunction()
+		}()
+		if panicErr != nil {
+			if r.errorPredicate(ResilientMethods.MyFunction, panicErr) {
+				return panicErr
+			}
+			r.nonRetryableErrorHandler(ctx, ResilientMethods.MyFunction, panicErr)
+			nonRetryablePanic = panicErr
+			return nil
+		}
+		if err == nil {
+			return nil
+		}
+		if r.errorPredicate(ResilientMethods.MyFunction, err) {
+			return err
+		}
+		r.nonRetryableErrorHandler(ctx, ResilientMethods.MyFunction, err)
+		nonRetryableErr = err
+		return nil
+	})
+	if nonRetryablePanic != nil {
+		return r.errorConverters["Resilient.MyFunction"].(func(error) fake.MyError)(nonRetryablePanic)
+	}
+	if nonRetryableErr != nil {
+		return nonRetryableErr
+	}
+	if err != nil {
+		if fallback, ok := r.fallbacks["Resilient.MyFunction"].(func(context.Context, error) fake.MyError); ok {
+			return fallback(context.Background(), err)
+		}
+	}
+	if err != nil {
+		if typedErr, ok := err.(fake.MyError); ok {
+			return typedErr
+		}
+		return r.errorConverters["Resilient.MyFunction"].(func(error) fake.MyError)(err)
+	}
+	return nil
+}`,
+			wantErr: false,
+		},
+		{
+			name:       "Function returns custom error type",
+			methodName: "MyFunction",
+			signature:  types.NewSignatureType(nil, nil, nil, types.NewTuple(), types.NewTuple(types.NewVar(token.NoPos, nil, "", types.Typ[types.String]), customErrVar), false),
+			want: `func (r *Resilient) MyFunction() (string, fake.MyError) {
+	var nonRetryableErr fake.MyError
+	var r0 string
+	err := r.run(context.Background(), ResilientMethods.MyFunction, func(ctx context.Context) error {
+		var err fake.MyError
+		r0, err = r.delegate.MyFunction()
+		if err == nil {
+			return nil
+		}
+		if r.errorPredicate(ResilientMethods.MyFunction, err) {
+			return err
+		}
+		r.nonRetryableErrorHandler(ctx, ResilientMethods.MyFunction, err)
+		nonRetryableErr = err
+		return nil
+	})
+	if nonRetryableErr != nil {
+		return r0, nonRetryableErr
+	}
+	if err != nil {
+		if fallback, ok := r.fallbacks["Resilient.MyFunction"].(func(context.Context, error) (string, fake.MyError)); ok {
+			return fallback(context.Background(), err)
+		}
+	}
+	if err != nil {
+		if typedErr, ok := err.(fake.MyError); ok {
+			return r0, typedErr
+		}
+		return r0, r.errorConverters["Resilient.MyFunction"].(func(error) fake.MyError)(err)
+	}
+	return r0, nil
 }`,
 			wantErr: false,
 		},
@@ -125,6 +620,14 @@ func TestRetryable_Statement(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			m, err := method.ParseMethod(tt.methodName, tt.signature)
 			require.NoError(t, err)
+			m.NoRetry = tt.noRetry
+			m.ContextVariant = tt.contextVariant
+			m.Abandon = tt.abandon
+			m.Hedged = tt.hedged
+			m.Traced = tt.traced
+			m.Observed = tt.observed
+			m.Logged = tt.logged
+			m.Recovered = tt.recovered
 			ret := retryable.NewRetryable(m, "Resilient", tt.structTypeArgs, "r")
 			buf := &bytes.Buffer{}
 			s, err := ret.Statement()
@@ -3,11 +3,11 @@ package loader
 import (
 	"fmt"
 	"go/ast"
+	"go/token"
 	"go/types"
 	"path/filepath"
 	"regexp"
 	"strings"
-	"unicode"
 
 	"github.com/clear-street/reinforcer/internal/generator/method"
 	rtypes "github.com/clear-street/reinforcer/internal/types"
@@ -39,6 +39,10 @@ const (
 
 const regexChars = "\\.+*?()|[]{}^$"
 
+// loadMode is the information needed from the loaded packages
+const loadMode = packages.NeedTypes | packages.NeedImports | packages.NeedSyntax | packages.NeedTypesInfo |
+	packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles
+
 // LoadingError holds any errors that occurred while loading a package
 type LoadingError struct {
 	Errors []error
@@ -64,6 +68,8 @@ type Result struct {
 // Loader is a utility service for extracting type information from a go package
 type Loader struct {
 	loaderFn func(cfg *packages.Config, patterns ...string) ([]*packages.Package, error)
+	// cache holds the packages that were already loaded keyed by import path or absolute file path
+	cache map[string][]*packages.Package
 }
 
 // DefaultLoader creates the default loader
@@ -78,7 +84,55 @@ func NewLoader(pkgLoader func(cfg *packages.Config, patterns ...string) ([]*pack
 	}
 	return &Loader{
 		loaderFn: pkgLoader,
+		cache:    make(map[string][]*packages.Package),
+	}
+}
+
+// Preload loads the given packages (import paths) and files in a single pass so that subsequent loads of any of them
+// don't need to go through the package loader again. Loading packages is the most expensive part of code generation so
+// loading everything at once is considerably faster than loading each path on its own when targeting many paths.
+func (l *Loader) Preload(packagePaths []string, filePaths []string) error {
+	patterns := make([]string, 0, len(packagePaths)+len(filePaths))
+	pkgPaths := make(map[string]struct{})
+	for _, pkgPath := range packagePaths {
+		if _, ok := l.cache[pkgPath]; !ok {
+			pkgPaths[pkgPath] = struct{}{}
+			patterns = append(patterns, pkgPath)
+		}
+	}
+	absFilePaths := make(map[string]struct{})
+	for _, filePath := range filePaths {
+		absolutePath, err := filepath.Abs(filePath)
+		if err != nil {
+			return fmt.Errorf("failed to create absolute path from=%s; error=%w", filePath, err)
+		}
+		if _, ok := l.cache[absolutePath]; !ok {
+			absFilePaths[absolutePath] = struct{}{}
+			patterns = append(patterns, "file="+absolutePath)
+		}
+	}
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	log.Debug().Msgf("Preloading %d patterns", len(patterns))
+	pkgs, err := l.loaderFn(&packages.Config{Mode: loadMode}, patterns...)
+	if err != nil {
+		return fmt.Errorf("loading packages for inspection: %v", err)
 	}
+
+	// Paths that can't be matched to a loaded package (e.g. relative import paths) are loaded on their own later on
+	for _, pkg := range pkgs {
+		if _, ok := pkgPaths[pkg.PkgPath]; ok {
+			l.cache[pkg.PkgPath] = []*packages.Package{pkg}
+		}
+		for _, goFile := range pkg.GoFiles {
+			if _, ok := absFilePaths[goFile]; ok {
+				l.cache[goFile] = []*packages.Package{pkg}
+			}
+		}
+	}
+	return nil
 }
 
 // LoadOne loads the given type
@@ -140,9 +194,14 @@ func (l *Loader) loadExpr(path string, expr *regexp.Regexp, mode LoadMode) (*pac
 
 	var typesFound []string
 	if mode == FileLoadMode {
+		absolutePath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create absolute path from=%s; error=%w", path, err)
+		}
+
 		var targetFileIndex int
 		for idx, goFile := range goFiles {
-			if path == goFile {
+			if absolutePath == goFile {
 				logger.Trace().Msgf("Target file found at index %d", idx)
 				targetFileIndex = idx
 				break
@@ -168,6 +227,7 @@ func (l *Loader) loadExpr(path string, expr *regexp.Regexp, mode LoadMode) (*pac
 
 	logger.Info().Msgf("Matching types to target expressions: %s", strings.Join(matchingTypes, ", "))
 
+	docs := methodDocs(pkg.Syntax)
 	for _, typeFound := range matchingTypes {
 		obj := pkg.Types.Scope().Lookup(typeFound)
 		if obj == nil {
@@ -177,14 +237,14 @@ func (l *Loader) loadExpr(path string, expr *regexp.Regexp, mode LoadMode) (*pac
 		switch typ := obj.Type().Underlying().(type) {
 		case *types.Interface:
 			logger.Info().Msgf("Discovered interface type %s", typeFound)
-			result, err := loadFromInterface(typeFound, typ, obj.Type())
+			result, err := loadFromInterface(typeFound, typ, obj.Type(), docs, pkg.Fset)
 			if err != nil {
 				return nil, nil, err
 			}
 			results[typeFound] = result
 		case *types.Struct:
 			logger.Info().Msgf("Discovered struct type %s", typeFound)
-			result, err := loadFromStruct(pkg.Syntax[0], typeFound, pkg.TypesInfo)
+			result, err := loadFromStruct(typeFound, obj.Type(), docs, pkg.Fset)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -201,46 +261,45 @@ func (l *Loader) loadExpr(path string, expr *regexp.Regexp, mode LoadMode) (*pac
 
 func (l *Loader) load(path string, mode LoadMode) ([]*packages.Package, error) {
 	cfg := &packages.Config{
-		Mode: packages.NeedTypes | packages.NeedImports | packages.NeedSyntax | packages.NeedTypesInfo |
-			packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles,
+		Mode: loadMode,
 	}
 
-	var pkgs []*packages.Package
-	var err error
+	var pattern, key string
 	if mode == PackageLoadMode {
-		pkgs, err = l.loaderFn(cfg, path)
-		if err != nil {
-			return nil, fmt.Errorf("loading packages for inspection: %v", err)
-		}
+		pattern, key = path, path
 	} else if mode == FileLoadMode {
 		absolutePath, err := filepath.Abs(path)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create absolute path from=%s; error=%w", path, err)
 		}
-		pkgs, err = l.loaderFn(cfg, "file="+absolutePath)
-		if err != nil {
-			return nil, fmt.Errorf("loading packages for inspection: %v", err)
-		}
+		pattern, key = "file="+absolutePath, absolutePath
 	} else {
 		return nil, fmt.Errorf("unsupported load mode=%v", mode)
 	}
+
+	if pkgs, ok := l.cache[key]; ok {
+		log.Debug().Msgf("Using cached packages for %s", key)
+		return pkgs, nil
+	}
+	pkgs, err := l.loaderFn(cfg, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("loading packages for inspection: %v", err)
+	}
+	l.cache[key] = pkgs
 	return pkgs, nil
 }
 
-func loadFromInterface(name string, interfaceType *types.Interface, objType types.Type) (*Result, error) {
+func loadFromInterface(name string, interfaceType *types.Interface, objType types.Type, docs map[token.Pos]*ast.CommentGroup, fset *token.FileSet) (*Result, error) {
 	result := &Result{
 		Name: name,
 	}
-	typeParams := objType.(*types.Named).TypeParams()
-	for p := 0; p < typeParams.Len(); p++ {
-		typeParam := typeParams.At(p)
-		typeParamName := typeParam.Obj().Name()
-		typ, err := rtypes.ToType(typeParam.Constraint(), false)
-		if err != nil {
-			return nil, fmt.Errorf("failed to convert type parameter %s; error=%w", typeParamName, err)
-		}
-		result.TypeParams = append(result.TypeParams, jen.Id(typeParamName).Add(typ))
-		result.TypeArgs = append(result.TypeArgs, jen.Id(typeParamName))
+	named := objType.(*types.Named)
+	if err := loadTypeParams(result, named); err != nil {
+		return nil, err
+	}
+	explicit := make(map[*types.Func]struct{}, interfaceType.NumExplicitMethods())
+	for m := 0; m < interfaceType.NumExplicitMethods(); m++ {
+		explicit[interfaceType.ExplicitMethod(m)] = struct{}{}
 	}
 	for m := 0; m < interfaceType.NumMethods(); m++ {
 		meth := interfaceType.Method(m)
@@ -248,67 +307,165 @@ func loadFromInterface(name string, interfaceType *types.Interface, objType type
 		if err != nil {
 			return nil, err
 		}
+		if err := applyDoc(mm, docs[meth.Pos()]); err != nil {
+			return nil, err
+		}
+		mm.Position = methodPosition(fset, meth)
+		if _, ok := explicit[meth]; !ok {
+			mm.PromotedFrom = declaringType(meth, named.Obj().Pkg())
+			log.Debug().Msgf("Method %s of %s is promoted from %s", meth.Name(), name, mm.PromotedFrom)
+		}
 		result.Methods = append(result.Methods, mm)
 	}
 	return result, nil
 }
 
-func loadFromStruct(f *ast.File, name string, info *types.Info) (*Result, error) {
+// loadFromStruct loads the exported methods in the method set of a pointer to the struct, this includes the methods
+// declared in any of the package's files as well as the methods promoted from embedded fields
+func loadFromStruct(name string, objType types.Type, docs map[token.Pos]*ast.CommentGroup, fset *token.FileSet) (*Result, error) {
 	result := &Result{
 		Name: name,
 	}
-	var firstError error
-	ast.Inspect(f, func(node ast.Node) bool {
-		fn, ok := node.(*ast.FuncDecl)
-		if !ok {
-			return true
+	named := objType.(*types.Named)
+	if err := loadTypeParams(result, named); err != nil {
+		return nil, err
+	}
+
+	// The methods of a generic type are declared with their own receiver type parameters, instantiating the type with
+	// its type parameters binds the signatures to the type parameters of the type's declaration
+	var typ types.Type = named
+	if typeParams := named.TypeParams(); typeParams.Len() > 0 {
+		typeArgs := make([]types.Type, typeParams.Len())
+		for p := 0; p < typeParams.Len(); p++ {
+			typeArgs[p] = typeParams.At(p)
 		}
-		if fn.Recv == nil {
-			return true
+		inst, err := types.Instantiate(nil, named, typeArgs, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to instantiate %s; error=%w", name, err)
 		}
-		for _, l := range fn.Recv.List {
-			var ident *ast.Ident
-			switch t := l.Type.(type) {
-			case *ast.Ident:
-				ident = t
-			case *ast.StarExpr:
-				switch t := t.X.(type) {
-				case *ast.Ident:
-					ident = t
-				case *ast.IndexExpr:
-					// single type parameter
-					ident = t.X.(*ast.Ident)
-				case *ast.IndexListExpr:
-					// multiple type parameters
-					ident = t.X.(*ast.Ident)
-				}
-			}
+		typ = inst
+	}
 
-			if ident == nil || ident.Name != name {
-				continue
-			}
+	methodSet := types.NewMethodSet(types.NewPointer(typ))
+	for m := 0; m < methodSet.Len(); m++ {
+		sel := methodSet.At(m)
+		meth := sel.Obj().(*types.Func)
+		// Ignore unexported methods
+		if !meth.Exported() {
+			log.Debug().Msgf("Ignoring function %s as it is unexported", meth.Name())
+			continue
+		}
 
-			// Ignore unexported methods
-			if !unicode.IsUpper(rune(fn.Name.Name[0])) {
-				log.Debug().Msgf("Ignoring function %s as it is unexported", fn.Name.Name)
-				continue
-			}
+		mm, err := method.ParseMethod(meth.Name(), meth.Type().(*types.Signature))
+		if err != nil {
+			return nil, err
+		}
+		if err := applyDoc(mm, docs[meth.Pos()]); err != nil {
+			return nil, err
+		}
+		mm.Position = methodPosition(fset, meth)
+		// Methods reached through an embedded field are promoted
+		if len(sel.Index()) > 1 {
+			mm.PromotedFrom = declaringType(meth, named.Obj().Pkg())
+			log.Debug().Msgf("Method %s of %s is promoted from %s", meth.Name(), name, mm.PromotedFrom)
+		}
+		result.Methods = append(result.Methods, mm)
+	}
+	return result, nil
+}
 
-			meth, err := method.ParseMethod(fn.Name.Name, info.Defs[fn.Name].Type().(*types.Signature))
-			if err != nil {
-				if firstError == nil {
-					firstError = err
+// declaringType describes the type that declares the given method, types from other packages are qualified with the
+// name of their package
+func declaringType(meth *types.Func, pkg *types.Package) string {
+	recv := meth.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return ""
+	}
+	typ := recv.Type()
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+	return types.TypeString(typ, func(other *types.Package) string {
+		if other == pkg {
+			return ""
+		}
+		return other.Name()
+	})
+}
+
+// methodPosition describes the position of the method's declaration as the path of its package, the name of its file
+// and its line (e.g. github.com/org/pkg/client.go:12), it's empty for methods without a position (e.g. error's Error)
+func methodPosition(fset *token.FileSet, meth *types.Func) string {
+	if meth.Pkg() == nil || !meth.Pos().IsValid() {
+		return ""
+	}
+	pos := fset.Position(meth.Pos())
+	return fmt.Sprintf("%s/%s:%d", meth.Pkg().Path(), filepath.Base(pos.Filename), pos.Line)
+}
+
+// loadTypeParams loads the type parameters of the given type into the result
+func loadTypeParams(result *Result, named *types.Named) error {
+	typeParams := named.TypeParams()
+	for p := 0; p < typeParams.Len(); p++ {
+		typeParam := typeParams.At(p)
+		typeParamName := typeParam.Obj().Name()
+		typ, err := rtypes.ToType(typeParam.Constraint(), false)
+		if err != nil {
+			return fmt.Errorf("failed to convert type parameter %s; error=%w", typeParamName, err)
+		}
+		result.TypeParams = append(result.TypeParams, jen.Id(typeParamName).Add(typ))
+		result.TypeArgs = append(result.TypeArgs, jen.Id(typeParamName))
+	}
+	return nil
+}
+
+// directivePrefix is the prefix of the comment directives that annotate the methods (e.g. //reinforcer:passthrough)
+const directivePrefix = "//reinforcer:"
+
+// applyDoc sets the text of the method's doc comment and the settings given by the directives in the comment
+func applyDoc(mm *method.Method, doc *ast.CommentGroup) error {
+	if doc == nil {
+		return nil
+	}
+	mm.Doc = doc.Text()
+	for _, c := range doc.List {
+		directive, ok := strings.CutPrefix(c.Text, directivePrefix)
+		if !ok {
+			continue
+		}
+		if err := mm.ApplyDirective(directive); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// methodDocs collects the doc comments of the methods (both interface methods and functions with a receiver) declared
+// in the given files keyed by the position of the method's name
+func methodDocs(files []*ast.File) map[token.Pos]*ast.CommentGroup {
+	docs := make(map[token.Pos]*ast.CommentGroup)
+	for _, f := range files {
+		ast.Inspect(f, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.FuncDecl:
+				if n.Recv != nil && n.Doc != nil {
+					docs[n.Name.Pos()] = n.Doc
 				}
 				return false
+			case *ast.InterfaceType:
+				for _, field := range n.Methods.List {
+					if field.Doc == nil {
+						continue
+					}
+					for _, name := range field.Names {
+						docs[name.Pos()] = field.Doc
+					}
+				}
 			}
-			result.Methods = append(result.Methods, meth)
-		}
-		return true
-	})
-	if firstError != nil {
-		return nil, firstError
+			return true
+		})
 	}
-	return result, nil
+	return docs
 }
 
 func extractPackageErrors(pkgs []*packages.Package) error {
@@ -1,7 +1,9 @@
 package loader_test
 
 import (
+	"github.com/clear-street/reinforcer/internal/generator/method"
 	"github.com/clear-street/reinforcer/internal/loader"
+	"github.com/dave/jennifer/jen"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/tools/go/packages"
 	"golang.org/x/tools/go/packages/packagestest"
@@ -69,6 +71,7 @@ type OtherService interface {
 import "context"
 
 type Service interface {
+	// GetUserID returns the ID of the user
 	GetUserID(ctx context.Context, userID string) (string, error)
 }
 `,
@@ -88,6 +91,7 @@ type Service interface {
 		require.Equal(t, "Service", svc.Name)
 		require.Equal(t, 1, len(svc.Methods))
 		require.Equal(t, "GetUserID", svc.Methods[0].Name)
+		require.Equal(t, "GetUserID returns the ID of the user\n", svc.Methods[0].Doc)
 	})
 
 	t.Run("Load Struct", func(t *testing.T) {
@@ -101,6 +105,7 @@ import "context"
 type service struct {
 }
 
+// GetUserID returns the ID of the user
 func (s *service) GetUserID(ctx context.Context, userID string) (string, error) {
 	return "My User", nil
 }
@@ -119,6 +124,7 @@ func (s *service) GetUserID(ctx context.Context, userID string) (string, error)
 		require.Equal(t, "service", svc.Name)
 		require.Equal(t, 1, len(svc.Methods))
 		require.Equal(t, "GetUserID", svc.Methods[0].Name)
+		require.Equal(t, "GetUserID returns the ID of the user\n", svc.Methods[0].Doc)
 	})
 
 	t.Run("Load struct with method with generic typed argument", func(t *testing.T) {
@@ -202,6 +208,273 @@ func (g *genericService[T, U]) DoTheThing() (string, error) { return "yep", nil
 		require.Equal(t, 1, len(svc.Methods))
 		require.Equal(t, "DoTheThing", svc.Methods[0].Name)
 	})
+
+	t.Run("Load struct with methods across files", func(t *testing.T) {
+		exported := packagestest.Export(t, packagestest.GOPATH, []packagestest.Module{{
+			Name: "github.com/clear-street",
+			Files: map[string]interface{}{
+				"fake/a.go": `package fake
+
+type helper struct{}
+
+func (h *helper) Close() error { return nil }
+`,
+				"fake/service.go": `package fake
+
+import "context"
+
+type Service struct {
+	*helper
+}
+
+func (s *Service) GetUserID(ctx context.Context, userID string) (string, error) {
+	return "My User", nil
+}
+`,
+				"fake/service_save.go": `package fake
+
+import "context"
+
+// SaveUser saves the user
+func (s Service) SaveUser(ctx context.Context, userID string) error {
+	return nil
+}
+
+func (s *Service) validate() error {
+	return nil
+}
+`,
+			}}})
+		defer exported.Cleanup()
+
+		l := loader.NewLoader(func(cfg *packages.Config, patterns ...string) ([]*packages.Package, error) {
+			exported.Config.Mode = cfg.Mode
+			return packages.Load(exported.Config, patterns...)
+		})
+
+		svc, err := l.LoadOne("github.com/clear-street/fake", "Service", loader.PackageLoadMode)
+		require.NoError(t, err)
+		require.NotNil(t, svc)
+		require.Equal(t, 3, len(svc.Methods))
+		require.Equal(t, "Close", svc.Methods[0].Name)
+		require.Equal(t, "GetUserID", svc.Methods[1].Name)
+		require.Equal(t, "SaveUser", svc.Methods[2].Name)
+		require.Equal(t, "SaveUser saves the user\n", svc.Methods[2].Doc)
+	})
+
+	t.Run("Load generic struct methods", func(t *testing.T) {
+		exported := packagestest.Export(t, packagestest.GOPATH, []packagestest.Module{{
+			Name: "github.com/clear-street",
+			Files: map[string]interface{}{
+				"fake/fake.go": `package fake
+
+type genericService[T any] struct{}
+`,
+				"fake/methods.go": `package fake
+
+func (g *genericService[U]) DoTheThing(value U) (U, error) { return value, nil }
+`,
+			}}})
+		defer exported.Cleanup()
+
+		l := loader.NewLoader(func(cfg *packages.Config, patterns ...string) ([]*packages.Package, error) {
+			exported.Config.Mode = cfg.Mode
+			return packages.Load(exported.Config, patterns...)
+		})
+
+		svc, err := l.LoadOne("github.com/clear-street/fake", "genericService", loader.PackageLoadMode)
+		require.NoError(t, err)
+		require.NotNil(t, svc)
+		require.Equal(t, []jen.Code{jen.Id("T").Add(jen.Id("any"))}, svc.TypeParams)
+		require.Equal(t, []jen.Code{jen.Id("T")}, svc.TypeArgs)
+		require.Equal(t, 1, len(svc.Methods))
+		// The receiver's type parameter is bound to the type parameter of the declaration
+		require.Equal(t, []jen.Code{jen.Id("value").Add(jen.Id("T"))}, svc.Methods[0].ParametersNameAndType)
+	})
+
+	t.Run("Load promoted methods", func(t *testing.T) {
+		exported := packagestest.Export(t, packagestest.GOPATH, []packagestest.Module{{
+			Name: "github.com/clear-street",
+			Files: map[string]interface{}{
+				"fake/fake.go": `package fake
+
+import "io"
+
+type Reader interface {
+	Read(key string) (string, error)
+}
+
+type Store interface {
+	Reader
+	io.Closer
+	Write(key, value string) error
+}
+
+type Base[T any] struct{}
+
+func (b *Base[T]) Get() (T, error) {
+	var t T
+	return t, nil
+}
+
+type helper struct{}
+
+func (h *helper) Ping() error { return nil }
+
+type Cache struct {
+	Base[string]
+	Store
+	*helper
+}
+
+func (c *Cache) Flush() error { return nil }
+`,
+			}}})
+		defer exported.Cleanup()
+
+		l := loader.NewLoader(func(cfg *packages.Config, patterns ...string) ([]*packages.Package, error) {
+			exported.Config.Mode = cfg.Mode
+			return packages.Load(exported.Config, patterns...)
+		})
+
+		promotedFrom := func(res *loader.Result) map[string]string {
+			m := make(map[string]string)
+			for _, mm := range res.Methods {
+				m[mm.Name] = mm.PromotedFrom
+			}
+			return m
+		}
+
+		store, err := l.LoadOne("github.com/clear-street/fake", "Store", loader.PackageLoadMode)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			"Close": "io.Closer",
+			"Read":  "Reader",
+			"Write": "",
+		}, promotedFrom(store))
+		positions := make(map[string]string)
+		for _, mm := range store.Methods {
+			positions[mm.Name] = mm.Position
+		}
+		require.Equal(t, "github.com/clear-street/fake/fake.go:6", positions["Read"])
+		require.Equal(t, "github.com/clear-street/fake/fake.go:12", positions["Write"])
+		require.Contains(t, positions["Close"], "io/io.go:")
+
+		cache, err := l.LoadOne("github.com/clear-street/fake", "Cache", loader.PackageLoadMode)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			"Close": "io.Closer",
+			"Flush": "",
+			"Get":   "Base[string]",
+			"Ping":  "helper",
+			"Read":  "Reader",
+			"Write": "Store",
+		}, promotedFrom(cache))
+		for _, mm := range cache.Methods {
+			if mm.Name == "Get" {
+				// The type argument of the embedded generic type is substituted in the signature
+				require.Equal(t, []jen.Code{jen.Id("string"), jen.Id("error")}, mm.ReturnTypes)
+			}
+		}
+	})
+
+	t.Run("Load directives", func(t *testing.T) {
+		exported := packagestest.Export(t, packagestest.GOPATH, []packagestest.Module{{
+			Name: "github.com/clear-street",
+			Files: map[string]interface{}{
+				"fake/fake.go": `package fake
+
+type Service struct{}
+
+// Submit submits the order
+//
+//reinforcer:noretry
+//reinforcer:runner=payments-write
+func (s *Service) Submit(order string) error { return nil }
+
+//reinforcer:idempotent
+//reinforcer:passthrough
+func (s *Service) Get(id string) (string, error) { return "", nil }
+
+// Debug isn't exposed
+//reinforcer:skip
+func (s *Service) Debug() {}
+`,
+				"fake/invalid.go": `package fake
+
+type Invalid interface {
+	//reinforcer:retry
+	Get(id string) (string, error)
+}
+`,
+			}}})
+		defer exported.Cleanup()
+
+		l := loader.NewLoader(func(cfg *packages.Config, patterns ...string) ([]*packages.Package, error) {
+			exported.Config.Mode = cfg.Mode
+			return packages.Load(exported.Config, patterns...)
+		})
+
+		svc, err := l.LoadOne("github.com/clear-street/fake", "Service", loader.PackageLoadMode)
+		require.NoError(t, err)
+		require.Equal(t, 3, len(svc.Methods))
+
+		debug, get, submit := svc.Methods[0], svc.Methods[1], svc.Methods[2]
+		require.Equal(t, "Debug isn't exposed\n", debug.Doc)
+		require.True(t, debug.Skip)
+		require.Equal(t, "", get.Doc)
+		require.True(t, get.Idempotent)
+		require.True(t, get.PassThrough)
+		require.Equal(t, "Submit submits the order\n", submit.Doc)
+		require.True(t, submit.NoRetry)
+		require.Equal(t, "payments-write", submit.Runner)
+		require.False(t, submit.Idempotent)
+
+		_, err = l.LoadOne("github.com/clear-street/fake", "Invalid", loader.PackageLoadMode)
+		require.EqualError(t, err, "unknown directive=retry for method=Get")
+	})
+
+	t.Run("Load error result annotations", func(t *testing.T) {
+		exported := packagestest.Export(t, packagestest.GOPATH, []packagestest.Module{{
+			Name: "github.com/clear-street",
+			Files: map[string]interface{}{
+				"fake/fake.go": `package fake
+
+type MyErr struct{}
+
+func (e *MyErr) Error() string { return "my error" }
+
+type Service interface {
+	// Validate validates the input
+	//
+	//reinforcer:error=failure
+	Validate(input string) (invalid error, failure *MyErr)
+	Check() (error, bool)
+}
+`,
+			}}})
+		defer exported.Cleanup()
+
+		l := loader.NewLoader(func(cfg *packages.Config, patterns ...string) ([]*packages.Package, error) {
+			exported.Config.Mode = cfg.Mode
+			return packages.Load(exported.Config, patterns...)
+		})
+
+		svc, err := l.LoadOne("github.com/clear-street/fake", "Service", loader.PackageLoadMode)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(svc.Methods))
+
+		// The last result isn't an error so it's a plain value by default
+		require.Equal(t, "Check", svc.Methods[0].Name)
+		require.False(t, svc.Methods[0].ReturnsError)
+
+		validate := svc.Methods[1]
+		require.Equal(t, "Validate validates the input\n", validate.Doc)
+		require.Equal(t, "failure", validate.ErrorResult)
+		require.NoError(t, validate.SelectErrorResult(method.FirstErrorResult))
+		require.Equal(t, 1, *validate.ReturnErrorIndex)
+		require.True(t, validate.CustomErrorType)
+	})
 }
 
 func TestLoadMatched(t *testing.T) {
@@ -322,3 +595,69 @@ type StructWithNoMethods struct {
 		require.Equal(t, "Hello", results["HelloWorldService"].Methods[0].Name)
 	})
 }
+
+func TestLoader_Preload(t *testing.T) {
+	exported := packagestest.Export(t, packagestest.GOPATH, []packagestest.Module{{
+		Name: "github.com/clear-street",
+		Files: map[string]interface{}{
+			"fake/fake.go": `package fake
+
+import "context"
+
+type UserService interface {
+	GetUserID(ctx context.Context, userID string) (string, error)
+}
+`,
+			"fake/other.go": `package fake
+
+import "context"
+
+type HelloWorldService interface {
+	Hello(ctx context.Context, name string) error
+}
+`,
+			"lib/lib.go": `package lib
+
+type LockService interface {
+	Lock()
+	Unlock()
+}
+`,
+		}}})
+	defer exported.Cleanup()
+
+	var calls [][]string
+	l := loader.NewLoader(func(cfg *packages.Config, patterns ...string) ([]*packages.Package, error) {
+		calls = append(calls, patterns)
+		exported.Config.Mode = cfg.Mode
+		return packages.Load(exported.Config, patterns...)
+	})
+
+	fakeFile := exported.File("github.com/clear-street", "fake/fake.go")
+	otherFile := exported.File("github.com/clear-street", "fake/other.go")
+	require.NoError(t, l.Preload([]string{"github.com/clear-street/lib"}, []string{fakeFile, otherFile}))
+	require.Equal(t, 1, len(calls))
+
+	results, err := l.LoadAll(fakeFile, loader.FileLoadMode)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(results))
+	require.NotNil(t, results["UserService"])
+
+	results, err = l.LoadAll(otherFile, loader.FileLoadMode)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(results))
+	require.NotNil(t, results["HelloWorldService"])
+
+	results, err = l.LoadAll("github.com/clear-street/lib", loader.PackageLoadMode)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(results))
+	require.NotNil(t, results["LockService"])
+
+	// Everything was served from the preloaded packages
+	require.Equal(t, 1, len(calls))
+
+	// Paths that weren't preloaded still go through the package loader
+	_, err = l.LoadAll("github.com/clear-street/fake", loader.PackageLoadMode)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(calls))
+}
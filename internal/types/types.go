@@ -3,6 +3,7 @@ package types
 import (
 	"fmt"
 	"go/types"
+	"strings"
 
 	"github.com/dave/jennifer/jen"
 	"github.com/pkg/errors"
@@ -13,6 +14,17 @@ type named interface {
 	Name() string
 }
 
+// alias describes a *types.Alias, the type isn't referenced directly as it's only available since go1.22
+type alias interface {
+	types.Type
+	Obj() *types.TypeName
+}
+
+// genericAlias describes the type arguments of an instantiated *types.Alias, they're only available since go1.23
+type genericAlias interface {
+	TypeArgs() *types.TypeList
+}
+
 // ErrType is the types.Type for the error interface
 var ErrType types.Type
 
@@ -89,12 +101,19 @@ func ToType(t types.Type, variadic bool) (jen.Code, error) {
 
 	switch v := t.(type) {
 	case *types.Basic:
+		if v.Kind() == types.UnsafePointer {
+			return jen.Qual("unsafe", "Pointer"), nil
+		}
 		return jen.Id(v.Name()), nil
 	case *types.Chan:
 		rt, err := ToType(v.Elem(), false)
 		if err != nil {
 			return nil, err
 		}
+		// chan (<-chan T) needs parentheses, otherwise it's parsed as chan<- (chan T)
+		if elem, ok := v.Elem().(*types.Chan); ok && elem.Dir() == types.RecvOnly && v.Dir() != types.RecvOnly {
+			rt = jen.Parens(rt)
+		}
 		switch v.Dir() {
 		case types.SendRecv:
 			return jen.Chan().Add(rt), nil
@@ -105,25 +124,17 @@ func ToType(t types.Type, variadic bool) (jen.Code, error) {
 		}
 	case *types.Named:
 		typeName := v.Obj()
-		if _, ok := v.Underlying().(*types.Interface); ok {
-			if typeName.Pkg() != nil {
-				pkgPath := typeName.Pkg().Path()
-				return jen.Qual(
-					pkgPath,
-					typeName.Name(),
-				), nil
-			}
+		if typeName.Pkg() == nil {
+			// Predeclared types (e.g. error, comparable)
 			return jen.Id(typeName.Name()), nil
 		}
 		pkgPath := typeName.Pkg().Path()
-		var typeArgs []jen.Code
-		for p := 0; p < v.TypeArgs().Len(); p++ {
-			typeArg := v.TypeArgs().At(p)
-			tt, err := ToType(typeArg, false)
-			if err != nil {
-				return nil, errors.Wrapf(err, "failed to convert type %v", typeArg)
-			}
-			typeArgs = append(typeArgs, tt)
+		typeArgs, err := typeArgsToTypes(v.TypeArgs())
+		if err != nil {
+			return nil, err
+		}
+		if len(typeArgs) == 0 {
+			return jen.Qual(pkgPath, typeName.Name()), nil
 		}
 		return jen.Qual(
 			pkgPath,
@@ -136,13 +147,29 @@ func ToType(t types.Type, variadic bool) (jen.Code, error) {
 		}
 		return jen.Op("*").Add(rt), nil
 	case *types.Interface:
-		return jen.Id("any"), nil
+		return interfaceToType(v)
 	case *types.Slice:
 		elemType, err := ToType(v.Elem(), false)
 		if err != nil {
 			return nil, err
 		}
 		return jen.Index().Add(elemType), nil
+	case *types.Array:
+		elemType, err := ToType(v.Elem(), false)
+		if err != nil {
+			return nil, err
+		}
+		return jen.Index(jen.Lit(int(v.Len()))).Add(elemType), nil
+	case *types.Struct:
+		return structToType(v)
+	case *types.Union:
+		return unionToType(v)
+	case *types.Tuple:
+		tupleTypes, err := tupleToTypes(v, false)
+		if err != nil {
+			return nil, err
+		}
+		return jen.List(tupleTypes...), nil
 	case named:
 		return jen.Id(v.Name()), nil
 	case *types.Map:
@@ -156,37 +183,151 @@ func ToType(t types.Type, variadic bool) (jen.Code, error) {
 		}
 		return jen.Map(keyType).Add(elemType), nil
 	case *types.Signature:
-		fnVariadic := v.Variadic()
-		var paramTypes []jen.Code
-		lastIndex := v.Params().Len() - 1
-		for p := 0; p < v.Params().Len(); p++ {
-			paramType := v.Params().At(p).Type()
-			tt, err := ToType(paramType, lastIndex == p && fnVariadic)
+		return signatureToType(jen.Func(), v)
+	case *types.TypeParam:
+		return jen.Id(v.Obj().Name()), nil
+	case alias:
+		// Aliases are referred to by their name rather than by the type they stand for (e.g. any)
+		typeName := v.Obj()
+		var code *jen.Statement
+		if typeName.Pkg() != nil {
+			code = jen.Qual(typeName.Pkg().Path(), typeName.Name())
+		} else {
+			code = jen.Id(typeName.Name())
+		}
+		if generic, ok := v.(genericAlias); ok {
+			typeArgs, err := typeArgsToTypes(generic.TypeArgs())
 			if err != nil {
-				return nil, errors.Wrapf(err, "failed to convert type %v", paramType)
+				return nil, err
+			}
+			if len(typeArgs) > 0 {
+				code = code.Types(typeArgs...)
 			}
-			paramTypes = append(paramTypes, tt)
 		}
+		return code, nil
+	default:
+		return nil, fmt.Errorf("type not handled: %T", v)
+	}
+}
 
-		var returnTypes []jen.Code
-		for r := 0; r < v.Results().Len(); r++ {
-			returnType := v.Results().At(r).Type()
-			tt, err := ToType(returnType, false)
-			if err != nil {
-				return nil, errors.Wrapf(err, "failed to convert type %v", returnType)
+// typeArgsToTypes generates the representation for each of the type arguments of an instantiated type
+func typeArgsToTypes(l *types.TypeList) ([]jen.Code, error) {
+	var typeArgs []jen.Code
+	for p := 0; p < l.Len(); p++ {
+		typeArg := l.At(p)
+		tt, err := ToType(typeArg, false)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to convert type %v", typeArg)
+		}
+		typeArgs = append(typeArgs, tt)
+	}
+	return typeArgs, nil
+}
+
+// tupleToTypes generates the representation for each of the types in the tuple, if variadic is set the last type is
+// represented as a variadic type
+func tupleToTypes(t *types.Tuple, variadic bool) ([]jen.Code, error) {
+	var tupleTypes []jen.Code
+	lastIndex := t.Len() - 1
+	for i := 0; i < t.Len(); i++ {
+		typ := t.At(i).Type()
+		tt, err := ToType(typ, variadic && i == lastIndex)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to convert type %v", typ)
+		}
+		tupleTypes = append(tupleTypes, tt)
+	}
+	return tupleTypes, nil
+}
+
+// structToType generates the representation for a struct literal type "struct{ A int }", tags are kept as-is since
+// they're part of the type's identity
+func structToType(t *types.Struct) (jen.Code, error) {
+	var fields []jen.Code
+	for i := 0; i < t.NumFields(); i++ {
+		field := t.Field(i)
+		fieldType, err := ToType(field.Type(), false)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to convert field %s", field.Name())
+		}
+		var f *jen.Statement
+		if field.Embedded() {
+			f = jen.Add(fieldType)
+		} else {
+			f = jen.Id(field.Name()).Add(fieldType)
+		}
+		if tag := t.Tag(i); tag != "" {
+			if strings.Contains(tag, "`") {
+				f.Lit(tag)
+			} else {
+				f.Id("`" + tag + "`")
 			}
-			returnTypes = append(returnTypes, tt)
 		}
-		if len(returnTypes) == 0 {
-			return jen.Func().Params(paramTypes...), nil
+		fields = append(fields, f)
+	}
+	return jen.Struct(fields...), nil
+}
+
+// unionToType generates the representation for a union of type terms "~int | string"
+func unionToType(t *types.Union) (jen.Code, error) {
+	var terms []jen.Code
+	for i := 0; i < t.Len(); i++ {
+		term := t.Term(i)
+		termType, err := ToType(term.Type(), false)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to convert term %v", term)
 		}
-		if len(returnTypes) > 1 {
-			return jen.Func().Params(paramTypes...).Parens(jen.List(returnTypes...)), nil
+		if term.Tilde() {
+			termType = jen.Op("~").Add(termType)
 		}
-		return jen.Func().Params(paramTypes...).Add(returnTypes[0]), nil
-	case *types.TypeParam:
-		return jen.Id(v.Obj().Name()), nil
-	default:
-		return nil, fmt.Errorf("type not handled: %T", v)
+		terms = append(terms, termType)
+	}
+	return jen.Union(terms...), nil
+}
+
+// signatureToType adds the params and the results of the signature to the given statement, e.g. "func(int) error" when
+// given "func" or "Close() error" when given the name of an interface method
+func signatureToType(s *jen.Statement, t *types.Signature) (jen.Code, error) {
+	paramTypes, err := tupleToTypes(t.Params(), t.Variadic())
+	if err != nil {
+		return nil, err
+	}
+	returnTypes, err := tupleToTypes(t.Results(), false)
+	if err != nil {
+		return nil, err
+	}
+	if len(returnTypes) == 0 {
+		return s.Params(paramTypes...), nil
+	}
+	if len(returnTypes) > 1 {
+		return s.Params(paramTypes...).Parens(jen.List(returnTypes...)), nil
+	}
+	return s.Params(paramTypes...).Add(returnTypes[0]), nil
+}
+
+// interfaceToType generates the representation for an interface literal type "interface{ io.Reader; Close() error }"
+// with its embedded types (including constraint elements such as "~int | string") and its explicit methods, "any" is
+// only used for the empty interface
+func interfaceToType(t *types.Interface) (jen.Code, error) {
+	if t.NumEmbeddeds() == 0 && t.NumExplicitMethods() == 0 {
+		return jen.Id("any"), nil
+	}
+	var elems []jen.Code
+	for i := 0; i < t.NumEmbeddeds(); i++ {
+		embedded := t.EmbeddedType(i)
+		tt, err := ToType(embedded, false)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to convert embedded type %v", embedded)
+		}
+		elems = append(elems, tt)
+	}
+	for i := 0; i < t.NumExplicitMethods(); i++ {
+		meth := t.ExplicitMethod(i)
+		tt, err := signatureToType(jen.Id(meth.Name()), meth.Type().(*types.Signature))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to convert method %s", meth.Name())
+		}
+		elems = append(elems, tt)
 	}
+	return jen.Interface(elems...), nil
 }
@@ -0,0 +1,252 @@
+package types_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	rtypes "github.com/clear-street/reinforcer/internal/types"
+	"github.com/dave/jennifer/jen"
+	"github.com/stretchr/testify/require"
+)
+
+const typesSrc = `package fake
+
+import "unsafe"
+
+type Handler func(name string) error
+
+type Number interface {
+	~int | ~int64 | float64
+}
+
+type Getter[T any] interface {
+	Get() T
+}
+
+type Alias = Handler
+
+var (
+	basic         int
+	unsafePointer unsafe.Pointer
+	array         [32]byte
+	arrayOfArrays [2][4]*int
+	structLit     struct {
+		Handler
+		Name string ` + "`json:\"name\" yaml:\"name\"`" + `
+		age  int
+	}
+	emptyStruct    struct{}
+	chanOfRecvChan chan (<-chan int)
+	sendChan       chan<- []string
+	namedFunc      Handler
+	funcWithTuple  func(int, ...string) (map[string]int, error)
+	funcOfFunc     func(func() bool) func(Handler) [3]int
+	generic        Getter[[]int]
+	alias          Alias
+	emptyIface     interface{}
+	closer         interface{ Close() error }
+	anonIface      interface {
+		Getter[string]
+		error
+		Set(key string, values ...int) (bool, error)
+		Reset()
+	}
+	nestedIface func(interface{ Get() interface{ Len() int } }) interface{}
+)
+
+type Constraint interface {
+	Number
+	~string | ~[]byte
+	String() string
+}
+`
+
+func TestToType(t *testing.T) {
+	pkg := mustCheck(t, typesSrc)
+	lookup := func(name string) types.Type {
+		return pkg.Scope().Lookup(name).Type()
+	}
+
+	// Aliases are only materialized as their own type with gotypesalias=1
+	wantAlias := "var _ fake.Handler"
+	if lookup("alias") != lookup("Handler") {
+		wantAlias = "var _ fake.Alias"
+	}
+
+	tests := []struct {
+		name string
+		typ  types.Type
+		// render places the type in a declaration so it can be formatted, by default "var _ <type>"
+		render func(jen.Code) jen.Code
+		want   string
+	}{
+		{
+			name: "Basic",
+			typ:  lookup("basic"),
+			want: "var _ int",
+		},
+		{
+			name: "Unsafe pointer",
+			typ:  lookup("unsafePointer"),
+			want: "var _ unsafe.Pointer",
+		},
+		{
+			name: "Array",
+			typ:  lookup("array"),
+			want: "var _ [32]byte",
+		},
+		{
+			name: "Array of arrays",
+			typ:  lookup("arrayOfArrays"),
+			want: "var _ [2][4]*int",
+		},
+		{
+			name: "Struct literal",
+			typ:  lookup("structLit"),
+			want: "var _ struct {\n\tfake.Handler\n\tName string `json:\"name\" yaml:\"name\"`\n\tage  int\n}",
+		},
+		{
+			name: "Empty struct",
+			typ:  lookup("emptyStruct"),
+			want: "var _ struct{}",
+		},
+		{
+			name: "Chan of receive-only chan",
+			typ:  lookup("chanOfRecvChan"),
+			want: "var _ chan (<-chan int)",
+		},
+		{
+			name: "Send-only chan",
+			typ:  lookup("sendChan"),
+			want: "var _ chan<- []string",
+		},
+		{
+			name: "Named func type",
+			typ:  lookup("namedFunc"),
+			want: "var _ fake.Handler",
+		},
+		{
+			name: "Func with tuples",
+			typ:  lookup("funcWithTuple"),
+			want: "var _ func(int, ...string) (map[string]int, error)",
+		},
+		{
+			name: "Func of funcs",
+			typ:  lookup("funcOfFunc"),
+			want: "var _ func(func() bool) func(fake.Handler) [3]int",
+		},
+		{
+			name:   "Tuple",
+			typ:    lookup("funcWithTuple").(*types.Signature).Results(),
+			render: func(c jen.Code) jen.Code { return jen.Var().Id("_").Func().Params().Parens(c) },
+			want:   "var _ func() (map[string]int, error)",
+		},
+		{
+			name:   "Union with tilde terms",
+			typ:    lookup("Number").Underlying().(*types.Interface).EmbeddedType(0),
+			render: func(c jen.Code) jen.Code { return jen.Type().Id("_").Interface(c) },
+			want:   "type _ interface {\n\t~int | ~int64 | float64\n}",
+		},
+		{
+			name: "Empty interface",
+			typ:  lookup("emptyIface"),
+			want: "var _ any",
+		},
+		{
+			name: "Anonymous interface",
+			typ:  lookup("closer"),
+			want: "var _ interface {\n\tClose() error\n}",
+		},
+		{
+			name: "Anonymous interface with embedded types",
+			typ:  lookup("anonIface"),
+			want: "var _ interface {\n\tfake.Getter[string]\n\terror\n\tReset()\n\tSet(string, ...int) (bool, error)\n}",
+		},
+		{
+			name: "Nested anonymous interfaces",
+			typ:  lookup("nestedIface"),
+			want: "var _ func(interface {\n\tGet() interface {\n\t\tLen() int\n\t}\n}) any",
+		},
+		{
+			name:   "Constraint interface",
+			typ:    lookup("Constraint").Underlying(),
+			render: func(c jen.Code) jen.Code { return jen.Type().Id("_").Add(c) },
+			want:   "type _ interface {\n\tfake.Number\n\t~string | ~[]byte\n\tString() string\n}",
+		},
+		{
+			name: "Instantiated generic interface",
+			typ:  lookup("generic"),
+			want: "var _ fake.Getter[[]int]",
+		},
+		{
+			name: "Alias",
+			typ:  lookup("alias"),
+			want: wantAlias,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rtypes.ToType(tt.typ, false)
+			require.NoError(t, err)
+			render := tt.render
+			if render == nil {
+				render = func(c jen.Code) jen.Code { return jen.Var().Id("_").Add(c) }
+			}
+			require.Equal(t, tt.want, fmt.Sprintf("%#v", render(got)))
+		})
+	}
+}
+
+func TestToType_GenericAlias(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "fake.go", `package fake
+
+type Getter[T any] interface {
+	Get() T
+}
+
+type GetterAlias[T any] = Getter[T]
+
+type List[T any] = []T
+
+var (
+	getter GetterAlias[string]
+	list   List[*int]
+)
+`, parser.ParseComments)
+	require.NoError(t, err)
+	cfg := &types.Config{Importer: importer.Default()}
+	pkg, err := cfg.Check("github.com/clear-street/fake", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Skipf("generic aliases aren't supported by this toolchain; error=%v", err)
+	}
+	if _, ok := pkg.Scope().Lookup("list").Type().(*types.Slice); ok {
+		t.Skip("aliases are only materialized as their own type with gotypesalias=1")
+	}
+
+	for name, want := range map[string]string{
+		"getter": "var _ fake.GetterAlias[string]",
+		"list":   "var _ fake.List[*int]",
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := rtypes.ToType(pkg.Scope().Lookup(name).Type(), false)
+			require.NoError(t, err)
+			require.Equal(t, want, fmt.Sprintf("%#v", jen.Var().Id("_").Add(got)))
+		})
+	}
+}
+
+func mustCheck(t *testing.T, src string) *types.Package {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "fake.go", src, parser.ParseComments)
+	require.NoError(t, err)
+	cfg := &types.Config{Importer: importer.Default()}
+	pkg, err := cfg.Check("github.com/clear-street/fake", fset, []*ast.File{f}, nil)
+	require.NoError(t, err)
+	return pkg
+}
@@ -0,0 +1,191 @@
+// Package logging logs the retries, the non-retryable errors, the rejections and the recovered panics of the calls to
+// the types generated by reinforcer with a slog.Handler. The generated code only uses this package when it's generated
+// with --logging.
+package logging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"github.com/clear-street/reinforcer/pkg/runner"
+	"github.com/slok/goresilience"
+)
+
+// Attributes of the records
+const (
+	// TypeKey is the generated type that was called
+	TypeKey = "type"
+	// MethodKey is the method that was called
+	MethodKey = "method"
+	// AttemptKey is the number of the attempt, starting at 1
+	AttemptKey = "attempt"
+	// ErrorKey is the error of the event (e.g. the error of the previous attempt of a retry)
+	ErrorKey = "error"
+	// RejectedByKey is the middleware that rejected the call (e.g. timeout or circuitbreaker), see runner.Rejection
+	RejectedByKey = "rejected_by"
+	// StackKey is the stack trace of a recovered panic
+	StackKey = "stack"
+)
+
+// Messages of the records
+const (
+	// RetryMessage is the message of the records of the retries
+	RetryMessage = "retrying call"
+	// NonRetryableMessage is the message of the records of the errors classified as non-retryable
+	NonRetryableMessage = "non-retryable error"
+	// RejectionMessage is the message of the records of the calls rejected by a middleware
+	RejectionMessage = "call rejected"
+	// PanicMessage is the message of the records of the panics recovered from the delegates
+	PanicMessage = "recovered panic"
+)
+
+// callKey is the context key of the call being logged
+type callKey struct{}
+
+// Config configures the levels of the records and their sampling
+type Config struct {
+	// RetryLevel is the level of the retries, defaults to slog.LevelInfo
+	RetryLevel slog.Leveler
+	// NonRetryableLevel is the level of the errors classified as non-retryable, defaults to slog.LevelInfo
+	NonRetryableLevel slog.Leveler
+	// RejectionLevel is the level of the calls rejected by a middleware, defaults to slog.LevelWarn
+	RejectionLevel slog.Leveler
+	// PanicLevel is the level of the panics recovered from the delegates, defaults to slog.LevelError
+	PanicLevel slog.Leveler
+	// SampleEvery logs one of every SampleEvery records of the same kind for a method, starting with the first one. Every
+	// record is logged when it's 0 or 1, the recovered panics are never sampled.
+	SampleEvery uint64
+}
+
+// Logger logs the events of the calls to the generated types, a nil Logger logs nothing
+type Logger struct {
+	logger *slog.Logger
+	cfg    Config
+	// counters holds the number of records of every kind and method for the sampling
+	counters sync.Map
+}
+
+// NewLogger creates a Logger that hands its records to the given handler, the handler decides which levels are enabled
+func NewLogger(h slog.Handler, cfg Config) *Logger {
+	if cfg.RetryLevel == nil {
+		cfg.RetryLevel = slog.LevelInfo
+	}
+	if cfg.NonRetryableLevel == nil {
+		cfg.NonRetryableLevel = slog.LevelInfo
+	}
+	if cfg.RejectionLevel == nil {
+		cfg.RejectionLevel = slog.LevelWarn
+	}
+	if cfg.PanicLevel == nil {
+		cfg.PanicLevel = slog.LevelError
+	}
+	return &Logger{
+		logger: slog.New(h),
+		cfg:    cfg,
+	}
+}
+
+// call is a call being logged, it's shared by the attempts of the call
+type call struct {
+	logger   *Logger
+	typeName string
+	method   string
+
+	mu       sync.Mutex
+	attempts int
+	lastErr  error
+}
+
+// Runner wraps the given runner so that the retries and the rejections of the calls to the given method are logged, the
+// context handed to the function carries the call so that NonRetryable and Panic can log the events of the attempts.
+// The runner is returned as-is when the logger is nil.
+func (l *Logger) Runner(typeName, method string, r goresilience.Runner) goresilience.Runner {
+	if l == nil {
+		return r
+	}
+	return goresilience.RunnerFunc(func(ctx context.Context, fn goresilience.Func) error {
+		c := &call{
+			logger:   l,
+			typeName: typeName,
+			method:   method,
+		}
+		ctx = context.WithValue(ctx, callKey{}, c)
+		err := r.Run(ctx, func(ctx context.Context) error {
+			c.mu.Lock()
+			c.attempts++
+			attempt, lastErr := c.attempts, c.lastErr
+			c.mu.Unlock()
+			if attempt > 1 {
+				c.log(ctx, "retry", l.cfg.RetryLevel, RetryMessage, slog.Int(AttemptKey, attempt), errAttr(lastErr))
+			}
+
+			err := fn(ctx)
+			if err != nil && !errors.Is(err, runner.ErrHedgeLost) {
+				c.mu.Lock()
+				c.lastErr = err
+				c.mu.Unlock()
+			}
+			return err
+		})
+		if rejection := runner.Rejection(err); rejection != "" {
+			c.log(ctx, "rejection", l.cfg.RejectionLevel, RejectionMessage, slog.String(RejectedByKey, rejection), errAttr(err))
+		}
+		return err
+	})
+}
+
+// NonRetryable logs the error classified as non-retryable of the call carried by the given context, nil errors are
+// ignored
+func NonRetryable(ctx context.Context, err error) {
+	c, ok := ctx.Value(callKey{}).(*call)
+	if !ok || err == nil {
+		return
+	}
+	c.log(ctx, "nonretryable", c.logger.cfg.NonRetryableLevel, NonRetryableMessage, errAttr(err))
+}
+
+// Panic logs a panic recovered from the delegate of the call carried by the given context along with its stack trace,
+// the recovered panics are never sampled
+func Panic(ctx context.Context, err error, stack []byte) {
+	c, ok := ctx.Value(callKey{}).(*call)
+	if !ok {
+		return
+	}
+	c.logger.logger.LogAttrs(ctx, c.logger.cfg.PanicLevel.Level(), PanicMessage,
+		slog.String(TypeKey, c.typeName),
+		slog.String(MethodKey, c.method),
+		errAttr(err),
+		slog.String(StackKey, string(stack)),
+	)
+}
+
+// log logs a record of the call unless it's sampled out
+func (c *call) log(ctx context.Context, kind string, level slog.Leveler, msg string, attrs ...slog.Attr) {
+	if !c.logger.logger.Enabled(ctx, level.Level()) || !c.logger.sample(kind, c.typeName, c.method) {
+		return
+	}
+	c.logger.logger.LogAttrs(ctx, level.Level(), msg, append([]slog.Attr{
+		slog.String(TypeKey, c.typeName),
+		slog.String(MethodKey, c.method),
+	}, attrs...)...)
+}
+
+// sample determines whether the next record of the given kind for the given method is logged
+func (l *Logger) sample(kind, typeName, method string) bool {
+	if l.cfg.SampleEvery <= 1 {
+		return true
+	}
+	counter, _ := l.counters.LoadOrStore(kind+":"+runner.QualifiedName(typeName, method), new(uint64))
+	return (atomic.AddUint64(counter.(*uint64), 1)-1)%l.cfg.SampleEvery == 0
+}
+
+// errAttr is the attribute of the given error, the attribute is empty (and thus ignored) for nil errors
+func errAttr(err error) slog.Attr {
+	if err == nil {
+		return slog.Attr{}
+	}
+	return slog.Any(ErrorKey, err)
+}
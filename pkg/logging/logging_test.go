@@ -0,0 +1,149 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/clear-street/reinforcer/pkg/logging"
+	"github.com/slok/goresilience"
+	"github.com/slok/goresilience/circuitbreaker"
+	"github.com/slok/goresilience/retry"
+	"github.com/stretchr/testify/require"
+)
+
+// newLogger creates a logger whose records are written as JSON to the returned buffer
+func newLogger(level slog.Level, cfg logging.Config) (*logging.Logger, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	h := slog.NewJSONHandler(buf, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	return logging.NewLogger(h, cfg), buf
+}
+
+func records(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var got []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		record := make(map[string]interface{})
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		got = append(got, record)
+	}
+	return got
+}
+
+func TestLogger_Runner(t *testing.T) {
+	failing := func(ctx context.Context) error {
+		return fmt.Errorf("failure")
+	}
+
+	t.Run("Retries", func(t *testing.T) {
+		l, buf := newLogger(slog.LevelInfo, logging.Config{})
+		r := l.Runner("Client", "GetUser", retry.New(retry.Config{Times: 2, DisableBackoff: true}))
+		calls := 0
+		require.NoError(t, r.Run(context.Background(), func(ctx context.Context) error {
+			calls++
+			if calls < 3 {
+				return fmt.Errorf("failure %d", calls)
+			}
+			return nil
+		}))
+		require.Equal(t, []map[string]interface{}{
+			{"level": "INFO", "msg": logging.RetryMessage, "type": "Client", "method": "GetUser", "attempt": float64(2), "error": "failure 1"},
+			{"level": "INFO", "msg": logging.RetryMessage, "type": "Client", "method": "GetUser", "attempt": float64(3), "error": "failure 2"},
+		}, records(t, buf))
+	})
+
+	t.Run("Non-retryable errors", func(t *testing.T) {
+		l, buf := newLogger(slog.LevelInfo, logging.Config{NonRetryableLevel: slog.LevelWarn})
+		r := l.Runner("Client", "GetUser", retry.New(retry.Config{Times: 2, DisableBackoff: true}))
+		require.NoError(t, r.Run(context.Background(), func(ctx context.Context) error {
+			logging.NonRetryable(ctx, fmt.Errorf("not found"))
+			return nil
+		}))
+		require.Equal(t, []map[string]interface{}{
+			{"level": "WARN", "msg": logging.NonRetryableMessage, "type": "Client", "method": "GetUser", "error": "not found"},
+		}, records(t, buf))
+	})
+
+	t.Run("Rejections", func(t *testing.T) {
+		l, buf := newLogger(slog.LevelInfo, logging.Config{})
+		r := l.Runner("Client", "GetUser", circuitbreaker.New(circuitbreaker.Config{
+			MinimumRequestToOpen:        1,
+			ErrorPercentThresholdToOpen: 1,
+		}))
+		require.Error(t, r.Run(context.Background(), failing))
+		require.Error(t, r.Run(context.Background(), failing))
+		got := records(t, buf)
+		require.Len(t, got, 1)
+		require.Equal(t, "WARN", got[0]["level"])
+		require.Equal(t, logging.RejectionMessage, got[0]["msg"])
+		require.Equal(t, "circuitbreaker", got[0]["rejected_by"])
+	})
+
+	t.Run("Panics", func(t *testing.T) {
+		l, buf := newLogger(slog.LevelInfo, logging.Config{SampleEvery: 10})
+		r := l.Runner("Client", "GetUser", goresilience.RunnerChain())
+		for i := 0; i < 2; i++ {
+			require.NoError(t, r.Run(context.Background(), func(ctx context.Context) error {
+				logging.Panic(ctx, fmt.Errorf("boom"), []byte("goroutine 1 [running]:"))
+				return nil
+			}))
+		}
+		require.Equal(t, []map[string]interface{}{
+			{"level": "ERROR", "msg": logging.PanicMessage, "type": "Client", "method": "GetUser", "error": "boom", "stack": "goroutine 1 [running]:"},
+			{"level": "ERROR", "msg": logging.PanicMessage, "type": "Client", "method": "GetUser", "error": "boom", "stack": "goroutine 1 [running]:"},
+		}, records(t, buf))
+	})
+
+	t.Run("Sampling", func(t *testing.T) {
+		l, buf := newLogger(slog.LevelInfo, logging.Config{SampleEvery: 2})
+		getUser := l.Runner("Client", "GetUser", goresilience.RunnerChain())
+		listUsers := l.Runner("Client", "ListUsers", goresilience.RunnerChain())
+		for i := 1; i <= 3; i++ {
+			err := fmt.Errorf("not found %d", i)
+			for _, r := range []goresilience.Runner{getUser, listUsers} {
+				require.NoError(t, r.Run(context.Background(), func(ctx context.Context) error {
+					logging.NonRetryable(ctx, err)
+					return nil
+				}))
+			}
+		}
+		var got []string
+		for _, record := range records(t, buf) {
+			got = append(got, fmt.Sprintf("%s %s", record["method"], record["error"]))
+		}
+		require.Equal(t, []string{"GetUser not found 1", "ListUsers not found 1", "GetUser not found 3", "ListUsers not found 3"}, got)
+	})
+
+	t.Run("Levels", func(t *testing.T) {
+		l, buf := newLogger(slog.LevelWarn, logging.Config{})
+		r := l.Runner("Client", "GetUser", retry.New(retry.Config{Times: 1, DisableBackoff: true}))
+		require.Error(t, r.Run(context.Background(), failing))
+		require.Empty(t, buf.String())
+	})
+
+	t.Run("Nil logger", func(t *testing.T) {
+		var l *logging.Logger
+		r := goresilience.RunnerChain()
+		require.Equal(t, r, l.Runner("Client", "GetUser", r))
+	})
+}
+
+func TestNonRetryable(t *testing.T) {
+	// Calls that aren't logged are ignored
+	logging.NonRetryable(context.Background(), fmt.Errorf("not found"))
+	logging.Panic(context.Background(), fmt.Errorf("boom"), nil)
+}
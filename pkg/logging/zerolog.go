@@ -0,0 +1,126 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/rs/zerolog"
+)
+
+// ZerologHandler is a slog.Handler that writes the records with a zerolog.Logger, the groups are flattened into the
+// keys of their attributes (e.g. request.id)
+type ZerologHandler struct {
+	logger zerolog.Logger
+	attrs  []slog.Attr
+	group  string
+}
+
+// NewZerologHandler creates a ZerologHandler that writes the records with the given logger, the records are filtered by
+// the level of the logger and the global level of zerolog
+func NewZerologHandler(logger zerolog.Logger) *ZerologHandler {
+	return &ZerologHandler{
+		logger: logger,
+	}
+}
+
+// Enabled reports whether the logger writes the records of the given level
+func (h *ZerologHandler) Enabled(_ context.Context, level slog.Level) bool {
+	zlevel := zerologLevel(level)
+	return zlevel >= h.logger.GetLevel() && zlevel >= zerolog.GlobalLevel()
+}
+
+// Handle writes the record with the logger
+func (h *ZerologHandler) Handle(_ context.Context, r slog.Record) error {
+	e := h.logger.WithLevel(zerologLevel(r.Level))
+	if e == nil {
+		return nil
+	}
+	for _, a := range h.attrs {
+		addAttr(e, "", a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(e, h.group, a)
+		return true
+	})
+	e.Msg(r.Message)
+	return nil
+}
+
+// WithAttrs creates a handler that adds the given attributes to every record
+func (h *ZerologHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handler := *h
+	handler.attrs = make([]slog.Attr, 0, len(h.attrs)+len(attrs))
+	handler.attrs = append(handler.attrs, h.attrs...)
+	for _, a := range attrs {
+		if h.group != "" {
+			a.Key = h.group + a.Key
+		}
+		handler.attrs = append(handler.attrs, a)
+	}
+	return &handler
+}
+
+// WithGroup creates a handler that prefixes the keys of the subsequent attributes with the given group
+func (h *ZerologHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	handler := *h
+	handler.group = h.group + name + "."
+	return &handler
+}
+
+// addAttr adds the given attribute to the event, the key is prefixed with the given group
+func addAttr(e *zerolog.Event, group string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	key := group + a.Key
+	switch a.Value.Kind() {
+	case slog.KindString:
+		e.Str(key, a.Value.String())
+	case slog.KindInt64:
+		e.Int64(key, a.Value.Int64())
+	case slog.KindUint64:
+		e.Uint64(key, a.Value.Uint64())
+	case slog.KindFloat64:
+		e.Float64(key, a.Value.Float64())
+	case slog.KindBool:
+		e.Bool(key, a.Value.Bool())
+	case slog.KindDuration:
+		e.Dur(key, a.Value.Duration())
+	case slog.KindTime:
+		e.Time(key, a.Value.Time())
+	case slog.KindGroup:
+		prefix := group
+		if a.Key != "" {
+			prefix = key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			addAttr(e, prefix, ga)
+		}
+	default:
+		if err, ok := a.Value.Any().(error); ok {
+			e.AnErr(key, err)
+			return
+		}
+		e.Interface(key, a.Value.Any())
+	}
+}
+
+// zerologLevel maps the level of a record to the level of zerolog
+func zerologLevel(level slog.Level) zerolog.Level {
+	switch {
+	case level >= slog.LevelError:
+		return zerolog.ErrorLevel
+	case level >= slog.LevelWarn:
+		return zerolog.WarnLevel
+	case level >= slog.LevelInfo:
+		return zerolog.InfoLevel
+	case level >= slog.LevelDebug:
+		return zerolog.DebugLevel
+	default:
+		return zerolog.TraceLevel
+	}
+}
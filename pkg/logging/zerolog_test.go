@@ -0,0 +1,67 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/clear-street/reinforcer/pkg/logging"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestZerologHandler(t *testing.T) {
+	t.Run("Attributes", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		logger := slog.New(logging.NewZerologHandler(zerolog.New(buf))).With("service", "users").WithGroup("request")
+		logger.Warn("call rejected",
+			slog.String("id", "abc"),
+			slog.Int("attempt", 2),
+			slog.Bool("retried", true),
+			slog.Duration("elapsed", time.Second),
+			slog.Any("error", fmt.Errorf("failure")),
+			slog.Group("user", slog.String("name", "jane")),
+		)
+		require.JSONEq(t, `{
+	"level": "warn",
+	"message": "call rejected",
+	"service": "users",
+	"request.id": "abc",
+	"request.attempt": 2,
+	"request.retried": true,
+	"request.elapsed": 1000,
+	"request.error": "failure",
+	"request.user.name": "jane"
+}`, buf.String())
+	})
+
+	t.Run("Levels", func(t *testing.T) {
+		tests := []struct {
+			level slog.Level
+			want  string
+		}{
+			{level: slog.LevelDebug - 1, want: "trace"},
+			{level: slog.LevelDebug, want: "debug"},
+			{level: slog.LevelInfo, want: "info"},
+			{level: slog.LevelWarn, want: "warn"},
+			{level: slog.LevelError, want: "error"},
+		}
+		for _, tt := range tests {
+			buf := &bytes.Buffer{}
+			slog.New(logging.NewZerologHandler(zerolog.New(buf))).Log(context.Background(), tt.level, "msg")
+			require.JSONEq(t, fmt.Sprintf(`{"level": %q, "message": "msg"}`, tt.want), buf.String())
+		}
+	})
+
+	t.Run("Disabled levels", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		h := logging.NewZerologHandler(zerolog.New(buf).Level(zerolog.WarnLevel))
+		require.False(t, h.Enabled(context.Background(), slog.LevelInfo))
+		require.True(t, h.Enabled(context.Background(), slog.LevelWarn))
+		slog.New(h).Info("msg")
+		require.Empty(t, buf.String())
+	})
+}
@@ -0,0 +1,133 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/slok/goresilience"
+)
+
+// defaultHedgeAttempts is the number of concurrent attempts of a hedged call when none is configured
+const defaultHedgeAttempts = 2
+
+// ErrHedgeLost is returned by the attempts of a hedged call that completed after another attempt claimed the call
+var ErrHedgeLost = errors.New("hedged attempt lost to another attempt")
+
+// abandoned is the winner of the hedged calls that returned before any of their attempts claimed them
+var abandoned = &hedgedAttempt{}
+
+// hedgeKey is the context key of the attempt of a hedged call
+type hedgeKey struct{}
+
+// HedgeConfig configures the hedge middleware
+type HedgeConfig struct {
+	// Delay is the time waited for an attempt to complete before firing the next attempt
+	Delay time.Duration
+	// Attempts is the maximum number of concurrent attempts of a call, defaults to 2
+	Attempts int
+}
+
+// hedgedCall holds the attempt that claimed a hedged call
+type hedgedCall struct {
+	mu     sync.Mutex
+	winner *hedgedAttempt
+}
+
+// hedgedAttempt is an attempt of a hedged call
+type hedgedAttempt struct {
+	call *hedgedCall
+	err  error
+}
+
+// claim makes the attempt the winner of the call unless another attempt already claimed it
+func (a *hedgedAttempt) claim() bool {
+	a.call.mu.Lock()
+	defer a.call.mu.Unlock()
+	if a.call.winner == nil {
+		a.call.winner = a
+	}
+	return a.call.winner == a
+}
+
+// abandon claims the call on behalf of none of its attempts so that the attempts still running lose the call, it
+// returns false when an attempt already claimed the call
+func (c *hedgedCall) abandon() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.winner == nil {
+		c.winner = abandoned
+	}
+	return c.winner == abandoned
+}
+
+// NewHedgeMiddleware creates a middleware that hedges the calls: when an attempt hasn't completed after the configured
+// delay another attempt is fired concurrently, up to the configured number of attempts. The first attempt to complete
+// wins, its error is returned and the context of the other attempts is cancelled. When the context of the call is done
+// before an attempt claimed the call the attempts still running lose the call and the error of the context is returned.
+//
+// The attempts run concurrently so they must not write to shared variables, the code generated with --hedging claims
+// the call with ClaimAttempt before handing out the results of an attempt.
+func NewHedgeMiddleware(cfg HedgeConfig) goresilience.Middleware {
+	if cfg.Attempts <= 0 {
+		cfg.Attempts = defaultHedgeAttempts
+	}
+	return func(next goresilience.Runner) goresilience.Runner {
+		next = goresilience.SanitizeRunner(next)
+		return goresilience.RunnerFunc(func(ctx context.Context, f goresilience.Func) error {
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			call := &hedgedCall{}
+			// Buffered so that the losing attempts don't block once the call returned
+			completed := make(chan *hedgedAttempt, cfg.Attempts)
+			fire := func() {
+				attempt := &hedgedAttempt{call: call}
+				go func() {
+					attempt.err = next.Run(context.WithValue(ctx, hedgeKey{}, attempt), f)
+					completed <- attempt
+				}()
+			}
+
+			fire()
+			fired := 1
+			timer := time.NewTimer(cfg.Delay)
+			defer timer.Stop()
+			done := ctx.Done()
+			for {
+				select {
+				case attempt := <-completed:
+					// The attempt wins unless another attempt claimed the call, in which case the winner completes soon
+					if attempt.claim() {
+						return attempt.err
+					}
+				case <-timer.C:
+					if fired < cfg.Attempts {
+						fire()
+						fired++
+						timer.Reset(cfg.Delay)
+					}
+				case <-done:
+					// The attempts still running must not hand out their results once the call returned, unless an attempt
+					// already claimed the call in which case it completes soon
+					if call.abandon() {
+						return ctx.Err()
+					}
+					done = nil
+				}
+			}
+		})
+	}
+}
+
+// ClaimAttempt claims the hedged call (see NewHedgeMiddleware) that the given context belongs to, it returns false when
+// another attempt already claimed the call and the results of the attempt must be discarded. It always returns true for
+// calls that aren't hedged.
+func ClaimAttempt(ctx context.Context) bool {
+	attempt, ok := ctx.Value(hedgeKey{}).(*hedgedAttempt)
+	if !ok {
+		return true
+	}
+	return attempt.claim()
+}
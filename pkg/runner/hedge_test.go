@@ -0,0 +1,113 @@
+package runner_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/clear-street/reinforcer/pkg/runner"
+	"github.com/slok/goresilience"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHedgeMiddleware(t *testing.T) {
+	newRunner := func(cfg runner.HedgeConfig) goresilience.Runner {
+		return goresilience.RunnerChain(runner.NewHedgeMiddleware(cfg))
+	}
+
+	t.Run("Fast calls aren't hedged", func(t *testing.T) {
+		var calls int32
+		err := newRunner(runner.HedgeConfig{Delay: time.Second}).Run(context.Background(), func(ctx context.Context) error {
+			atomic.AddInt32(&calls, 1)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("First attempt to complete wins", func(t *testing.T) {
+		var calls int32
+		cancelled := make(chan struct{})
+		err := newRunner(runner.HedgeConfig{Delay: 10 * time.Millisecond}).Run(context.Background(), func(ctx context.Context) error {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				// The first attempt hangs until it's cancelled by the second attempt completing
+				<-ctx.Done()
+				close(cancelled)
+				return ctx.Err()
+			}
+			return fmt.Errorf("second attempt")
+		})
+		require.EqualError(t, err, "second attempt")
+		select {
+		case <-cancelled:
+		case <-time.After(time.Second):
+			t.Fatal("the losing attempt wasn't cancelled")
+		}
+	})
+
+	t.Run("Attempts are limited", func(t *testing.T) {
+		var calls int32
+		err := newRunner(runner.HedgeConfig{Delay: time.Millisecond, Attempts: 3}).Run(context.Background(), func(ctx context.Context) error {
+			atomic.AddInt32(&calls, 1)
+			time.Sleep(50 * time.Millisecond)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("Attempt that claimed the call wins", func(t *testing.T) {
+		var calls int32
+		claimed := make(chan struct{})
+		err := newRunner(runner.HedgeConfig{Delay: time.Millisecond}).Run(context.Background(), func(ctx context.Context) error {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				require.True(t, runner.ClaimAttempt(ctx))
+				close(claimed)
+				// Completes after the second attempt but it claimed the call first
+				time.Sleep(20 * time.Millisecond)
+				return fmt.Errorf("first attempt")
+			}
+			<-claimed
+			require.False(t, runner.ClaimAttempt(ctx))
+			return runner.ErrHedgeLost
+		})
+		require.EqualError(t, err, "first attempt")
+	})
+
+	t.Run("Attempts lose the call once its context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		// The attempts write to a shared result once they claimed the call like the code generated with --hedging
+		var result string
+		var lost int32
+		completed := make(chan struct{}, 2)
+		go func() {
+			time.Sleep(5 * time.Millisecond)
+			cancel()
+		}()
+		err := newRunner(runner.HedgeConfig{Delay: time.Millisecond}).Run(ctx, func(ctx context.Context) error {
+			defer func() {
+				completed <- struct{}{}
+			}()
+			// The attempts ignore the context like the delegates that don't receive one
+			time.Sleep(20 * time.Millisecond)
+			if !runner.ClaimAttempt(ctx) {
+				atomic.AddInt32(&lost, 1)
+				return runner.ErrHedgeLost
+			}
+			result = "attempt"
+			return nil
+		})
+		require.ErrorIs(t, err, context.Canceled)
+		require.Empty(t, result)
+		for i := 0; i < 2; i++ {
+			<-completed
+		}
+		require.Equal(t, int32(2), atomic.LoadInt32(&lost))
+	})
+}
+
+func TestClaimAttempt(t *testing.T) {
+	require.True(t, runner.ClaimAttempt(context.Background()))
+}
@@ -0,0 +1,75 @@
+package runner
+
+import (
+	"context"
+
+	"github.com/slok/goresilience"
+)
+
+// skipRetriesKey is the context key that flags the calls whose retries must be skipped
+type skipRetriesKey struct{}
+
+// Retry marks the given middleware as a retry middleware, retry middlewares are skipped in the runners of the methods
+// that aren't idempotent when the factory gates retries (see Factory.WithIdempotentMethods). The retry middlewares
+// built from a policy are already marked.
+func Retry(m goresilience.Middleware) goresilience.Middleware {
+	return func(next goresilience.Runner) goresilience.Runner {
+		retrying := m(next)
+		return goresilience.RunnerFunc(func(ctx context.Context, f goresilience.Func) error {
+			if skip, _ := ctx.Value(skipRetriesKey{}).(bool); skip {
+				return next.Run(ctx, f)
+			}
+			return retrying.Run(ctx, f)
+		})
+	}
+}
+
+// WithIdempotentMethods enables the gating of retries, the retry middlewares (see Retry) only run for the given
+// idempotent methods while the other middlewares (e.g. timeouts and circuit breakers) still run for every method. The
+// names are either qualified runner names (e.g. Client.GetUser), bare method names (e.g. GetUser) to match the method
+// in every type or the names given with the //reinforcer:runner directive, the generated XxxIdempotentRunnerNames
+// functions return the runner names of the idempotent methods of a generated type. Calling it again replaces the
+// idempotent methods and any runner already created is discarded. This is thread-safe and returns the factory to allow
+// chaining.
+//
+// Only the retry middlewares marked with Retry are skipped, the factory can't tell the other retry middlewares apart
+// from the rest of the chain: a retry.NewMiddleware given to NewFactory, WithTypeMiddlewares or WithMethodMiddlewares
+// without being wrapped in Retry still retries the methods that aren't idempotent.
+func (f *Factory) WithIdempotentMethods(names ...string) *Factory {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.idempotent = make(map[string]struct{}, len(names))
+	for _, name := range names {
+		f.idempotent[name] = struct{}{}
+	}
+	f.discard(func(string) bool {
+		return true
+	})
+	return f
+}
+
+// skipsRetries determines if the retries of the runner with the given name must be skipped. The caller must hold the
+// lock.
+func (f *Factory) skipsRetries(name string) bool {
+	if f.idempotent == nil {
+		return false
+	}
+	if _, ok := f.idempotent[name]; ok {
+		return false
+	}
+	_, method := SplitName(name)
+	_, ok := f.idempotent[method]
+	return !ok
+}
+
+// SkipRetries flags the calls to the given runner so that its retry middlewares (see Retry) are skipped while the other
+// middlewares still run, the flag is cleared before calling the function so that it doesn't leak to nested runners. The
+// generated code skips the retries of the methods annotated with //reinforcer:noretry with it.
+func SkipRetries(r goresilience.Runner) goresilience.Runner {
+	return goresilience.RunnerFunc(func(ctx context.Context, fn goresilience.Func) error {
+		return r.Run(context.WithValue(ctx, skipRetriesKey{}, true), func(ctx context.Context) error {
+			return fn(context.WithValue(ctx, skipRetriesKey{}, false))
+		})
+	})
+}
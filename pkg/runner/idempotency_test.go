@@ -0,0 +1,76 @@
+package runner_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/clear-street/reinforcer/pkg/runner"
+	"github.com/slok/goresilience"
+	"github.com/slok/goresilience/retry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFactory_WithIdempotentMethods(t *testing.T) {
+	calls := 0
+	failing := func(ctx context.Context) error {
+		calls++
+		return fmt.Errorf("failure")
+	}
+	callsFor := func(f *runner.Factory, name string) int {
+		calls = 0
+		require.Error(t, f.GetRunner(name).Run(context.Background(), failing))
+		return calls
+	}
+	newRetry := func() goresilience.Middleware {
+		return runner.Retry(retry.NewMiddleware(retry.Config{Times: 2, DisableBackoff: true}))
+	}
+
+	t.Run("Retries every method by default", func(t *testing.T) {
+		f := runner.NewFactory(newRetry())
+		require.Equal(t, 3, callsFor(f, "Client.SubmitOrder"))
+	})
+
+	t.Run("Only retries idempotent methods", func(t *testing.T) {
+		f := runner.NewFactory(newRetry()).WithIdempotentMethods("Client.GetUser", "ListUsers", "payments-read")
+		require.Equal(t, 3, callsFor(f, "Client.GetUser"))
+		require.Equal(t, 3, callsFor(f, "Store.ListUsers"))
+		require.Equal(t, 3, callsFor(f, "payments-read"))
+		require.Equal(t, 1, callsFor(f, "Client.SubmitOrder"))
+		require.Equal(t, 1, callsFor(f, "Store.GetUser"))
+	})
+
+	t.Run("Other middlewares still run", func(t *testing.T) {
+		ran := 0
+		counting := func(next goresilience.Runner) goresilience.Runner {
+			return goresilience.RunnerFunc(func(ctx context.Context, fn goresilience.Func) error {
+				ran++
+				return next.Run(ctx, fn)
+			})
+		}
+		f := runner.NewFactory(counting, newRetry()).WithIdempotentMethods()
+		require.Equal(t, 1, callsFor(f, "Client.SubmitOrder"))
+		require.Equal(t, 1, ran)
+	})
+
+	t.Run("Policy retries are gated", func(t *testing.T) {
+		f, err := runner.NewFactoryFromPolicy(mustParsePolicy(t, "default:\n  retry:\n    times: 2\n    disableBackoff: true\n"))
+		require.NoError(t, err)
+		f.WithIdempotentMethods("Client.GetUser")
+		require.Equal(t, 3, callsFor(f, "Client.GetUser"))
+		require.Equal(t, 1, callsFor(f, "Client.SubmitOrder"))
+	})
+
+	t.Run("Gating doesn't leak to nested runners", func(t *testing.T) {
+		f := runner.NewFactory(newRetry()).WithIdempotentMethods("Store.Get")
+		nested := 0
+		err := f.GetRunner("Client.SubmitOrder").Run(context.Background(), func(ctx context.Context) error {
+			return f.GetRunner("Store.Get").Run(ctx, func(ctx context.Context) error {
+				nested++
+				return fmt.Errorf("failure")
+			})
+		})
+		require.Error(t, err)
+		require.Equal(t, 3, nested)
+	})
+}
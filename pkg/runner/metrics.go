@@ -0,0 +1,157 @@
+package runner
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/slok/goresilience"
+	"github.com/slok/goresilience/metrics"
+)
+
+// Outcomes of a call
+const (
+	// SuccessOutcome is the outcome of the calls that succeeded
+	SuccessOutcome = "success"
+	// ErrorOutcome is the outcome of the calls that failed once the middlewares gave up (e.g. the retries are exhausted)
+	ErrorOutcome = "error"
+	// NonRetryableOutcome is the outcome of the calls whose error was classified as non-retryable
+	NonRetryableOutcome = "non_retryable"
+	// RejectedOutcome is the outcome of the calls rejected by a middleware (e.g. the circuit breaker is open)
+	RejectedOutcome = "rejected"
+)
+
+// Recorder records the metrics of the calls made through the runners of a Factory. The metrics are labeled with the
+// type and the method of the runner name (see SplitName), which are the generated type and the name of the method in
+// the generated XxxMethods, the type is empty for the runners named with the //reinforcer:runner directive.
+// Implementations must be safe for concurrent use.
+type Recorder interface {
+	// ObserveCall records the latency of a call through the middlewares and its outcome (success, error, non_retryable or
+	// rejected), the calls are only known to be non-retryable when their errors are recorded with RecordNonRetryable
+	ObserveCall(typeName, method string, duration time.Duration, outcome string)
+	// IncAttempt records an attempt of a call, every call makes at least one attempt unless it's rejected
+	IncAttempt(typeName, method string)
+	// IncRetry records a retry of a call by the retry middleware
+	IncRetry(typeName, method string)
+	// IncNonRetryable records a call whose error was classified as non-retryable by the error predicate, see
+	// RecordNonRetryable
+	IncNonRetryable(typeName, method string)
+	// IncCircuitBreakerTransition records a transition of the circuit breaker to the given state (open, half-open or
+	// closed)
+	IncCircuitBreakerTransition(typeName, method, state string)
+}
+
+// WithRecorder records the metrics of the calls made through the runners with the given recorder, the latency and the
+// outcome of every call, its attempts, the retries and the transitions of the circuit breakers. Any runner already
+// created is discarded so that subsequent calls to GetRunner record the metrics. This is thread-safe and returns the
+// factory to allow chaining.
+func (f *Factory) WithRecorder(rec Recorder) *Factory {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.recorder = rec
+	f.discard(func(string) bool {
+		return true
+	})
+	return f
+}
+
+// recordedCallKey is the context key of the call being recorded
+type recordedCallKey struct{}
+
+// recordedCall flags the call whose error was classified as non-retryable, the attempt returns nil to the middlewares
+type recordedCall struct {
+	mu           sync.Mutex
+	nonRetryable bool
+}
+
+// RecordNonRetryable flags the call carried by the given context as non-retryable, the middlewares never see the errors
+// classified as non-retryable as they're returned to the caller as-is. The call is recorded with the
+// NonRetryableOutcome by the recorder of the runner and its error is counted. It's meant to be the non-retryable error
+// handler of the generated types, for example:
+//
+//	reinforced.WithNonRetryableErrorHandler(runner.RecordNonRetryable)
+//
+// Nil errors and the calls that aren't recorded are ignored.
+func RecordNonRetryable(ctx context.Context, _ string, err error) {
+	call, ok := ctx.Value(recordedCallKey{}).(*recordedCall)
+	if !ok || err == nil {
+		return
+	}
+	call.mu.Lock()
+	call.nonRetryable = true
+	call.mu.Unlock()
+}
+
+// recordMetrics chains the given middlewares so that the calls and their attempts are recorded, the middlewares report
+// the retries and the transitions of the circuit breakers through the goresilience recorder set in the context by the
+// metrics middleware
+func recordMetrics(rec Recorder, name string, middlewares []goresilience.Middleware) goresilience.Runner {
+	typeName, method := SplitName(name)
+	attempts := func(next goresilience.Runner) goresilience.Runner {
+		next = goresilience.SanitizeRunner(next)
+		return goresilience.RunnerFunc(func(ctx context.Context, fn goresilience.Func) error {
+			rec.IncAttempt(typeName, method)
+			return next.Run(ctx, fn)
+		})
+	}
+	resilienceRec := &resilienceRecorder{
+		Recorder: metrics.Dummy,
+		rec:      rec,
+		typeName: typeName,
+		method:   method,
+	}
+	chain := make([]goresilience.Middleware, 0, len(middlewares)+2)
+	chain = append(chain, metrics.NewMiddleware(name, resilienceRec))
+	chain = append(chain, middlewares...)
+	r := goresilience.RunnerChain(append(chain, attempts)...)
+	return goresilience.RunnerFunc(func(ctx context.Context, fn goresilience.Func) error {
+		call := &recordedCall{}
+		start := time.Now()
+		err := r.Run(context.WithValue(ctx, recordedCallKey{}, call), fn)
+		call.mu.Lock()
+		nonRetryable := call.nonRetryable
+		call.mu.Unlock()
+		if nonRetryable {
+			rec.IncNonRetryable(typeName, method)
+		}
+		rec.ObserveCall(typeName, method, time.Since(start), outcome(err, nonRetryable))
+		return err
+	})
+}
+
+// outcome determines the outcome of a call from the error returned by the middlewares and whether the error of the call
+// was classified as non-retryable
+func outcome(err error, nonRetryable bool) string {
+	switch {
+	case err != nil && Rejection(err) != "":
+		return RejectedOutcome
+	case err != nil:
+		return ErrorOutcome
+	case nonRetryable:
+		return NonRetryableOutcome
+	default:
+		return SuccessOutcome
+	}
+}
+
+// resilienceRecorder adapts a Recorder to the recorder used by the goresilience middlewares, only the retries and the
+// transitions of the circuit breaker are recorded
+type resilienceRecorder struct {
+	metrics.Recorder
+	rec      Recorder
+	typeName string
+	method   string
+}
+
+func (r *resilienceRecorder) WithID(string) metrics.Recorder {
+	return r
+}
+
+func (r *resilienceRecorder) IncRetry() {
+	r.rec.IncRetry(r.typeName, r.method)
+}
+
+func (r *resilienceRecorder) IncCircuitbreakerState(state string) {
+	r.rec.IncCircuitBreakerTransition(r.typeName, r.method, state)
+}
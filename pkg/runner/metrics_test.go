@@ -0,0 +1,195 @@
+package runner_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/clear-street/reinforcer/pkg/runner"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/slok/goresilience/circuitbreaker"
+	"github.com/slok/goresilience/retry"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRecorder records the metrics as "type.method" keyed counters
+type fakeRecorder struct {
+	mu           sync.Mutex
+	outcomes     map[string][]string
+	attempts     map[string]int
+	retries      map[string]int
+	nonRetryable map[string]int
+	transitions  map[string][]string
+}
+
+func newFakeRecorder() *fakeRecorder {
+	return &fakeRecorder{
+		outcomes:     make(map[string][]string),
+		attempts:     make(map[string]int),
+		retries:      make(map[string]int),
+		nonRetryable: make(map[string]int),
+		transitions:  make(map[string][]string),
+	}
+}
+
+func (r *fakeRecorder) ObserveCall(typeName, method string, _ time.Duration, outcome string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name := runner.QualifiedName(typeName, method)
+	r.outcomes[name] = append(r.outcomes[name], outcome)
+}
+
+func (r *fakeRecorder) IncAttempt(typeName, method string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts[runner.QualifiedName(typeName, method)]++
+}
+
+func (r *fakeRecorder) IncRetry(typeName, method string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.retries[runner.QualifiedName(typeName, method)]++
+}
+
+func (r *fakeRecorder) IncNonRetryable(typeName, method string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nonRetryable[runner.QualifiedName(typeName, method)]++
+}
+
+func (r *fakeRecorder) IncCircuitBreakerTransition(typeName, method, state string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name := runner.QualifiedName(typeName, method)
+	r.transitions[name] = append(r.transitions[name], state)
+}
+
+func TestFactory_WithRecorder(t *testing.T) {
+	name := runner.QualifiedName("Client", "GetUser")
+	failing := func(ctx context.Context) error {
+		return fmt.Errorf("failure")
+	}
+
+	t.Run("Attempts and retries", func(t *testing.T) {
+		rec := newFakeRecorder()
+		f := runner.NewFactory(retry.NewMiddleware(retry.Config{Times: 2, DisableBackoff: true})).WithRecorder(rec)
+
+		calls := 0
+		require.NoError(t, f.GetRunner(name).Run(context.Background(), func(ctx context.Context) error {
+			calls++
+			if calls < 3 {
+				return fmt.Errorf("failure")
+			}
+			return nil
+		}))
+		require.Error(t, f.GetRunner(name).Run(context.Background(), failing))
+
+		require.Equal(t, []string{runner.SuccessOutcome, runner.ErrorOutcome}, rec.outcomes[name])
+		require.Equal(t, 6, rec.attempts[name])
+		require.Equal(t, 4, rec.retries[name])
+	})
+
+	t.Run("Circuit breaker transitions", func(t *testing.T) {
+		rec := newFakeRecorder()
+		f := runner.NewFactory(circuitbreaker.NewMiddleware(circuitbreaker.Config{
+			MinimumRequestToOpen:        1,
+			ErrorPercentThresholdToOpen: 1,
+		})).WithRecorder(rec)
+
+		require.Error(t, f.GetRunner(name).Run(context.Background(), failing))
+		require.Error(t, f.GetRunner(name).Run(context.Background(), failing))
+
+		require.Equal(t, []string{runner.ErrorOutcome, runner.RejectedOutcome}, rec.outcomes[name])
+		require.Equal(t, 1, rec.attempts[name])
+		require.Contains(t, rec.transitions[name], "open")
+	})
+
+	t.Run("Discards previously created runners", func(t *testing.T) {
+		rec := newFakeRecorder()
+		f := runner.NewFactory()
+		noop := func(ctx context.Context) error {
+			return nil
+		}
+
+		require.NoError(t, f.GetRunner(name).Run(context.Background(), noop))
+		f.WithRecorder(rec)
+		require.NoError(t, f.GetRunner(name).Run(context.Background(), noop))
+		require.Equal(t, []string{runner.SuccessOutcome}, rec.outcomes[name])
+	})
+
+	t.Run("Runner directive names have no type", func(t *testing.T) {
+		rec := newFakeRecorder()
+		f := runner.NewFactory().WithRecorder(rec)
+
+		require.NoError(t, f.GetRunner("Batch").Run(context.Background(), func(ctx context.Context) error {
+			return nil
+		}))
+		require.Equal(t, 1, rec.attempts["Batch"])
+	})
+}
+
+func TestRecordNonRetryable(t *testing.T) {
+	name := runner.QualifiedName("Client", "GetUser")
+	rec := newFakeRecorder()
+	f := runner.NewFactory(retry.NewMiddleware(retry.Config{Times: 2, DisableBackoff: true})).WithRecorder(rec)
+
+	// The attempts hand the non-retryable errors to the handler and return nil to the middlewares
+	require.NoError(t, f.GetRunner(name).Run(context.Background(), func(ctx context.Context) error {
+		runner.RecordNonRetryable(ctx, "GetUser", fmt.Errorf("not found"))
+		return nil
+	}))
+	require.NoError(t, f.GetRunner(name).Run(context.Background(), func(ctx context.Context) error {
+		runner.RecordNonRetryable(ctx, "GetUser", nil)
+		return nil
+	}))
+
+	require.Equal(t, []string{runner.NonRetryableOutcome, runner.SuccessOutcome}, rec.outcomes[name])
+	require.Equal(t, 1, rec.nonRetryable[name])
+	require.Equal(t, 2, rec.attempts[name])
+
+	t.Run("Calls that aren't recorded are ignored", func(t *testing.T) {
+		require.NotPanics(t, func() {
+			runner.RecordNonRetryable(context.Background(), "GetUser", fmt.Errorf("not found"))
+		})
+	})
+}
+
+func TestPrometheusRecorder(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	rec, err := runner.NewPrometheusRecorder(reg)
+	require.NoError(t, err)
+
+	f := runner.NewFactory(retry.NewMiddleware(retry.Config{Times: 1, DisableBackoff: true})).WithRecorder(rec)
+	require.Error(t, f.GetRunner(runner.QualifiedName("Client", "GetUser")).Run(context.Background(), func(ctx context.Context) error {
+		return fmt.Errorf("failure")
+	}))
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	got := make(map[string]float64)
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			labels := ""
+			for _, l := range m.GetLabel() {
+				labels += fmt.Sprintf(",%s=%s", l.GetName(), l.GetValue())
+			}
+			value := m.GetCounter().GetValue()
+			if h := m.GetHistogram(); h != nil {
+				value = float64(h.GetSampleCount())
+			}
+			got[family.GetName()+labels] = value
+		}
+	}
+	require.Equal(t, map[string]float64{
+		"reinforcer_attempts_total,method=GetUser,type=Client":                      2,
+		"reinforcer_call_duration_seconds,method=GetUser,outcome=error,type=Client": 1,
+		"reinforcer_retries_total,method=GetUser,type=Client":                       1,
+	}, got)
+
+	t.Run("Metrics are registered once", func(t *testing.T) {
+		_, err := runner.NewPrometheusRecorder(reg)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,380 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/slok/goresilience"
+	"github.com/slok/goresilience/bulkhead"
+	"github.com/slok/goresilience/circuitbreaker"
+	"github.com/slok/goresilience/concurrencylimit"
+	"github.com/slok/goresilience/concurrencylimit/execute"
+	"github.com/slok/goresilience/concurrencylimit/limit"
+	"github.com/slok/goresilience/retry"
+	"github.com/slok/goresilience/timeout"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// FIFOQueue executes the calls waiting for a concurrency slot in first-in-first-out order
+	FIFOQueue = "fifo"
+	// LIFOQueue executes the calls waiting for a concurrency slot in last-in-first-out order
+	LIFOQueue = "lifo"
+)
+
+// Duration is a time.Duration that is decoded from a duration string such as "100ms" or "1m30s"
+type Duration time.Duration
+
+// UnmarshalYAML decodes the duration from its string representation
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	var s string
+	if err := value.Decode(&s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q at line %d; error=%w", s, value.Line, err)
+	}
+	if parsed < 0 {
+		return fmt.Errorf("negative duration %q at line %d", s, value.Line)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// RetryPolicy configures the retry middleware, see retry.Config
+type RetryPolicy struct {
+	WaitBase       Duration `yaml:"waitBase"`
+	DisableBackoff bool     `yaml:"disableBackoff"`
+	Times          int      `yaml:"times"`
+}
+
+// TimeoutPolicy configures the timeout middleware, see timeout.Config
+type TimeoutPolicy struct {
+	Timeout Duration `yaml:"timeout"`
+}
+
+// HedgePolicy configures the hedge middleware, see HedgeConfig
+type HedgePolicy struct {
+	Delay    Duration `yaml:"delay"`
+	Attempts int      `yaml:"attempts"`
+}
+
+// CircuitBreakerPolicy configures the circuit breaker middleware, see circuitbreaker.Config
+type CircuitBreakerPolicy struct {
+	ErrorPercentThresholdToOpen        int      `yaml:"errorPercentThresholdToOpen"`
+	MinimumRequestToOpen               int      `yaml:"minimumRequestToOpen"`
+	SuccessfulRequiredOnHalfOpen       int      `yaml:"successfulRequiredOnHalfOpen"`
+	WaitDurationInOpenState            Duration `yaml:"waitDurationInOpenState"`
+	MetricsSlidingWindowBucketQuantity int      `yaml:"metricsSlidingWindowBucketQuantity"`
+	MetricsBucketDuration              Duration `yaml:"metricsBucketDuration"`
+}
+
+// BulkheadPolicy configures the bulkhead middleware, see bulkhead.Config
+type BulkheadPolicy struct {
+	Workers     int      `yaml:"workers"`
+	MaxWaitTime Duration `yaml:"maxWaitTime"`
+}
+
+// ConcurrencyLimitPolicy configures the concurrency limit middleware, see concurrencylimit.Config
+type ConcurrencyLimitPolicy struct {
+	// Limit is a static limit of concurrent executions, when unset the limit is adapted with the AIMD algorithm
+	Limit int `yaml:"limit"`
+	// MinimumLimit is the limit the AIMD algorithm starts with and won't decrease below
+	MinimumLimit int `yaml:"minimumLimit"`
+	// RTTTimeout is the execution time after which the AIMD algorithm considers the execution a failure
+	RTTTimeout Duration `yaml:"rttTimeout"`
+	// Queue is the order in which the executions waiting for a slot are executed: fifo (default) or lifo
+	Queue string `yaml:"queue"`
+	// MaxWaitTime is the maximum time an execution waits for a slot before being rejected
+	MaxWaitTime Duration `yaml:"maxWaitTime"`
+}
+
+// MiddlewarePolicy describes the middlewares of a runner, the middlewares are chained in the order of the fields (the
+// circuit breaker is the outermost middleware and the timeout the innermost one).
+type MiddlewarePolicy struct {
+	CircuitBreaker   *CircuitBreakerPolicy   `yaml:"circuitbreaker"`
+	Bulkhead         *BulkheadPolicy         `yaml:"bulkhead"`
+	ConcurrencyLimit *ConcurrencyLimitPolicy `yaml:"concurrencylimit"`
+	Retry            *RetryPolicy            `yaml:"retry"`
+	Hedge            *HedgePolicy            `yaml:"hedge"`
+	Timeout          *TimeoutPolicy          `yaml:"timeout"`
+}
+
+// Rule applies a MiddlewarePolicy to the runners with a matching name
+type Rule struct {
+	// Match is the expression matched against the runner names. A qualified name (e.g. Client.GetUser) or one with
+	// wildcards (e.g. Client.*) is matched against the whole runner name, a bare name (e.g. Get*) is matched against the
+	// method name of any type. An expression wrapped in slashes (e.g. /^Client\.(Get|List).*$/) is a regular expression
+	// matched against the whole runner name.
+	Match string `yaml:"match"`
+
+	MiddlewarePolicy `yaml:",inline"`
+
+	regex *regexp.Regexp
+}
+
+// Policy is a declarative description of the middlewares used by the runners of a Factory
+type Policy struct {
+	// Default is used for the runners that don't match any rule
+	Default *MiddlewarePolicy `yaml:"default"`
+	// Rules are evaluated in order, the first rule matching a runner name determines the runner's middlewares
+	Rules []*Rule `yaml:"rules"`
+}
+
+// LoadPolicyFile reads and parses the YAML or JSON policy document at the given path
+func LoadPolicyFile(filename string) (*Policy, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file %s; error=%w", filename, err)
+	}
+	p, err := ParsePolicy(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy file %s; error=%w", filename, err)
+	}
+	return p, nil
+}
+
+// ParsePolicy parses and validates a YAML or JSON policy document, unknown fields are rejected
+func ParsePolicy(data []byte) (*Policy, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	p := &Policy{}
+	if err := dec.Decode(p); err != nil {
+		return nil, fmt.Errorf("failed to decode policy; error=%w", err)
+	}
+	if err := p.compile(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// NewFactoryFromPolicy creates a runner factory whose runners are built from the given policy
+func NewFactoryFromPolicy(p *Policy) (*Factory, error) {
+	if err := p.compile(); err != nil {
+		return nil, err
+	}
+	f := NewFactory()
+	f.policy = p
+	return f, nil
+}
+
+// Validate cross-checks the policy against the runner names that exist (see MethodNames), an error is returned if a
+// rule doesn't match any of them as it likely refers to a method that was renamed or removed.
+func (p *Policy) Validate(names ...string) error {
+	if err := p.compile(); err != nil {
+		return err
+	}
+	var unknown []string
+	for _, r := range p.Rules {
+		matched := false
+		for _, name := range names {
+			if r.matches(name) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			unknown = append(unknown, r.Match)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("policy rules don't match any known method: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// MethodNames returns the qualified runner names for the given type and generated methods descriptor, e.g.
+// MethodNames("Client", reinforced.ClientMethods).
+func MethodNames(typeName string, methods interface{}) []string {
+	v := reflect.Indirect(reflect.ValueOf(methods))
+	if v.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("expected the generated methods struct, got=%T", methods))
+	}
+	var names []string
+	for i := 0; i < v.NumField(); i++ {
+		if field := v.Field(i); field.Kind() == reflect.String {
+			names = append(names, QualifiedName(typeName, field.String()))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// middlewaresFor builds the middlewares for the given runner name, ok is false when neither a rule nor a default
+// matches. The middlewares are created on every call so that stateful middlewares aren't shared between runners.
+func (p *Policy) middlewaresFor(name string) (middlewares []goresilience.Middleware, ok bool) {
+	if m := p.policyFor(name); m != nil {
+		return m.middlewares(), true
+	}
+	return nil, false
+}
+
+// policyFor returns the middleware policy that applies to the given runner name, nil if there's none
+func (p *Policy) policyFor(name string) *MiddlewarePolicy {
+	if p == nil {
+		return nil
+	}
+	for _, r := range p.Rules {
+		if r.matches(name) {
+			return &r.MiddlewarePolicy
+		}
+	}
+	return p.Default
+}
+
+// compile validates the policy and prepares the rules for matching
+func (p *Policy) compile() error {
+	if p.Default != nil {
+		if err := p.Default.validate(); err != nil {
+			return fmt.Errorf("invalid default policy; error=%w", err)
+		}
+	}
+	for idx, r := range p.Rules {
+		if r == nil || r.Match == "" {
+			return fmt.Errorf("rule %d must have a match expression", idx)
+		}
+		if err := r.compile(); err != nil {
+			return fmt.Errorf("invalid rule %q; error=%w", r.Match, err)
+		}
+	}
+	return nil
+}
+
+func (r *Rule) compile() error {
+	expr := r.Match
+	switch {
+	case len(expr) > 1 && strings.HasPrefix(expr, "/") && strings.HasSuffix(expr, "/"):
+		expr = expr[1 : len(expr)-1]
+	case strings.Contains(expr, nameSeparator):
+		expr = globToRegex(expr)
+	default:
+		// Bare method names match the method of any type
+		expr = "^(.*\\" + nameSeparator + ")?" + strings.TrimPrefix(globToRegex(expr), "^")
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return err
+	}
+	r.regex = re
+	return r.validate()
+}
+
+func (r *Rule) matches(name string) bool {
+	return r.regex.MatchString(name)
+}
+
+func globToRegex(glob string) string {
+	if _, err := path.Match(glob, ""); err != nil {
+		// Not a valid glob, treat it as a literal
+		return "^" + regexp.QuoteMeta(glob) + "$"
+	}
+	b := &strings.Builder{}
+	b.WriteString("^")
+	for _, c := range glob {
+		switch c {
+		case '*':
+			b.WriteString("[^" + regexp.QuoteMeta(nameSeparator) + "]*")
+		case '?':
+			b.WriteString("[^" + regexp.QuoteMeta(nameSeparator) + "]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+func (m *MiddlewarePolicy) validate() error {
+	if m.Retry != nil && m.Retry.Times < 0 {
+		return fmt.Errorf("retry times must not be negative")
+	}
+	if m.Hedge != nil && m.Hedge.Attempts < 0 {
+		return fmt.Errorf("hedge attempts must not be negative")
+	}
+	if m.Bulkhead != nil && m.Bulkhead.Workers < 0 {
+		return fmt.Errorf("bulkhead workers must not be negative")
+	}
+	if m.CircuitBreaker != nil && (m.CircuitBreaker.ErrorPercentThresholdToOpen < 0 || m.CircuitBreaker.ErrorPercentThresholdToOpen > 100) {
+		return fmt.Errorf("circuit breaker error percent threshold must be between 0 and 100")
+	}
+	if cl := m.ConcurrencyLimit; cl != nil {
+		if cl.Limit < 0 || cl.MinimumLimit < 0 {
+			return fmt.Errorf("concurrency limits must not be negative")
+		}
+		switch cl.Queue {
+		case "", FIFOQueue, LIFOQueue:
+		default:
+			return fmt.Errorf("unknown concurrency limit queue %q, expected %s or %s", cl.Queue, FIFOQueue, LIFOQueue)
+		}
+	}
+	return nil
+}
+
+func (m *MiddlewarePolicy) middlewares() []goresilience.Middleware {
+	var middlewares []goresilience.Middleware
+	if cb := m.CircuitBreaker; cb != nil {
+		middlewares = append(middlewares, circuitbreaker.NewMiddleware(circuitbreaker.Config{
+			ErrorPercentThresholdToOpen:        cb.ErrorPercentThresholdToOpen,
+			MinimumRequestToOpen:               cb.MinimumRequestToOpen,
+			SuccessfulRequiredOnHalfOpen:       cb.SuccessfulRequiredOnHalfOpen,
+			WaitDurationInOpenState:            time.Duration(cb.WaitDurationInOpenState),
+			MetricsSlidingWindowBucketQuantity: cb.MetricsSlidingWindowBucketQuantity,
+			MetricsBucketDuration:              time.Duration(cb.MetricsBucketDuration),
+		}))
+	}
+	if bh := m.Bulkhead; bh != nil {
+		middlewares = append(middlewares, bulkhead.NewMiddleware(bulkhead.Config{
+			Workers:     bh.Workers,
+			MaxWaitTime: time.Duration(bh.MaxWaitTime),
+		}))
+	}
+	if cl := m.ConcurrencyLimit; cl != nil {
+		var limiter limit.Limiter
+		if cl.Limit > 0 {
+			limiter = limit.NewStatic(cl.Limit)
+		} else {
+			limiter = limit.NewAIMD(limit.AIMDConfig{
+				MinimumLimit: cl.MinimumLimit,
+				RTTTimeout:   time.Duration(cl.RTTTimeout),
+			})
+		}
+		var executor execute.Executor
+		if cl.Queue == LIFOQueue {
+			executor = execute.NewLIFO(execute.LIFOConfig{MaxWaitTime: time.Duration(cl.MaxWaitTime)})
+		} else {
+			executor = execute.NewFIFO(execute.FIFOConfig{MaxWaitTime: time.Duration(cl.MaxWaitTime)})
+		}
+		middlewares = append(middlewares, concurrencylimit.NewMiddleware(concurrencylimit.Config{
+			Limiter:  limiter,
+			Executor: executor,
+		}))
+	}
+	if r := m.Retry; r != nil {
+		middlewares = append(middlewares, Retry(retry.NewMiddleware(retry.Config{
+			WaitBase:       time.Duration(r.WaitBase),
+			DisableBackoff: r.DisableBackoff,
+			Times:          r.Times,
+		})))
+	}
+	if h := m.Hedge; h != nil {
+		middlewares = append(middlewares, NewHedgeMiddleware(HedgeConfig{
+			Delay:    time.Duration(h.Delay),
+			Attempts: h.Attempts,
+		}))
+	}
+	if t := m.Timeout; t != nil {
+		middlewares = append(middlewares, timeout.NewMiddleware(timeout.Config{
+			Timeout: time.Duration(t.Timeout),
+		}))
+	}
+	return middlewares
+}
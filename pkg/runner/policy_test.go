@@ -0,0 +1,157 @@
+package runner_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/clear-street/reinforcer/pkg/runner"
+	"github.com/slok/goresilience/errors"
+	"github.com/stretchr/testify/require"
+)
+
+var testMethods = struct {
+	GetUser  string
+	SaveFile string
+}{
+	GetUser:  "GetUser",
+	SaveFile: "SaveFile",
+}
+
+func TestParsePolicy(t *testing.T) {
+	t.Run("YAML", func(t *testing.T) {
+		p, err := runner.ParsePolicy([]byte(`
+default:
+  timeout:
+    timeout: 100ms
+rules:
+  - match: Client.SaveFile
+    timeout:
+      timeout: 30s
+  - match: "Client.*"
+    retry:
+      times: 3
+      waitBase: 20ms
+    circuitbreaker:
+      errorPercentThresholdToOpen: 50
+      waitDurationInOpenState: 5s
+  - match: /^Service\.(Get|List).*$/
+    bulkhead:
+      workers: 10
+    concurrencylimit:
+      limit: 5
+      queue: lifo
+    hedge:
+      delay: 15ms
+      attempts: 3
+`))
+		require.NoError(t, err)
+		require.Equal(t, runner.Duration(100*time.Millisecond), p.Default.Timeout.Timeout)
+		require.Len(t, p.Rules, 3)
+		require.Equal(t, runner.Duration(30*time.Second), p.Rules[0].Timeout.Timeout)
+		require.Equal(t, 3, p.Rules[1].Retry.Times)
+		require.Equal(t, 50, p.Rules[1].CircuitBreaker.ErrorPercentThresholdToOpen)
+		require.Equal(t, runner.LIFOQueue, p.Rules[2].ConcurrencyLimit.Queue)
+		require.Equal(t, runner.Duration(15*time.Millisecond), p.Rules[2].Hedge.Delay)
+		require.Equal(t, 3, p.Rules[2].Hedge.Attempts)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		p, err := runner.ParsePolicy([]byte(`{"rules": [{"match": "GetUser", "retry": {"times": 2, "disableBackoff": true}}]}`))
+		require.NoError(t, err)
+		require.Nil(t, p.Default)
+		require.Len(t, p.Rules, 1)
+		require.Equal(t, 2, p.Rules[0].Retry.Times)
+		require.True(t, p.Rules[0].Retry.DisableBackoff)
+	})
+
+	t.Run("Invalid documents", func(t *testing.T) {
+		for name, doc := range map[string]string{
+			"unknown field":    "rules:\n  - match: Get\n    retries:\n      times: 1\n",
+			"missing match":    "rules:\n  - retry:\n      times: 1\n",
+			"invalid duration": "default:\n  timeout:\n    timeout: soon\n",
+			"invalid regex":    "rules:\n  - match: /(/\n",
+			"unknown queue":    "rules:\n  - match: Get\n    concurrencylimit:\n      queue: random\n",
+			"negative retries": "rules:\n  - match: Get\n    retry:\n      times: -1\n",
+			"negative hedges":  "rules:\n  - match: Get\n    hedge:\n      attempts: -1\n",
+		} {
+			_, err := runner.ParsePolicy([]byte(doc))
+			require.Error(t, err, name)
+		}
+	})
+}
+
+func TestLoadPolicyFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(filename, []byte("rules:\n  - match: Client.*\n    retry:\n      times: 1\n"), 0600))
+
+	p, err := runner.LoadPolicyFile(filename)
+	require.NoError(t, err)
+	require.Len(t, p.Rules, 1)
+
+	_, err = runner.LoadPolicyFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+}
+
+func TestNewFactoryFromPolicy(t *testing.T) {
+	p, err := runner.ParsePolicy([]byte(`
+default:
+  timeout:
+    timeout: 10ms
+rules:
+  - match: Client.SaveFile
+    timeout:
+      timeout: 1s
+  - match: Get*
+    retry:
+      times: 2
+      waitBase: 1ms
+      disableBackoff: true
+`))
+	require.NoError(t, err)
+	f, err := runner.NewFactoryFromPolicy(p)
+	require.NoError(t, err)
+
+	slow := func(ctx context.Context) error {
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}
+	require.NoError(t, f.GetRunner("Client.SaveFile").Run(context.Background(), slow))
+	require.ErrorIs(t, f.GetRunner("Client.Delete").Run(context.Background(), slow), errors.ErrTimeout)
+
+	for _, name := range []string{"Client.GetUser", "Service.GetData"} {
+		calls := 0
+		err = f.GetRunner(name).Run(context.Background(), func(ctx context.Context) error {
+			calls++
+			return fmt.Errorf("failure")
+		})
+		require.Error(t, err)
+		require.Equal(t, 3, calls, name)
+	}
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	names := append(runner.MethodNames("Client", testMethods), runner.MethodNames("Service", &testMethods)...)
+	require.Equal(t, []string{"Client.GetUser", "Client.SaveFile", "Service.GetUser", "Service.SaveFile"}, names)
+
+	p, err := runner.ParsePolicy([]byte(`
+rules:
+  - match: Client.SaveFile
+  - match: GetUser
+  - match: /^Service\..*$/
+`))
+	require.NoError(t, err)
+	require.NoError(t, p.Validate(names...))
+
+	p, err = runner.ParsePolicy([]byte(`
+rules:
+  - match: Client.SaveFile
+  - match: Client.GetUsers
+  - match: Store.*
+`))
+	require.NoError(t, err)
+	require.EqualError(t, p.Validate(names...), "policy rules don't match any known method: Client.GetUsers, Store.*")
+}
@@ -0,0 +1,92 @@
+package runner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// prometheusNamespace is the namespace of the Prometheus metrics
+const prometheusNamespace = "reinforcer"
+
+// PrometheusRecorder is a Recorder that records the metrics with Prometheus
+type PrometheusRecorder struct {
+	calls              *prometheus.HistogramVec
+	attempts           *prometheus.CounterVec
+	retries            *prometheus.CounterVec
+	nonRetryable       *prometheus.CounterVec
+	breakerTransitions *prometheus.CounterVec
+}
+
+// NewPrometheusRecorder creates a PrometheusRecorder whose metrics are registered with the given registerer, the
+// metrics are labeled with the type and the method of the calls:
+//
+//   - reinforcer_call_duration_seconds is the latency of the calls, also labeled with their outcome
+//   - reinforcer_attempts_total is the number of attempts
+//   - reinforcer_retries_total is the number of retries
+//   - reinforcer_non_retryable_errors_total is the number of errors classified as non-retryable
+//   - reinforcer_circuitbreaker_transitions_total is the number of transitions of the circuit breakers, also labeled
+//     with the state the circuit breaker moved to
+func NewPrometheusRecorder(registerer prometheus.Registerer) (*PrometheusRecorder, error) {
+	labels := []string{"type", "method"}
+	r := &PrometheusRecorder{
+		calls: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: prometheusNamespace,
+			Name:      "call_duration_seconds",
+			Help:      "The latency of the calls through the middlewares.",
+			Buckets:   prometheus.DefBuckets,
+		}, append(labels, "outcome")),
+		attempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prometheusNamespace,
+			Name:      "attempts_total",
+			Help:      "The number of attempts of the calls.",
+		}, labels),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prometheusNamespace,
+			Name:      "retries_total",
+			Help:      "The number of retries of the calls.",
+		}, labels),
+		nonRetryable: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prometheusNamespace,
+			Name:      "non_retryable_errors_total",
+			Help:      "The number of errors classified as non-retryable.",
+		}, labels),
+		breakerTransitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prometheusNamespace,
+			Name:      "circuitbreaker_transitions_total",
+			Help:      "The number of transitions of the circuit breakers.",
+		}, append(labels, "state")),
+	}
+	for _, c := range []prometheus.Collector{r.calls, r.attempts, r.retries, r.nonRetryable, r.breakerTransitions} {
+		if err := registerer.Register(c); err != nil {
+			return nil, fmt.Errorf("failed to register the metrics; error=%w", err)
+		}
+	}
+	return r, nil
+}
+
+// ObserveCall records the latency of a call and its outcome
+func (r *PrometheusRecorder) ObserveCall(typeName, method string, duration time.Duration, outcome string) {
+	r.calls.WithLabelValues(typeName, method, outcome).Observe(duration.Seconds())
+}
+
+// IncAttempt records an attempt of a call
+func (r *PrometheusRecorder) IncAttempt(typeName, method string) {
+	r.attempts.WithLabelValues(typeName, method).Inc()
+}
+
+// IncRetry records a retry of a call
+func (r *PrometheusRecorder) IncRetry(typeName, method string) {
+	r.retries.WithLabelValues(typeName, method).Inc()
+}
+
+// IncNonRetryable records an error classified as non-retryable
+func (r *PrometheusRecorder) IncNonRetryable(typeName, method string) {
+	r.nonRetryable.WithLabelValues(typeName, method).Inc()
+}
+
+// IncCircuitBreakerTransition records a transition of the circuit breaker to the given state
+func (r *PrometheusRecorder) IncCircuitBreakerTransition(typeName, method, state string) {
+	r.breakerTransitions.WithLabelValues(typeName, method, state).Inc()
+}
@@ -0,0 +1,40 @@
+package runner
+
+import (
+	"errors"
+
+	rerrors "github.com/slok/goresilience/errors"
+)
+
+const (
+	// TimeoutRejection is the rejection of the calls that timed out
+	TimeoutRejection = "timeout"
+	// CircuitBreakerRejection is the rejection of the calls made while the circuit breaker is open
+	CircuitBreakerRejection = "circuitbreaker"
+	// BulkheadRejection is the rejection of the calls that timed out waiting for a bulkhead worker
+	BulkheadRejection = "bulkhead"
+	// ConcurrencyLimitRejection is the rejection of the calls that exceeded the concurrency limit
+	ConcurrencyLimitRejection = "concurrencylimit"
+	// ContextRejection is the rejection of the calls that weren't executed because their context was cancelled
+	ContextRejection = "context"
+)
+
+// rejections maps the errors emitted by the middlewares to the middleware that rejected the call
+var rejections = map[error]string{
+	rerrors.ErrTimeout:                    TimeoutRejection,
+	rerrors.ErrCircuitOpen:                CircuitBreakerRejection,
+	rerrors.ErrTimeoutWaitingForExecution: BulkheadRejection,
+	rerrors.ErrRejectedExecution:          ConcurrencyLimitRejection,
+	rerrors.ErrContextCanceled:            ContextRejection,
+}
+
+// Rejection determines which middleware rejected a call from the error returned by the runner (e.g. timeout or
+// circuitbreaker), it's empty when the error wasn't emitted by a middleware.
+func Rejection(err error) string {
+	for rejectionErr, rejection := range rejections {
+		if errors.Is(err, rejectionErr) {
+			return rejection
+		}
+	}
+	return ""
+}
@@ -0,0 +1,32 @@
+package runner_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/clear-street/reinforcer/pkg/runner"
+	"github.com/slok/goresilience/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRejection(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "No error", err: nil, want: ""},
+		{name: "Delegate error", err: fmt.Errorf("failure"), want: ""},
+		{name: "Timeout", err: errors.ErrTimeout, want: runner.TimeoutRejection},
+		{name: "Circuit breaker", err: errors.ErrCircuitOpen, want: runner.CircuitBreakerRejection},
+		{name: "Bulkhead", err: errors.ErrTimeoutWaitingForExecution, want: runner.BulkheadRejection},
+		{name: "Concurrency limit", err: errors.ErrRejectedExecution, want: runner.ConcurrencyLimitRejection},
+		{name: "Context", err: errors.ErrContextCanceled, want: runner.ContextRejection},
+		{name: "Wrapped", err: fmt.Errorf("call failed; error=%w", errors.ErrCircuitOpen), want: runner.CircuitBreakerRejection},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, runner.Rejection(tt.err))
+		})
+	}
+}
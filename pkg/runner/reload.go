@@ -0,0 +1,119 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/slok/goresilience"
+)
+
+type reloadConfig struct {
+	preserveState bool
+}
+
+// ReloadOption configures how a Factory reloads its policy
+type ReloadOption func(*reloadConfig)
+
+// PreserveState keeps the runners whose middleware policy is the same in the old and the new policy, this way the state
+// of their middlewares (e.g. an open circuit breaker) survives the reload. Runners whose policy changed always start
+// with a fresh state.
+func PreserveState() ReloadOption {
+	return func(c *reloadConfig) {
+		c.preserveState = true
+	}
+}
+
+// Reload swaps the policy used to build the factory's runners, a nil policy removes it. Calls that are in-flight finish
+// on the runner they were started with while new calls pick up the runners built from the new policy. Chains registered
+// with WithMethodMiddlewares or WithTypeMiddlewares still take precedence over the policy. This is thread-safe.
+func (f *Factory) Reload(p *Policy, opts ...ReloadOption) error {
+	if p != nil {
+		if err := p.compile(); err != nil {
+			return err
+		}
+	}
+	cfg := &reloadConfig{}
+	for _, o := range opts {
+		o(cfg)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	runners := make(map[string]goresilience.Runner)
+	if cfg.preserveState {
+		for name, r := range f.runners {
+			if f.registered(name) || reflect.DeepEqual(f.policy.policyFor(name), p.policyFor(name)) {
+				runners[name] = r
+			}
+		}
+	}
+	f.runners = runners
+	f.policy = p
+	return nil
+}
+
+// WatchPolicyFile polls the policy file every interval and reloads the factory whenever the file is modified, it blocks
+// until the context is done. Errors reading or parsing the file are reported to onError (if not nil) and leave the
+// current policy in place.
+func (f *Factory) WatchPolicyFile(ctx context.Context, filename string, interval time.Duration, onError func(error), opts ...ReloadOption) {
+	report := func(err error) {
+		if onError != nil {
+			onError(err)
+		}
+	}
+
+	var lastMod time.Time
+	var lastSize int64
+	if info, err := os.Stat(filename); err == nil {
+		lastMod, lastSize = info.ModTime(), info.Size()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(filename)
+		if err != nil {
+			report(err)
+			continue
+		}
+		if info.ModTime().Equal(lastMod) && info.Size() == lastSize {
+			continue
+		}
+		lastMod, lastSize = info.ModTime(), info.Size()
+
+		p, err := LoadPolicyFile(filename)
+		if err != nil {
+			report(err)
+			continue
+		}
+		if err := f.Reload(p, opts...); err != nil {
+			report(err)
+		}
+	}
+}
+
+// registered determines if the runner with the given name is built from a chain registered in code rather than from the
+// policy. The caller must hold the lock.
+func (f *Factory) registered(name string) bool {
+	if _, ok := f.methodMiddlewares[name]; ok {
+		return true
+	}
+	typeName, method := SplitName(name)
+	if typeName == "" {
+		return false
+	}
+	if _, ok := f.typeMiddlewares[typeName]; ok {
+		return true
+	}
+	_, ok := f.methodMiddlewares[method]
+	return ok
+}
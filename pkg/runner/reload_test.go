@@ -0,0 +1,133 @@
+package runner_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/clear-street/reinforcer/pkg/runner"
+	"github.com/slok/goresilience/errors"
+	"github.com/stretchr/testify/require"
+)
+
+const breakerPolicy = `
+rules:
+  - match: Client.*
+    circuitbreaker:
+      errorPercentThresholdToOpen: 1
+      minimumRequestToOpen: 1
+      waitDurationInOpenState: 1m
+`
+
+func TestFactory_Reload(t *testing.T) {
+	fail := func(ctx context.Context) error {
+		return fmt.Errorf("failure")
+	}
+	succeed := func(ctx context.Context) error {
+		return nil
+	}
+	openBreaker := func(t *testing.T, f *runner.Factory) {
+		require.Error(t, f.GetRunner("Client.GetUser").Run(context.Background(), fail))
+		require.ErrorIs(t, f.GetRunner("Client.GetUser").Run(context.Background(), succeed), errors.ErrCircuitOpen)
+	}
+
+	t.Run("New calls use the new policy", func(t *testing.T) {
+		p := mustParsePolicy(t, "default:\n  timeout:\n    timeout: 10ms\n")
+		f, err := runner.NewFactoryFromPolicy(p)
+		require.NoError(t, err)
+
+		slow := func(ctx context.Context) error {
+			time.Sleep(50 * time.Millisecond)
+			return nil
+		}
+		old := f.GetRunner("Client.GetUser")
+		require.ErrorIs(t, old.Run(context.Background(), slow), errors.ErrTimeout)
+
+		require.NoError(t, f.Reload(mustParsePolicy(t, "default:\n  timeout:\n    timeout: 1s\n")))
+		require.NoError(t, f.GetRunner("Client.GetUser").Run(context.Background(), slow))
+
+		// Runners obtained before the reload keep their chain
+		require.ErrorIs(t, old.Run(context.Background(), slow), errors.ErrTimeout)
+	})
+
+	t.Run("Resets state by default", func(t *testing.T) {
+		f, err := runner.NewFactoryFromPolicy(mustParsePolicy(t, breakerPolicy))
+		require.NoError(t, err)
+		openBreaker(t, f)
+
+		require.NoError(t, f.Reload(mustParsePolicy(t, breakerPolicy)))
+		require.NoError(t, f.GetRunner("Client.GetUser").Run(context.Background(), succeed))
+	})
+
+	t.Run("Preserves state of unchanged runners", func(t *testing.T) {
+		f, err := runner.NewFactoryFromPolicy(mustParsePolicy(t, breakerPolicy))
+		require.NoError(t, err)
+		openBreaker(t, f)
+
+		require.NoError(t, f.Reload(mustParsePolicy(t, breakerPolicy), runner.PreserveState()))
+		require.ErrorIs(t, f.GetRunner("Client.GetUser").Run(context.Background(), succeed), errors.ErrCircuitOpen)
+
+		// The policy for the runner changed so its state can't be preserved
+		require.NoError(t, f.Reload(mustParsePolicy(t, "rules:\n  - match: Client.*\n    retry:\n      times: 1\n"), runner.PreserveState()))
+		require.NoError(t, f.GetRunner("Client.GetUser").Run(context.Background(), succeed))
+	})
+
+	t.Run("Invalid policy", func(t *testing.T) {
+		f := runner.NewFactory()
+		require.Error(t, f.Reload(&runner.Policy{Rules: []*runner.Rule{{Match: "/(/"}}}))
+	})
+}
+
+func TestFactory_WatchPolicyFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(filename, []byte("rules: []\n"), 0600))
+
+	f, err := runner.NewFactoryFromPolicy(mustParsePolicy(t, "rules: []\n"))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errs := make(chan error, 10)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f.WatchPolicyFile(ctx, filename, time.Millisecond, func(err error) {
+			errs <- err
+		})
+	}()
+
+	// Give the watcher time to take a snapshot of the file before it's modified
+	time.Sleep(20 * time.Millisecond)
+
+	calls := 0
+	failing := func(ctx context.Context) error {
+		calls++
+		return fmt.Errorf("failure")
+	}
+	require.NoError(t, os.WriteFile(filename, []byte("rules:\n  - match: Client.GetUser\n    retry:\n      times: 1\n      waitBase: 1ms\n"), 0600))
+	require.Eventually(t, func() bool {
+		calls = 0
+		_ = f.GetRunner("Client.GetUser").Run(context.Background(), failing)
+		return calls == 2
+	}, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, os.WriteFile(filename, []byte("rules:\n  - unknown: true\n"), 0600))
+	select {
+	case err := <-errs:
+		require.Error(t, err)
+	case <-time.After(time.Second):
+		require.Fail(t, "expected an error for the invalid policy")
+	}
+
+	cancel()
+	<-done
+}
+
+func mustParsePolicy(t *testing.T, doc string) *runner.Policy {
+	p, err := runner.ParsePolicy([]byte(doc))
+	require.NoError(t, err)
+	return p
+}
@@ -1,26 +1,107 @@
 package runner
 
 import (
-	"github.com/slok/goresilience"
+	"strings"
 	"sync"
+
+	"github.com/slok/goresilience"
 )
 
+// nameSeparator separates the type name from the method name in a qualified runner name
+const nameSeparator = "."
+
 // Factory of runners
 type Factory struct {
-	mu          sync.RWMutex
-	runners     map[string]goresilience.Runner
-	middlewares []goresilience.Middleware
+	mu                sync.RWMutex
+	runners           map[string]goresilience.Runner
+	middlewares       []goresilience.Middleware
+	methodMiddlewares map[string][]goresilience.Middleware
+	typeMiddlewares   map[string][]goresilience.Middleware
+	policy            *Policy
+	// idempotent holds the names of the idempotent methods when retries are gated, nil otherwise
+	idempotent map[string]struct{}
+	// recorder records the metrics of the calls when set
+	recorder Recorder
 }
 
 // NewFactory creates an instance of a Runner factory that will create runners on demand if they don't exist otherwise
-// return a singleton instance of a runner for each unique runner identifier.
+// return a singleton instance of a runner for each unique runner identifier. The given middlewares are the default
+// chain used for every runner that doesn't have a more specific chain registered. The retry middlewares must be wrapped
+// in Retry for the factory to skip them (see WithIdempotentMethods), an unwrapped retry middleware retries every call.
 func NewFactory(middlewares ...goresilience.Middleware) *Factory {
 	return &Factory{
-		runners:     make(map[string]goresilience.Runner),
-		middlewares: middlewares,
+		runners:           make(map[string]goresilience.Runner),
+		middlewares:       middlewares,
+		methodMiddlewares: make(map[string][]goresilience.Middleware),
+		typeMiddlewares:   make(map[string][]goresilience.Middleware),
 	}
 }
 
+// QualifiedName builds the runner name used by the generated code for the given type and method (e.g. Client.GetUser)
+func QualifiedName(typeName, method string) string {
+	if typeName == "" {
+		return method
+	}
+	return typeName + nameSeparator + method
+}
+
+// SplitName splits a runner name into its type and method names, the type name is empty when the runner name is not
+// qualified. The name is split on its last dot, the names given with the //reinforcer:runner directive can't contain
+// dots so that they're never split.
+func SplitName(name string) (typeName, method string) {
+	if idx := strings.LastIndex(name, nameSeparator); idx >= 0 {
+		return name[:idx], name[idx+1:]
+	}
+	return "", name
+}
+
+// WithMethodMiddlewares registers the middlewares used to build the runner for the given method in lieu of the default
+// middlewares. The name can be qualified with the type (e.g. Client.GetUser) to target the method of a single type or
+// be the bare method name (e.g. GetUser) to target the method in every type. Any runner already created for the method
+// is discarded so that subsequent calls to GetRunner use the new chain, unless a more specific chain still applies to
+// it. This is thread-safe and returns the factory to allow chaining.
+func (f *Factory) WithMethodMiddlewares(name string, middlewares ...goresilience.Middleware) *Factory {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.methodMiddlewares[name] = middlewares
+	f.discard(func(runnerName string) bool {
+		if runnerName == name {
+			return true
+		}
+		typeName, method := SplitName(runnerName)
+		if typeName == "" || method != name {
+			return false
+		}
+		// The qualified method and the type chains take precedence over the bare method chain
+		if _, ok := f.methodMiddlewares[runnerName]; ok {
+			return false
+		}
+		_, ok := f.typeMiddlewares[typeName]
+		return !ok
+	})
+	return f
+}
+
+// WithTypeMiddlewares registers the middlewares used to build the runners for all the methods of the given type in lieu
+// of the default middlewares. Chains registered for a qualified method name take precedence over the type's chain. Any
+// runner already created for the type without such a chain is discarded so that subsequent calls to GetRunner use the
+// new chain. This is thread-safe and returns the factory to allow chaining.
+func (f *Factory) WithTypeMiddlewares(typeName string, middlewares ...goresilience.Middleware) *Factory {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.typeMiddlewares[typeName] = middlewares
+	f.discard(func(runnerName string) bool {
+		if t, _ := SplitName(runnerName); t != typeName {
+			return false
+		}
+		_, ok := f.methodMiddlewares[runnerName]
+		return !ok
+	})
+	return f
+}
+
 // GetRunner retrieves a runner with the given name, this is guaranteed to always return a Runner. This is thread-safe.
 func (f *Factory) GetRunner(name string) goresilience.Runner {
 	f.mu.RLock()
@@ -38,7 +119,47 @@ func (f *Factory) GetRunner(name string) goresilience.Runner {
 	if r, ok := f.runners[name]; ok {
 		return r
 	}
-	runner := goresilience.RunnerChain(f.middlewares...)
+	var runner goresilience.Runner
+	if f.recorder != nil {
+		runner = recordMetrics(f.recorder, name, f.middlewaresFor(name))
+	} else {
+		runner = goresilience.RunnerChain(f.middlewaresFor(name)...)
+	}
+	if f.skipsRetries(name) {
+		runner = SkipRetries(runner)
+	}
 	f.runners[name] = runner
 	return runner
 }
+
+// middlewaresFor resolves the middleware chain for the given runner name, the most specific chain wins: qualified
+// method, type, bare method, policy and finally the default chain. The caller must hold the lock.
+func (f *Factory) middlewaresFor(name string) []goresilience.Middleware {
+	if middlewares, ok := f.methodMiddlewares[name]; ok {
+		return middlewares
+	}
+	typeName, method := SplitName(name)
+	if typeName != "" {
+		if middlewares, ok := f.typeMiddlewares[typeName]; ok {
+			return middlewares
+		}
+		if middlewares, ok := f.methodMiddlewares[method]; ok {
+			return middlewares
+		}
+	}
+	if f.policy != nil {
+		if middlewares, ok := f.policy.middlewaresFor(name); ok {
+			return middlewares
+		}
+	}
+	return f.middlewares
+}
+
+// discard removes the cached runners matched by the given predicate. The caller must hold the write lock.
+func (f *Factory) discard(match func(runnerName string) bool) {
+	for runnerName := range f.runners {
+		if match(runnerName) {
+			delete(f.runners, runnerName)
+		}
+	}
+}
@@ -37,3 +37,100 @@ func TestFactory_GetRunner(t *testing.T) {
 	require.Equal(t, 4, mwCalled)
 	require.Equal(t, 2, mwCreated)
 }
+
+func TestFactory_WithMethodMiddlewares(t *testing.T) {
+	var calls []string
+	mw := func(id string) goresilience.Middleware {
+		return func(r goresilience.Runner) goresilience.Runner {
+			return goresilience.RunnerFunc(func(ctx context.Context, f goresilience.Func) error {
+				calls = append(calls, id)
+				return r.Run(ctx, f)
+			})
+		}
+	}
+	noop := func(ctx context.Context) error {
+		return nil
+	}
+
+	f := runner.NewFactory(mw("default")).
+		WithMethodMiddlewares("SaveFile", mw("slow"), mw("batch"))
+
+	require.NoError(t, f.GetRunner("GetUser").Run(context.Background(), noop))
+	require.NoError(t, f.GetRunner("SaveFile").Run(context.Background(), noop))
+	require.Equal(t, []string{"default", "slow", "batch"}, calls)
+
+	t.Run("Replaces previously created runner", func(t *testing.T) {
+		calls = nil
+		f.WithMethodMiddlewares("GetUser", mw("fast"))
+		require.NoError(t, f.GetRunner("GetUser").Run(context.Background(), noop))
+		require.Equal(t, []string{"fast"}, calls)
+	})
+}
+
+func TestFactory_WithTypeMiddlewares(t *testing.T) {
+	var calls []string
+	mw := func(id string) goresilience.Middleware {
+		return func(r goresilience.Runner) goresilience.Runner {
+			return goresilience.RunnerFunc(func(ctx context.Context, f goresilience.Func) error {
+				calls = append(calls, id)
+				return r.Run(ctx, f)
+			})
+		}
+	}
+	noop := func(ctx context.Context) error {
+		return nil
+	}
+
+	f := runner.NewFactory(mw("default")).
+		WithTypeMiddlewares("Client", mw("client")).
+		WithMethodMiddlewares("Get", mw("get")).
+		WithMethodMiddlewares("Client.Save", mw("client-save"))
+
+	for _, name := range []string{"Client.Get", "Client.Save", "Client.List", "Service.Get", "Service.List", "Get"} {
+		require.NoError(t, f.GetRunner(name).Run(context.Background(), noop))
+	}
+	require.Equal(t, []string{"client", "client-save", "client", "get", "default", "get"}, calls)
+}
+
+func TestFactory_WithMethodMiddlewares_KeepsOverriddenRunners(t *testing.T) {
+	created := map[string]int{}
+	mw := func(id string) goresilience.Middleware {
+		return func(r goresilience.Runner) goresilience.Runner {
+			created[id]++
+			return r
+		}
+	}
+
+	f := runner.NewFactory(mw("default")).
+		WithTypeMiddlewares("Client", mw("client")).
+		WithMethodMiddlewares("Service.Get", mw("service-get"))
+	for _, name := range []string{"Client.Get", "Service.Get", "Other.Get", "Client.List"} {
+		f.GetRunner(name)
+	}
+	require.Equal(t, map[string]int{"client": 2, "service-get": 1, "default": 1}, created)
+
+	f.WithMethodMiddlewares("Get", mw("get"))
+	for _, name := range []string{"Client.Get", "Service.Get", "Other.Get", "Client.List"} {
+		f.GetRunner(name)
+	}
+	require.Equal(t, map[string]int{"client": 2, "service-get": 1, "default": 1, "get": 1}, created)
+
+	f.WithTypeMiddlewares("Service", mw("service"))
+	for _, name := range []string{"Service.Get", "Service.List"} {
+		f.GetRunner(name)
+	}
+	require.Equal(t, map[string]int{"client": 2, "service-get": 1, "default": 1, "get": 1, "service": 1}, created)
+}
+
+func TestQualifiedName(t *testing.T) {
+	require.Equal(t, "Client.GetUser", runner.QualifiedName("Client", "GetUser"))
+	require.Equal(t, "GetUser", runner.QualifiedName("", "GetUser"))
+
+	typeName, method := runner.SplitName("Client.GetUser")
+	require.Equal(t, "Client", typeName)
+	require.Equal(t, "GetUser", method)
+
+	typeName, method = runner.SplitName("GetUser")
+	require.Equal(t, "", typeName)
+	require.Equal(t, "GetUser", method)
+}
@@ -0,0 +1,161 @@
+// Package tracing traces the calls to the types generated by reinforcer with OpenTelemetry, every call produces a span
+// with a child span per attempt. The generated code only uses this package when it's generated with --tracing.
+package tracing
+
+import (
+	"context"
+	"sync"
+
+	"github.com/clear-street/reinforcer/pkg/runner"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the tracer used to create the spans
+const instrumentationName = "github.com/clear-street/reinforcer"
+
+// Attributes of the spans
+const (
+	// TypeKey is the generated type that was called
+	TypeKey = attribute.Key("reinforcer.type")
+	// MethodKey is the method that was called
+	MethodKey = attribute.Key("reinforcer.method")
+	// AttemptKey is the number of the attempt, starting at 1
+	AttemptKey = attribute.Key("reinforcer.attempt")
+	// AttemptsKey is the number of attempts made by the call
+	AttemptsKey = attribute.Key("reinforcer.attempts")
+	// OutcomeKey is the final outcome of the call (e.g. runner.SuccessOutcome)
+	OutcomeKey = attribute.Key("reinforcer.outcome")
+	// NonRetryableKey is set when the error was classified as non-retryable by the error predicate
+	NonRetryableKey = attribute.Key("reinforcer.non_retryable")
+	// RejectedByKey is the middleware that rejected the call (e.g. timeout or circuitbreaker), see runner.Rejection
+	RejectedByKey = attribute.Key("reinforcer.rejected_by")
+)
+
+// callKey is the context key of the call being traced
+type callKey struct{}
+
+// Tracer creates the spans of the calls to the generated types
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// NewTracer creates a Tracer that creates its spans with the given tracer provider, the global tracer provider is used
+// when it's nil (see otel.SetTracerProvider)
+func NewTracer(tp trace.TracerProvider) *Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return &Tracer{
+		tracer: tp.Tracer(instrumentationName),
+	}
+}
+
+// Call is a call to a method of a generated type, it spans all the attempts made by the middlewares
+type Call struct {
+	tracer trace.Tracer
+	span   trace.Span
+	name   string
+
+	mu           sync.Mutex
+	attempts     int
+	nonRetryable bool
+}
+
+// StartCall starts the span of a call to the given method, the returned context carries the call so that the attempts
+// are started as child spans of the call
+func (t *Tracer) StartCall(ctx context.Context, typeName, method string) (context.Context, *Call) {
+	name := runner.QualifiedName(typeName, method)
+	ctx, span := t.tracer.Start(ctx, name, trace.WithAttributes(
+		TypeKey.String(typeName),
+		MethodKey.String(method),
+	))
+	call := &Call{
+		tracer: t.tracer,
+		span:   span,
+		name:   name,
+	}
+	return context.WithValue(ctx, callKey{}, call), call
+}
+
+// End ends the span of the call with the error returned by the middlewares
+func (c *Call) End(err error) {
+	c.mu.Lock()
+	attempts, nonRetryable := c.attempts, c.nonRetryable
+	c.mu.Unlock()
+
+	c.span.SetAttributes(AttemptsKey.Int(attempts))
+	switch rejection := runner.Rejection(err); {
+	case rejection != "":
+		c.span.SetAttributes(OutcomeKey.String(runner.RejectedOutcome), RejectedByKey.String(rejection))
+		c.span.RecordError(err)
+		c.span.SetStatus(codes.Error, err.Error())
+	case err != nil:
+		c.span.SetAttributes(OutcomeKey.String(runner.ErrorOutcome))
+		c.span.RecordError(err)
+		c.span.SetStatus(codes.Error, err.Error())
+	case nonRetryable:
+		// The middlewares don't see the non-retryable errors, they're returned to the caller as-is
+		c.span.SetAttributes(OutcomeKey.String(runner.NonRetryableOutcome), NonRetryableKey.Bool(true))
+		c.span.SetStatus(codes.Error, "non-retryable error")
+	default:
+		c.span.SetAttributes(OutcomeKey.String(runner.SuccessOutcome))
+	}
+	c.span.End()
+}
+
+// Attempt is an attempt of a call made by the middlewares
+type Attempt struct {
+	span trace.Span
+	call *Call
+}
+
+// StartAttempt starts the span of an attempt of the call carried by the given context, the attempt isn't traced when
+// the context doesn't carry a call
+func StartAttempt(ctx context.Context) (context.Context, *Attempt) {
+	call, ok := ctx.Value(callKey{}).(*Call)
+	if !ok {
+		return ctx, &Attempt{}
+	}
+	call.mu.Lock()
+	call.attempts++
+	attempt := call.attempts
+	call.mu.Unlock()
+
+	ctx, span := call.tracer.Start(ctx, call.name+".attempt", trace.WithAttributes(AttemptKey.Int(attempt)))
+	return ctx, &Attempt{
+		span: span,
+		call: call,
+	}
+}
+
+// RecordError records the error returned by the delegate, nil errors are ignored
+func (a *Attempt) RecordError(err error) {
+	if a.span == nil || err == nil {
+		return
+	}
+	a.span.RecordError(err)
+	a.span.SetStatus(codes.Error, err.Error())
+}
+
+// NonRetryable flags the error of the attempt as non-retryable, the error is returned to the caller without being
+// retried. Nil errors are ignored.
+func (a *Attempt) NonRetryable(err error) {
+	if a.span == nil || err == nil {
+		return
+	}
+	a.span.SetAttributes(NonRetryableKey.Bool(true))
+	a.call.mu.Lock()
+	a.call.nonRetryable = true
+	a.call.mu.Unlock()
+}
+
+// End ends the span of the attempt
+func (a *Attempt) End() {
+	if a.span == nil {
+		return
+	}
+	a.span.End()
+}
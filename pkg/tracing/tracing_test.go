@@ -0,0 +1,146 @@
+package tracing_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/clear-street/reinforcer/pkg/runner"
+	"github.com/clear-street/reinforcer/pkg/tracing"
+	"github.com/slok/goresilience"
+	"github.com/slok/goresilience/circuitbreaker"
+	"github.com/slok/goresilience/retry"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// call traces a call the way the generated code does, the delegate is called once per attempt
+func call(t *tracing.Tracer, r goresilience.Runner, retryable func(error) bool, delegate func(ctx context.Context) error) error {
+	ctx, c := t.StartCall(context.Background(), "Client", "GetUser")
+	err := r.Run(ctx, func(ctx context.Context) error {
+		ctx, attempt := tracing.StartAttempt(ctx)
+		defer attempt.End()
+		err := delegate(ctx)
+		attempt.RecordError(err)
+		if retryable(err) {
+			return err
+		}
+		attempt.NonRetryable(err)
+		return nil
+	})
+	c.End(err)
+	return err
+}
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestTracer(t *testing.T) {
+	retryAll := func(error) bool {
+		return true
+	}
+	newTracer := func() (*tracing.Tracer, *tracetest.InMemoryExporter) {
+		exporter := tracetest.NewInMemoryExporter()
+		return tracing.NewTracer(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))), exporter
+	}
+
+	t.Run("Attempts are child spans of the call", func(t *testing.T) {
+		tracer, exporter := newTracer()
+		calls := 0
+		err := call(tracer, retry.New(retry.Config{Times: 2, DisableBackoff: true}), retryAll, func(ctx context.Context) error {
+			calls++
+			if calls < 3 {
+				return fmt.Errorf("failure")
+			}
+			return nil
+		})
+		require.NoError(t, err)
+
+		spans := exporter.GetSpans().Snapshots()
+		require.Len(t, spans, 4)
+		callSpan := spans[3]
+		require.Equal(t, "Client.GetUser", callSpan.Name())
+		require.Equal(t, map[attribute.Key]attribute.Value{
+			tracing.TypeKey:     attribute.StringValue("Client"),
+			tracing.MethodKey:   attribute.StringValue("GetUser"),
+			tracing.AttemptsKey: attribute.IntValue(3),
+			tracing.OutcomeKey:  attribute.StringValue(runner.SuccessOutcome),
+		}, attributes(callSpan))
+		for i, attemptSpan := range spans[:3] {
+			require.Equal(t, "Client.GetUser.attempt", attemptSpan.Name())
+			require.Equal(t, callSpan.SpanContext().SpanID(), attemptSpan.Parent().SpanID())
+			require.Equal(t, attribute.IntValue(i+1), attributes(attemptSpan)[tracing.AttemptKey])
+		}
+		require.Equal(t, codes.Error, spans[0].Status().Code)
+		require.Equal(t, codes.Unset, spans[2].Status().Code)
+	})
+
+	t.Run("Exhausted retries", func(t *testing.T) {
+		tracer, exporter := newTracer()
+		err := call(tracer, retry.New(retry.Config{Times: 1, DisableBackoff: true}), retryAll, func(ctx context.Context) error {
+			return fmt.Errorf("failure")
+		})
+		require.Error(t, err)
+
+		spans := exporter.GetSpans().Snapshots()
+		require.Len(t, spans, 3)
+		attrs := attributes(spans[2])
+		require.Equal(t, attribute.StringValue(runner.ErrorOutcome), attrs[tracing.OutcomeKey])
+		require.Equal(t, attribute.IntValue(2), attrs[tracing.AttemptsKey])
+		require.Equal(t, codes.Error, spans[2].Status().Code)
+	})
+
+	t.Run("Non-retryable errors", func(t *testing.T) {
+		tracer, exporter := newTracer()
+		err := call(tracer, retry.New(retry.Config{Times: 2, DisableBackoff: true}), func(error) bool {
+			return false
+		}, func(ctx context.Context) error {
+			return fmt.Errorf("not found")
+		})
+		require.NoError(t, err)
+
+		spans := exporter.GetSpans().Snapshots()
+		require.Len(t, spans, 2)
+		require.Equal(t, attribute.BoolValue(true), attributes(spans[0])[tracing.NonRetryableKey])
+		attrs := attributes(spans[1])
+		require.Equal(t, attribute.StringValue(runner.NonRetryableOutcome), attrs[tracing.OutcomeKey])
+		require.Equal(t, attribute.BoolValue(true), attrs[tracing.NonRetryableKey])
+	})
+
+	t.Run("Rejected calls", func(t *testing.T) {
+		tracer, exporter := newTracer()
+		breaker := circuitbreaker.New(circuitbreaker.Config{
+			MinimumRequestToOpen:        1,
+			ErrorPercentThresholdToOpen: 1,
+		})
+		failing := func(ctx context.Context) error {
+			return fmt.Errorf("failure")
+		}
+		require.Error(t, call(tracer, breaker, retryAll, failing))
+		require.Error(t, call(tracer, breaker, retryAll, failing))
+
+		spans := exporter.GetSpans().Snapshots()
+		require.Len(t, spans, 3)
+		attrs := attributes(spans[2])
+		require.Equal(t, attribute.StringValue(runner.RejectedOutcome), attrs[tracing.OutcomeKey])
+		require.Equal(t, attribute.StringValue(runner.CircuitBreakerRejection), attrs[tracing.RejectedByKey])
+		require.Equal(t, attribute.IntValue(0), attrs[tracing.AttemptsKey])
+	})
+}
+
+func TestStartAttempt(t *testing.T) {
+	// Attempts of calls that aren't traced are no-ops
+	ctx, attempt := tracing.StartAttempt(context.Background())
+	require.Equal(t, context.Background(), ctx)
+	attempt.RecordError(fmt.Errorf("failure"))
+	attempt.NonRetryable(fmt.Errorf("failure"))
+	attempt.End()
+}